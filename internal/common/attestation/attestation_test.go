@@ -0,0 +1,165 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attestation_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/snyk/container-cli/internal/common/attestation"
+	"github.com/snyk/container-cli/internal/common/attestation/attestationtest"
+	"github.com/stretchr/testify/require"
+)
+
+var testDigest = digest.FromString("manifest")
+
+func Test_SignAndVerify_GivenKeyPair_ShouldRoundTripStatement(t *testing.T) {
+	for _, keyType := range []attestationtest.KeyType{
+		attestationtest.ECDSAP256, attestationtest.ECDSAP384, attestationtest.Ed25519,
+	} {
+		t.Run(string(keyType), func(t *testing.T) {
+			keys := attestationtest.GenerateKeyPair(t, keyType)
+			signer, err := attestation.LoadSigner(keys.PrivateKeyPath)
+			require.NoError(t, err)
+			verifier, err := attestation.LoadVerifier(keys.PublicKeyPath)
+			require.NoError(t, err)
+			require.Equal(t, signer.KeyID(), verifier.KeyID())
+
+			statement := attestation.NewStatement(
+				"alpine", testDigest, attestation.PredicateTypeCycloneDX, json.RawMessage(`{"bomFormat":"CycloneDX"}`),
+			)
+			payload, err := json.Marshal(statement)
+			require.NoError(t, err)
+
+			envelope, err := attestation.Sign(attestation.PayloadTypeInToto, payload, signer)
+			require.NoError(t, err)
+			require.Equal(t, attestation.PayloadTypeInToto, envelope.PayloadType)
+			require.Len(t, envelope.Signatures, 1)
+			require.Equal(t, signer.KeyID(), envelope.Signatures[0].KeyID)
+
+			verified, err := envelope.Verify(verifier)
+			require.NoError(t, err)
+
+			var got attestation.Statement
+			require.NoError(t, json.Unmarshal(verified, &got))
+			require.Equal(t, attestation.StatementType, got.Type)
+			require.Equal(t, []attestation.Subject{
+				{Name: "alpine", Digest: map[string]string{"sha256": testDigest.Encoded()}},
+			}, got.Subject)
+			require.JSONEq(t, `{"bomFormat":"CycloneDX"}`, string(got.Predicate))
+		})
+	}
+}
+
+func Test_Verify_GivenTamperedEnvelope_ShouldReturnErrNoValidSignature(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.ECDSAP256)
+	signer, err := attestation.LoadSigner(keys.PrivateKeyPath)
+	require.NoError(t, err)
+	verifier, err := attestation.LoadVerifier(keys.PublicKeyPath)
+	require.NoError(t, err)
+
+	tests := map[string]func(e *attestation.Envelope){
+		"payload": func(e *attestation.Envelope) {
+			e.Payload = base64.StdEncoding.EncodeToString([]byte(`{"tampered":true}`))
+		},
+		"payload type": func(e *attestation.Envelope) { e.PayloadType = "application/json" },
+		"signature": func(e *attestation.Envelope) {
+			e.Signatures[0].Sig = base64.StdEncoding.EncodeToString([]byte("x"))
+		},
+		"key id": func(e *attestation.Envelope) { e.Signatures[0].KeyID = "other" },
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			envelope, err := attestation.Sign(attestation.PayloadTypeInToto, []byte(`{}`), signer)
+			require.NoError(t, err)
+
+			tamper(envelope)
+
+			_, err = envelope.Verify(verifier)
+			require.ErrorIs(t, err, attestation.ErrNoValidSignature)
+		})
+	}
+}
+
+func Test_Verify_GivenOtherKey_ShouldReturnErrNoValidSignature(t *testing.T) {
+	signer, err := attestation.LoadSigner(attestationtest.GenerateKeyPair(t, attestationtest.Ed25519).PrivateKeyPath)
+	require.NoError(t, err)
+	verifier, err := attestation.LoadVerifier(attestationtest.GenerateKeyPair(t, attestationtest.Ed25519).PublicKeyPath)
+	require.NoError(t, err)
+
+	envelope, err := attestation.Sign(attestation.PayloadTypeInToto, []byte(`{}`), signer)
+	require.NoError(t, err)
+
+	_, err = envelope.Verify(verifier)
+	require.ErrorIs(t, err, attestation.ErrNoValidSignature)
+}
+
+func Test_LoadSigner_GivenSEC1ECKey_ShouldLoadKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	attestationtest.WritePEM(t, path, "EC PRIVATE KEY", der)
+
+	signer, err := attestation.LoadSigner(path)
+	require.NoError(t, err)
+
+	verifier, err := attestation.NewVerifier(&key.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, verifier.KeyID(), signer.KeyID())
+}
+
+func Test_LoadSigner_GivenUnsupportedKey_ShouldReturnError(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaDER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	attestationtest.WritePEM(t, rsaPath, "PRIVATE KEY", rsaDER)
+	encryptedPath := filepath.Join(dir, "encrypted.pem")
+	attestationtest.WritePEM(t, encryptedPath, "ENCRYPTED PRIVATE KEY", []byte("x"))
+	publicPath := attestationtest.GenerateKeyPair(t, attestationtest.Ed25519).PublicKeyPath
+
+	tests := map[string]struct {
+		path          string
+		expectedError string
+	}{
+		"rsa key":        {rsaPath, "unsupported private key type *rsa.PrivateKey"},
+		"encrypted key":  {encryptedPath, `unsupported PEM block "ENCRYPTED PRIVATE KEY"`},
+		"public key":     {publicPath, `unsupported PEM block "PUBLIC KEY"`},
+		"missing file":   {filepath.Join(dir, "missing.pem"), "no such file or directory"},
+		"not a pem file": {filepath.Join("attestationtest", "attestationtest.go"), "does not contain a PEM encoded key"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := attestation.LoadSigner(tc.path)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
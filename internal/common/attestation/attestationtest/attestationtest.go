@@ -0,0 +1,86 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package attestationtest generates signing keys for tests.
+package attestationtest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// KeyType is the kind of key to generate.
+type KeyType string
+
+const (
+	ECDSAP256 KeyType = "ecdsa-p256"
+	ECDSAP384 KeyType = "ecdsa-p384"
+	Ed25519   KeyType = "ed25519"
+)
+
+// KeyPair holds the paths of a PEM encoded private key in PKCS#8 format and its public key.
+type KeyPair struct {
+	PrivateKeyPath string
+	PublicKeyPath  string
+}
+
+// GenerateKeyPair writes a new key pair of the given type into a temporary directory.
+func GenerateKeyPair(t *testing.T, keyType KeyType) KeyPair {
+	t.Helper()
+
+	var private crypto.Signer
+	var err error
+	switch keyType {
+	case ECDSAP256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ECDSAP384:
+		private, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case Ed25519:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unknown key type %q", keyType)
+	}
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	pair := KeyPair{
+		PrivateKeyPath: filepath.Join(dir, "key.pem"),
+		PublicKeyPath:  filepath.Join(dir, "key.pub"),
+	}
+	WritePEM(t, pair.PrivateKeyPath, "PRIVATE KEY", privateDER)
+	WritePEM(t, pair.PublicKeyPath, "PUBLIC KEY", publicDER)
+	return pair
+}
+
+// WritePEM writes a single PEM block to path.
+func WritePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
@@ -0,0 +1,91 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package attestation wraps SBOMs into signed in-toto attestations, using the DSSE envelope format
+// (https://github.com/secure-systems-lab/dsse).
+package attestation
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const (
+	// PayloadTypeInToto is the DSSE payload type of in-toto statements.
+	PayloadTypeInToto = "application/vnd.in-toto+json"
+	// MediaTypeEnvelope is the media type of a JSON encoded DSSE envelope.
+	MediaTypeEnvelope = "application/vnd.dsse.envelope.v1+json"
+)
+
+// Envelope is a DSSE envelope. The payload is base64 encoded, as in its JSON representation.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of a DSSE envelope.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// ErrNoValidSignature is returned if none of the signatures of an envelope were made with the key.
+var ErrNoValidSignature = errors.New("envelope has no valid signature for the key")
+
+// Sign wraps the payload into an envelope signed by the signer.
+func Sign(payloadType string, payload []byte, signer Signer) (*Envelope, error) {
+	sig, err := signer.Sign(pae(payloadType, payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign envelope: %w", err)
+	}
+
+	return &Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []Signature{{
+			KeyID: signer.KeyID(),
+			Sig:   base64.StdEncoding.EncodeToString(sig),
+		}},
+	}, nil
+}
+
+// Verify checks that the envelope carries a valid signature of the verifier's key and returns the payload.
+func (e *Envelope) Verify(verifier Verifier) ([]byte, error) {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope payload: %w", err)
+	}
+
+	message := pae(e.PayloadType, payload)
+	for _, s := range e.Signatures {
+		if s.KeyID != "" && s.KeyID != verifier.KeyID() {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if verifier.Verify(message, sig) == nil {
+			return payload, nil
+		}
+	}
+	return nil, ErrNoValidSignature
+}
+
+// pae returns the pre-authentication encoding of the payload, which is what gets signed.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
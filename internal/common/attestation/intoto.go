@@ -0,0 +1,56 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attestation
+
+import (
+	"encoding/json"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// StatementType is the type of in-toto v1 statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	PredicateTypeCycloneDX = "https://cyclonedx.org/bom"
+	PredicateTypeSPDX      = "https://spdx.dev/Document"
+)
+
+// Statement is an in-toto statement, see https://github.com/in-toto/attestation/tree/main/spec/v1.
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// Subject is an artifact an in-toto statement is about, identified by its digest.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// NewStatement returns a statement with the predicate about the artifact with the given name and digest.
+func NewStatement(name string, d digest.Digest, predicateType string, predicate json.RawMessage) *Statement {
+	return &Statement{
+		Type: StatementType,
+		Subject: []Subject{{
+			Name:   name,
+			Digest: map[string]string{d.Algorithm().String(): d.Encoded()},
+		}},
+		PredicateType: predicateType,
+		Predicate:     predicate,
+	}
+}
@@ -0,0 +1,209 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attestation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Signer signs messages with a private key.
+type Signer interface {
+	// KeyID identifies the key, it is derived from the public key.
+	KeyID() string
+	Sign(message []byte) ([]byte, error)
+}
+
+// Verifier verifies signatures with a public key.
+type Verifier interface {
+	// KeyID identifies the key, it matches the KeyID of the corresponding Signer.
+	KeyID() string
+	Verify(message, sig []byte) error
+}
+
+// LoadSigner reads an unencrypted ECDSA or Ed25519 private key from a PEM file.
+func LoadSigner(path string) (Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s, expected an unencrypted private key", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in %s: %w", path, err)
+	}
+	return NewSigner(key)
+}
+
+// LoadVerifier reads an ECDSA or Ed25519 public key from a PEM file.
+func LoadVerifier(path string) (Verifier, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported PEM block %q in %s, expected a public key", block.Type, path)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %s: %w", path, err)
+	}
+	return NewVerifier(key)
+}
+
+// NewSigner returns a signer for an *ecdsa.PrivateKey or an ed25519.PrivateKey.
+func NewSigner(key any) (Signer, error) {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		keyID, err := keyIDOf(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsaSigner{key: k, keyID: keyID}, nil
+	case ed25519.PrivateKey:
+		keyID, err := keyIDOf(k.Public())
+		if err != nil {
+			return nil, err
+		}
+		return &ed25519Signer{key: k, keyID: keyID}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T, expected ECDSA or Ed25519", key)
+	}
+}
+
+// NewVerifier returns a verifier for an *ecdsa.PublicKey or an ed25519.PublicKey.
+func NewVerifier(key any) (Verifier, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		keyID, err := keyIDOf(k)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsaVerifier{key: k, keyID: keyID}, nil
+	case ed25519.PublicKey:
+		keyID, err := keyIDOf(k)
+		if err != nil {
+			return nil, err
+		}
+		return &ed25519Verifier{key: k, keyID: keyID}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T, expected ECDSA or Ed25519", key)
+	}
+}
+
+type ecdsaSigner struct {
+	key   *ecdsa.PrivateKey
+	keyID string
+}
+
+func (s *ecdsaSigner) KeyID() string { return s.keyID }
+
+func (s *ecdsaSigner) Sign(message []byte) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, s.key, digestFor(s.key.Curve, message))
+}
+
+type ecdsaVerifier struct {
+	key   *ecdsa.PublicKey
+	keyID string
+}
+
+func (v *ecdsaVerifier) KeyID() string { return v.keyID }
+
+func (v *ecdsaVerifier) Verify(message, sig []byte) error {
+	if !ecdsa.VerifyASN1(v.key, digestFor(v.key.Curve, message), sig) {
+		return errors.New("invalid ECDSA signature")
+	}
+	return nil
+}
+
+type ed25519Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+func (s *ed25519Signer) KeyID() string { return s.keyID }
+
+func (s *ed25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, message), nil
+}
+
+type ed25519Verifier struct {
+	key   ed25519.PublicKey
+	keyID string
+}
+
+func (v *ed25519Verifier) KeyID() string { return v.keyID }
+
+func (v *ed25519Verifier) Verify(message, sig []byte) error {
+	if !ed25519.Verify(v.key, message, sig) {
+		return errors.New("invalid Ed25519 signature")
+	}
+	return nil
+}
+
+// digestFor hashes the message with the hash matching the strength of the curve.
+func digestFor(curve elliptic.Curve, message []byte) []byte {
+	h := crypto.SHA256
+	switch curve.Params().BitSize {
+	case 384:
+		h = crypto.SHA384
+	case 521:
+		h = crypto.SHA512
+	}
+	hasher := h.New()
+	hasher.Write(message)
+	return hasher.Sum(nil)
+}
+
+// keyIDOf returns the hex encoded SHA-256 of the DER encoded public key.
+func keyIDOf(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded key", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,222 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache is an on-disk cache of analysis results. Entries expire after a time to live, and the
+// least recently used entries are evicted once the cache exceeds its maximum size.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTTL is the time entries are kept for if not configured otherwise.
+	DefaultTTL = 24 * time.Hour
+	// DefaultMaxSize is the size in bytes the cache is limited to if not configured otherwise.
+	DefaultMaxSize = 512 << 20
+
+	entryExtension = ".entry"
+)
+
+// Config configures the cache.
+type Config struct {
+	// Dir is the directory the entries are stored in, which is created on demand.
+	Dir string
+	// TTL is the time entries are kept for after they were stored.
+	TTL time.Duration
+	// MaxSize is the total size in bytes of the entries the cache is limited to.
+	MaxSize int64
+}
+
+// Cache stores values by key on disk. It is safe for concurrent use, including by multiple processes.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	now     func() time.Time
+}
+
+// New returns the cache stored in the configured directory.
+func New(cfg Config) *Cache {
+	return &Cache{dir: cfg.Dir, ttl: cfg.TTL, maxSize: cfg.MaxSize, now: time.Now}
+}
+
+// Dir returns the directory the entries are stored in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// entry is the file format of a cached value.
+type entry struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"createdAt"`
+	Value     []byte    `json:"value"`
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+entryExtension)
+}
+
+// Get returns the value stored for the key, unless it expired. Reading a value marks it as recently used.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	path := c.path(key)
+	e, err := readEntry(path)
+	if err != nil || e.Key != key {
+		return nil, false
+	}
+	if c.expired(e) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	// the modification time records the last use of the entry, which eviction is based on.
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+	return e.Value, true
+}
+
+// Put stores the value for the key, evicting the least recently used entries if the cache grows beyond
+// its maximum size.
+func (c *Cache) Put(key string, value []byte) error {
+	now := c.now()
+	data, err := json.Marshal(entry{Key: key, CreatedAt: now, Value: value})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+
+	// entries are written to a temporary file first, so concurrent readers never see partial entries.
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), now, now); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return err
+	}
+
+	// expired entries are left to Get and Prune, which spares reading all entries on every write.
+	_, err = c.prune(false, false)
+	return err
+}
+
+// PruneResult tells what pruning removed from the cache.
+type PruneResult struct {
+	// Removed is the number of removed entries.
+	Removed int
+	// Freed is the size in bytes of the removed entries.
+	Freed int64
+	// Remaining is the number of entries left in the cache.
+	Remaining int
+}
+
+// Prune removes the expired entries and evicts the least recently used entries until the cache fits its
+// maximum size. All entries are removed if all is set.
+func (c *Cache) Prune(all bool) (PruneResult, error) {
+	return c.prune(all, true)
+}
+
+type entryFile struct {
+	path    string
+	size    int64
+	usedAt  time.Time
+	expired bool
+}
+
+func (c *Cache) prune(all, expire bool) (PruneResult, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return PruneResult{}, nil
+	}
+	if err != nil {
+		return PruneResult{}, err
+	}
+
+	var files []entryFile
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), entryExtension) {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		f := entryFile{path: filepath.Join(c.dir, d.Name()), size: info.Size(), usedAt: info.ModTime()}
+		// the creation time is only known from the entry itself.
+		if expire && !all && c.ttl > 0 {
+			e, err := readEntry(f.path)
+			f.expired = err != nil || c.expired(e)
+		}
+		files = append(files, f)
+	}
+
+	// the most recently used entries come first, so the tail is evicted.
+	slices.SortFunc(files, func(a, b entryFile) int { return b.usedAt.Compare(a.usedAt) })
+
+	var result PruneResult
+	var size int64
+	for _, f := range files {
+		keep := !all && !f.expired && (c.maxSize <= 0 || size+f.size <= c.maxSize)
+		if keep {
+			size += f.size
+			result.Remaining++
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return result, fmt.Errorf("could not remove %s: %w", f.path, err)
+		}
+		result.Removed++
+		result.Freed += f.size
+	}
+	return result, nil
+}
+
+func (c *Cache) expired(e *entry) bool {
+	return c.ttl > 0 && c.now().Sub(e.CreatedAt) > c.ttl
+}
+
+func readEntry(path string) (*entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
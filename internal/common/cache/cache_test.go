@@ -0,0 +1,153 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache returns a cache in a temporary directory with a clock the test controls.
+func newTestCache(t *testing.T, ttl time.Duration, maxSize int64) (*Cache, *time.Time) {
+	t.Helper()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := New(Config{Dir: filepath.Join(t.TempDir(), "cache"), TTL: ttl, MaxSize: maxSize})
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func Test_Get_GivenStoredValue_ShouldReturnItUntilExpired(t *testing.T) {
+	c, now := newTestCache(t, time.Hour, DefaultMaxSize)
+
+	_, ok := c.Get("alpine@sha256:abc")
+	require.False(t, ok)
+
+	require.NoError(t, c.Put("alpine@sha256:abc", []byte("depgraph")))
+	value, ok := c.Get("alpine@sha256:abc")
+	require.True(t, ok)
+	require.Equal(t, []byte("depgraph"), value)
+
+	*now = now.Add(2 * time.Hour)
+	_, ok = c.Get("alpine@sha256:abc")
+	require.False(t, ok)
+
+	entries, err := os.ReadDir(c.Dir())
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func Test_Put_GivenCacheExceedingMaxSize_ShouldEvictLeastRecentlyUsed(t *testing.T) {
+	c, now := newTestCache(t, time.Hour, 0)
+
+	for _, key := range []string{"first", "second"} {
+		require.NoError(t, c.Put(key, make([]byte, 32)))
+		*now = now.Add(time.Minute)
+	}
+	_, ok := c.Get("first")
+	require.True(t, ok)
+	*now = now.Add(time.Minute)
+
+	info, err := os.Stat(c.path("first"))
+	require.NoError(t, err)
+	c.maxSize = 2*info.Size() + 10
+
+	require.NoError(t, c.Put("third", make([]byte, 32)))
+
+	_, ok = c.Get("first")
+	require.True(t, ok)
+	_, ok = c.Get("second")
+	require.False(t, ok)
+	_, ok = c.Get("third")
+	require.True(t, ok)
+}
+
+func Test_Prune_GivenEntries_ShouldRemoveExpiredOrAll(t *testing.T) {
+	c, now := newTestCache(t, time.Hour, DefaultMaxSize)
+
+	require.NoError(t, c.Put("old", []byte("depgraph")))
+	*now = now.Add(45 * time.Minute)
+	require.NoError(t, c.Put("new", []byte("depgraph")))
+	*now = now.Add(30 * time.Minute)
+
+	result, err := c.Prune(false)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Removed)
+	require.Positive(t, result.Freed)
+	require.Equal(t, 1, result.Remaining)
+
+	result, err = c.Prune(true)
+	require.NoError(t, err)
+	require.Equal(t, PruneResult{Removed: 1, Freed: result.Freed, Remaining: 0}, result)
+}
+
+func Test_Prune_GivenMissingDirectory_ShouldDoNothing(t *testing.T) {
+	c, _ := newTestCache(t, time.Hour, DefaultMaxSize)
+
+	result, err := c.Prune(true)
+	require.NoError(t, err)
+	require.Equal(t, PruneResult{}, result)
+}
+
+func Test_NewFromConfiguration_GivenFlags_ShouldConfigureCache(t *testing.T) {
+	config := configuration.NewInMemory()
+	config.Set(configuration.CACHE_PATH, "/tmp/snyk")
+
+	c, err := NewFromConfiguration(config)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("/tmp/snyk", "container-depgraphs"), c.Dir())
+	require.Equal(t, DefaultTTL, c.ttl)
+	require.Equal(t, int64(DefaultMaxSize), c.maxSize)
+
+	config.Set(flags.FlagCacheDir.Name, "/var/cache/depgraphs")
+	config.Set(flags.FlagCacheTTL.Name, "90m")
+	config.Set(flags.FlagCacheMaxSize.Name, "64")
+
+	c, err = NewFromConfiguration(config)
+	require.NoError(t, err)
+	require.Equal(t, "/var/cache/depgraphs", c.Dir())
+	require.Equal(t, 90*time.Minute, c.ttl)
+	require.Equal(t, int64(64<<20), c.maxSize)
+}
+
+func Test_NewFromConfiguration_GivenInvalidFlags_ShouldReturnError(t *testing.T) {
+	tests := map[string]struct {
+		flag          string
+		value         string
+		expectedError string
+	}{
+		"ttl":           {flags.FlagCacheTTL.Name, "a day", "invalid cache TTL 0s"},
+		"negative ttl":  {flags.FlagCacheTTL.Name, "-1h", "invalid cache TTL -1h0m0s"},
+		"max size":      {flags.FlagCacheMaxSize.Name, "1GB", "invalid cache size 0"},
+		"zero max size": {flags.FlagCacheMaxSize.Name, "0", "invalid cache size 0"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.NewInMemory()
+			config.Set(flags.FlagCacheDir.Name, t.TempDir())
+			config.Set(tc.flag, tc.value)
+
+			_, err := NewFromConfiguration(config)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+)
+
+// depGraphsDir is the directory within the snyk cache directory depgraphs are cached in.
+const depGraphsDir = "container-depgraphs"
+
+// NewFromConfiguration returns the depgraph cache configured by the cache flags. The cache lives in the
+// cache directory of the snyk CLI unless configured otherwise.
+func NewFromConfiguration(config configuration.Configuration) (*Cache, error) {
+	var cfg Config
+
+	cfg.Dir = flags.FlagCacheDir.GetFlagValue(config)
+	if cfg.Dir == "" {
+		base := config.GetString(configuration.CACHE_PATH)
+		if base == "" {
+			userCacheDir, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("could not determine the cache directory: %w", err)
+			}
+			base = filepath.Join(userCacheDir, "snyk")
+		}
+		cfg.Dir = filepath.Join(base, depGraphsDir)
+	}
+
+	if cfg.TTL = flags.FlagCacheTTL.GetFlagValue(config); cfg.TTL <= 0 {
+		return nil, fmt.Errorf("invalid cache TTL %s, expected a positive duration such as 12h", cfg.TTL)
+	}
+
+	maxSize := flags.FlagCacheMaxSize.GetFlagValue(config)
+	if maxSize < 1 {
+		return nil, fmt.Errorf("invalid cache size %d, expected a positive number of MB", maxSize)
+	}
+	cfg.MaxSize = int64(maxSize) << 20
+
+	return New(cfg), nil
+}
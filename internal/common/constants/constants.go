@@ -23,9 +23,19 @@ const WorkflowIdentifierLegacyCli = "legacycli"
 
 const ContainerTargetArgName = "targetDirectory"
 
+// DepGraphDeadlineArgName holds the time.Time by which the depgraph workflow has to finish its analysis.
+const DepGraphDeadlineArgName = "snyk_container_depgraph_deadline"
+
 const (
 	/* HTTP Headers */
-	HeaderContentLocation = "Content-Location"
-	HeaderContentType     = "Content-Type"
-	ContentTypeJSON       = "application/json"
+	HeaderContentEncoding   = "Content-Encoding"
+	HeaderContentLocation   = "Content-Location"
+	HeaderContentType       = "Content-Type"
+	HeaderDeprecation       = "Deprecation"
+	HeaderLocation          = "Location"
+	HeaderSunset            = "Sunset"
+	HeaderSnykRequestID     = "snyk-request-id"
+	HeaderSnykVersionServed = "snyk-version-served"
+	ContentTypeJSON         = "application/json"
+	ContentTypeJSONAPI      = "application/vnd.api+json"
 )
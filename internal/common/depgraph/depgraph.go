@@ -0,0 +1,161 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SchemaVersion is the dep-graph schema version produced by this package.
+const SchemaVersion = "1.2.0"
+
+// RootNodeID is the node id used for the root of every dep-graph.
+const RootNodeID = "root-node"
+
+// DepGraph represents the JSON dep-graph document exchanged with the Snyk APIs.
+type DepGraph struct {
+	SchemaVersion string     `json:"schemaVersion"`
+	PkgManager    PkgManager `json:"pkgManager"`
+	Pkgs          []Pkg      `json:"pkgs"`
+	Graph         Graph      `json:"graph"`
+}
+
+type PkgManager struct {
+	Name         string       `json:"name"`
+	Repositories []Repository `json:"repositories,omitempty"`
+}
+
+type Repository struct {
+	Alias string `json:"alias"`
+}
+
+type Pkg struct {
+	ID   string  `json:"id"`
+	Info PkgInfo `json:"info"`
+}
+
+type PkgInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+type Graph struct {
+	RootNodeID string `json:"rootNodeId"`
+	Nodes      []Node `json:"nodes"`
+}
+
+type Node struct {
+	NodeID string `json:"nodeId"`
+	PkgID  string `json:"pkgId"`
+	Deps   []Dep  `json:"deps"`
+}
+
+type Dep struct {
+	NodeID string `json:"nodeId"`
+}
+
+// PkgID returns the canonical package id for the given name and version.
+func PkgID(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return fmt.Sprintf("%s@%s", name, version)
+}
+
+// Builder incrementally builds a dep-graph in which every package is a direct dependency of the root package.
+type Builder struct {
+	graph DepGraph
+	seen  map[string]bool
+}
+
+// NewBuilder creates a new Builder with the given package manager and root package.
+func NewBuilder(pkgManager PkgManager, root PkgInfo) *Builder {
+	rootID := PkgID(root.Name, root.Version)
+	return &Builder{
+		graph: DepGraph{
+			SchemaVersion: SchemaVersion,
+			PkgManager:    pkgManager,
+			Pkgs:          []Pkg{{ID: rootID, Info: root}},
+			Graph: Graph{
+				RootNodeID: RootNodeID,
+				Nodes:      []Node{{NodeID: RootNodeID, PkgID: rootID, Deps: []Dep{}}},
+			},
+		},
+		seen: map[string]bool{rootID: true},
+	}
+}
+
+// AddDependency adds the package as a direct dependency of the root package. Duplicates are ignored.
+func (b *Builder) AddDependency(info PkgInfo) {
+	id := PkgID(info.Name, info.Version)
+	if b.seen[id] {
+		return
+	}
+	b.seen[id] = true
+
+	b.graph.Pkgs = append(b.graph.Pkgs, Pkg{ID: id, Info: info})
+	b.graph.Graph.Nodes = append(b.graph.Graph.Nodes, Node{NodeID: id, PkgID: id, Deps: []Dep{}})
+	b.graph.Graph.Nodes[0].Deps = append(b.graph.Graph.Nodes[0].Deps, Dep{NodeID: id})
+}
+
+// Build returns the dep-graph built so far.
+func (b *Builder) Build() *DepGraph {
+	g := b.graph
+	return &g
+}
+
+// Validate checks that the dep-graph satisfies the invariants consumers of the schema rely on.
+func (g *DepGraph) Validate() error {
+	if major, _, _ := strings.Cut(g.SchemaVersion, "."); major != "1" {
+		return fmt.Errorf("unsupported schemaVersion %q", g.SchemaVersion)
+	}
+	if g.PkgManager.Name == "" {
+		return errors.New("pkgManager.name must not be empty")
+	}
+	if g.Graph.RootNodeID == "" {
+		return errors.New("graph.rootNodeId must not be empty")
+	}
+
+	pkgIDs := make(map[string]bool, len(g.Pkgs))
+	for i, p := range g.Pkgs {
+		if p.ID == "" || p.Info.Name == "" {
+			return fmt.Errorf("pkgs[%d] must have an id and info.name", i)
+		}
+		pkgIDs[p.ID] = true
+	}
+
+	nodeIDs := make(map[string]bool, len(g.Graph.Nodes))
+	for i, n := range g.Graph.Nodes {
+		if !pkgIDs[n.PkgID] {
+			return fmt.Errorf("graph.nodes[%d] references unknown pkgId %q", i, n.PkgID)
+		}
+		nodeIDs[n.NodeID] = true
+	}
+	if !nodeIDs[g.Graph.RootNodeID] {
+		return fmt.Errorf("graph.rootNodeId %q does not reference a node", g.Graph.RootNodeID)
+	}
+	for i, n := range g.Graph.Nodes {
+		for _, dep := range n.Deps {
+			if !nodeIDs[dep.NodeID] {
+				return fmt.Errorf("graph.nodes[%d] depends on unknown node %q", i, dep.NodeID)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph_test
+
+import (
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Builder_GivenDuplicateDependencies_ShouldAddThemOnce(t *testing.T) {
+	b := depgraph.NewBuilder(depgraph.PkgManager{Name: "apk"}, depgraph.PkgInfo{Name: "docker-image|alpine", Version: "3"})
+	b.AddDependency(depgraph.PkgInfo{Name: "musl", Version: "1.2"})
+	b.AddDependency(depgraph.PkgInfo{Name: "musl", Version: "1.2"})
+
+	g := b.Build()
+
+	require.NoError(t, g.Validate())
+	require.Len(t, g.Pkgs, 2)
+	require.Equal(t, []depgraph.Dep{{NodeID: "musl@1.2"}}, g.Graph.Nodes[0].Deps)
+}
+
+func Test_Validate_GivenInvalidDepGraph_ShouldReturnError(t *testing.T) {
+	valid := func() *depgraph.DepGraph {
+		b := depgraph.NewBuilder(depgraph.PkgManager{Name: "deb"}, depgraph.PkgInfo{Name: "docker-image|debian"})
+		b.AddDependency(depgraph.PkgInfo{Name: "bash", Version: "5"})
+		return b.Build()
+	}
+
+	tests := map[string]struct {
+		mutate        func(g *depgraph.DepGraph)
+		expectedError string
+	}{
+		"schema version": {
+			func(g *depgraph.DepGraph) { g.SchemaVersion = "2.0.0" },
+			`unsupported schemaVersion "2.0.0"`,
+		},
+		"package manager": {
+			func(g *depgraph.DepGraph) { g.PkgManager.Name = "" },
+			"pkgManager.name must not be empty",
+		},
+		"unknown root node": {
+			func(g *depgraph.DepGraph) { g.Graph.RootNodeID = "nope" },
+			`graph.rootNodeId "nope" does not reference a node`,
+		},
+		"unknown package": {
+			func(g *depgraph.DepGraph) { g.Graph.Nodes[1].PkgID = "nope" },
+			`graph.nodes[1] references unknown pkgId "nope"`,
+		},
+		"unknown dependency": {
+			func(g *depgraph.DepGraph) { g.Graph.Nodes[0].Deps[0].NodeID = "nope" },
+			`graph.nodes[0] depends on unknown node "nope"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := valid()
+			tc.mutate(g)
+			require.EqualError(t, g.Validate(), tc.expectedError)
+		})
+	}
+}
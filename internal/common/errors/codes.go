@@ -0,0 +1,104 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// Category groups error codes by what caused the error.
+type Category string
+
+const (
+	// CategoryInput errors are caused by invalid flags, arguments or files.
+	CategoryInput Category = "input"
+	// CategoryAuth errors are caused by missing or insufficient credentials for the Snyk API.
+	CategoryAuth Category = "auth"
+	// CategoryAPI errors are reported by the Snyk API.
+	CategoryAPI Category = "api"
+	// CategoryRegistry errors are caused by accessing the container registry.
+	CategoryRegistry Category = "registry"
+	// CategoryAnalysis errors are caused by the analysis of the container image.
+	CategoryAnalysis Category = "analysis"
+	// CategoryInterrupted errors report workflows which were stopped by their timeout or by the user.
+	CategoryInterrupted Category = "interrupted"
+	// CategoryPolicy errors report SBOMs or attestations which failed the checks they were subjected to.
+	CategoryPolicy Category = "policy"
+	// CategoryInternal errors are unexpected.
+	CategoryInternal Category = "internal"
+)
+
+// Code identifies a kind of error. Codes never change their meaning, so automation can branch on them.
+type Code struct {
+	ID       string
+	Category Category
+}
+
+// The codes of all container errors. New codes are appended, codes are never reused. SNYK-CONTAINER-0011 reported
+// any failure of the legacy CLI before its failures were classified, and is retired.
+var (
+	CodeInternal                   = Code{"SNYK-CONTAINER-0001", CategoryInternal}
+	CodeEmptyOrg                   = Code{"SNYK-CONTAINER-0002", CategoryAuth}
+	CodeUnauthorized               = Code{"SNYK-CONTAINER-0003", CategoryAuth}
+	CodeForbidden                  = Code{"SNYK-CONTAINER-0004", CategoryAuth}
+	CodeConfigFile                 = Code{"SNYK-CONTAINER-0005", CategoryInput}
+	CodeCacheConfig                = Code{"SNYK-CONTAINER-0006", CategoryInput}
+	CodeRemote                     = Code{"SNYK-CONTAINER-0007", CategoryAPI}
+	CodeBadRequest                 = Code{"SNYK-CONTAINER-0008", CategoryAPI}
+	CodeRateLimited                = Code{"SNYK-CONTAINER-0009", CategoryAPI}
+	CodeDepGraphWorkflow           = Code{"SNYK-CONTAINER-0010", CategoryAnalysis}
+	CodeNativeAnalyzer             = Code{"SNYK-CONTAINER-0012", CategoryAnalysis}
+	CodeDepGraphOutput             = Code{"SNYK-CONTAINER-0013", CategoryAnalysis}
+	CodeEmptySbomFormat            = Code{"SNYK-CONTAINER-0014", CategoryInput}
+	CodeInvalidSbomFormat          = Code{"SNYK-CONTAINER-0015", CategoryInput}
+	CodeInvalidPlatform            = Code{"SNYK-CONTAINER-0016", CategoryInput}
+	CodeTargetsFile                = Code{"SNYK-CONTAINER-0017", CategoryInput}
+	CodeInvalidConcurrency         = Code{"SNYK-CONTAINER-0018", CategoryInput}
+	CodeMultipleSbomsFailed        = Code{"SNYK-CONTAINER-0019", CategoryAnalysis}
+	CodeOutputFile                 = Code{"SNYK-CONTAINER-0020", CategoryInput}
+	CodeOutputFileTemplate         = Code{"SNYK-CONTAINER-0021", CategoryInput}
+	CodeSigningKey                 = Code{"SNYK-CONTAINER-0022", CategoryInput}
+	CodeAttestationFormat          = Code{"SNYK-CONTAINER-0023", CategoryInput}
+	CodeSubjectDigest              = Code{"SNYK-CONTAINER-0024", CategoryRegistry}
+	CodeAttachLocalImage           = Code{"SNYK-CONTAINER-0025", CategoryInput}
+	CodeAttach                     = Code{"SNYK-CONTAINER-0026", CategoryRegistry}
+	CodeInvalidVulnerabilitiesMode = Code{"SNYK-CONTAINER-0027", CategoryInput}
+	CodeVulnerabilitiesFormat      = Code{"SNYK-CONTAINER-0028", CategoryInput}
+	CodeVulnerabilitiesOffline     = Code{"SNYK-CONTAINER-0029", CategoryInput}
+	CodeVexWithoutVulnerabilities  = Code{"SNYK-CONTAINER-0030", CategoryInput}
+	CodeVexFile                    = Code{"SNYK-CONTAINER-0031", CategoryInput}
+	CodePolicyFile                 = Code{"SNYK-CONTAINER-0032", CategoryInput}
+	CodePolicyViolation            = Code{"SNYK-CONTAINER-0033", CategoryPolicy}
+	CodeInvalidInputCount          = Code{"SNYK-CONTAINER-0034", CategoryInput}
+	CodeSbomDocument               = Code{"SNYK-CONTAINER-0035", CategoryInput}
+	CodePrune                      = Code{"SNYK-CONTAINER-0036", CategoryInternal}
+	CodeEmptyAttestation           = Code{"SNYK-CONTAINER-0037", CategoryInput}
+	CodeEmptyPublicKey             = Code{"SNYK-CONTAINER-0038", CategoryInput}
+	CodePublicKey                  = Code{"SNYK-CONTAINER-0039", CategoryInput}
+	CodeAttestation                = Code{"SNYK-CONTAINER-0040", CategoryInput}
+	CodeVerificationFailed         = Code{"SNYK-CONTAINER-0041", CategoryPolicy}
+	CodeImageNotFound              = Code{"SNYK-CONTAINER-0042", CategoryRegistry}
+	CodeRegistryAuth               = Code{"SNYK-CONTAINER-0043", CategoryRegistry}
+	CodeUnsupportedPlatform        = Code{"SNYK-CONTAINER-0044", CategoryInput}
+	CodeArchiveUnreadable          = Code{"SNYK-CONTAINER-0045", CategoryInput}
+	CodeAnalysisTimeout            = Code{"SNYK-CONTAINER-0046", CategoryAnalysis}
+	CodeInvalidTimeout             = Code{"SNYK-CONTAINER-0047", CategoryInput}
+	CodeTimeout                    = Code{"SNYK-CONTAINER-0048", CategoryInterrupted}
+	CodeCanceled                   = Code{"SNYK-CONTAINER-0049", CategoryInterrupted}
+	CodeInvalidSbomAPIPath         = Code{"SNYK-CONTAINER-0050", CategoryInput}
+	CodeSbomAPIUnavailable         = Code{"SNYK-CONTAINER-0051", CategoryAPI}
+	CodeSbomJobFailed              = Code{"SNYK-CONTAINER-0052", CategoryAPI}
+	CodeSbomJobTimeout             = Code{"SNYK-CONTAINER-0053", CategoryAPI}
+	CodeMissingSbomAPIPath         = Code{"SNYK-CONTAINER-0054", CategoryInput}
+	CodeAnalyzerCrash              = Code{"SNYK-CONTAINER-0055", CategoryAnalysis}
+	CodeInvalidRetryMaxAttempts    = Code{"SNYK-CONTAINER-0056", CategoryInput}
+	CodeInvalidRetryMaxBackoff     = Code{"SNYK-CONTAINER-0057", CategoryInput}
+)
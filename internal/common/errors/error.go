@@ -14,11 +14,71 @@
 
 package errors
 
+import (
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/redact"
+)
+
 type ContainerExtensionError struct {
+	code    Code
 	err     error
 	userMsg string
+
+	// details reported by the Snyk API for a failed request, if any
+	apiErrorCode string
+	requestID    string
+	detail       string
 }
 
 func (xerr ContainerExtensionError) Error() string {
-	return xerr.userMsg
+	if xerr.detail == "" && xerr.apiErrorCode == "" && xerr.requestID == "" {
+		return xerr.userMsg
+	}
+
+	var sb strings.Builder
+	sb.WriteString(xerr.userMsg)
+	if xerr.detail != "" {
+		sb.WriteString("\nDetails: " + xerr.detail)
+	}
+	if xerr.apiErrorCode != "" {
+		sb.WriteString("\nError code: " + xerr.apiErrorCode)
+	}
+	if xerr.requestID != "" {
+		sb.WriteString("\nRequest ID: " + xerr.requestID)
+	}
+	return sb.String()
+}
+
+// WithAPIError attaches the details the Snyk API reported for a failed request to the error.
+func (xerr *ContainerExtensionError) WithAPIError(code, requestID, detail string) *ContainerExtensionError {
+	xerr.apiErrorCode = code
+	xerr.requestID = requestID
+	xerr.detail = redact.String(detail)
+	return xerr
+}
+
+// APIErrorCode returns the error code reported by the Snyk API, if any.
+func (xerr ContainerExtensionError) APIErrorCode() string {
+	return xerr.apiErrorCode
+}
+
+// RequestID returns the ID of the failed Snyk API request, if any.
+func (xerr ContainerExtensionError) RequestID() string {
+	return xerr.requestID
+}
+
+// Detail returns the explanation of the failure reported by the Snyk API, if any.
+func (xerr ContainerExtensionError) Detail() string {
+	return xerr.detail
+}
+
+// Code returns the code identifying the kind of error.
+func (xerr ContainerExtensionError) Code() Code {
+	return xerr.code
+}
+
+// Unwrap returns the error which caused the failure.
+func (xerr ContainerExtensionError) Unwrap() error {
+	return xerr.err
 }
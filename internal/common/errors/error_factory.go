@@ -16,6 +16,7 @@ package errors
 
 import (
 	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/redact"
 )
 
 type ErrorFactory struct {
@@ -28,11 +29,15 @@ func NewErrorFactory(logger *zerolog.Logger) *ErrorFactory {
 	}
 }
 
-func (ef *ErrorFactory) NewError(err error, userMsg string) *ContainerExtensionError {
-	ef.logger.Error().Err(err).Send()
+// NewError returns an error of the given kind with a message for the user. Secrets are redacted from both
+// the error and the message.
+func (ef *ErrorFactory) NewError(code Code, err error, userMsg string) *ContainerExtensionError {
+	err = redact.Error(err)
+	ef.logger.Error().Err(err).Str("code", code.ID).Send()
 
 	return &ContainerExtensionError{
+		code:    code,
 		err:     err,
-		userMsg: userMsg,
+		userMsg: redact.String(userMsg),
 	}
 }
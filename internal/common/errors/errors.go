@@ -18,8 +18,17 @@ import "fmt"
 
 func (ef *ErrorFactory) NewEmptyOrgError() *ContainerExtensionError {
 	return ef.NewError(
+		CodeEmptyOrg,
 		fmt.Errorf("failed to determine org id"),
 		"Snyk failed to infer an organization ID. Please make sure to authenticate using `snyk auth`. "+
 			"Should the issue persist, explicitly set an organization ID via the `--org` flag.",
 	)
 }
+
+func (ef *ErrorFactory) NewConfigFileError(err error) *ContainerExtensionError {
+	return ef.NewError(
+		CodeConfigFile,
+		fmt.Errorf("could not read the configuration: %w", err),
+		fmt.Sprintf("The configuration could not be read: %s.", err),
+	)
+}
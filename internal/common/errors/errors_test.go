@@ -0,0 +1,92 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	zlog "github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewError_GivenCode_ShouldExposeCodeAndCause(t *testing.T) {
+	err := NewErrorFactory(&zlog.Logger).NewError(CodeTargetsFile, fmt.Errorf("open: %w", fs.ErrNotExist), "message")
+
+	require.Equal(t, "SNYK-CONTAINER-0017", err.Code().ID)
+	require.Equal(t, CategoryInput, err.Code().Category)
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func Test_Codes_ShouldBeUnique(t *testing.T) {
+	codes := []Code{
+		CodeInternal, CodeEmptyOrg, CodeUnauthorized, CodeForbidden, CodeConfigFile, CodeCacheConfig, CodeRemote,
+		CodeBadRequest, CodeRateLimited, CodeDepGraphWorkflow, CodeNativeAnalyzer, CodeDepGraphOutput,
+		CodeEmptySbomFormat, CodeInvalidSbomFormat, CodeInvalidPlatform, CodeTargetsFile, CodeInvalidConcurrency,
+		CodeMultipleSbomsFailed, CodeOutputFile, CodeOutputFileTemplate, CodeSigningKey, CodeAttestationFormat,
+		CodeSubjectDigest, CodeAttachLocalImage, CodeAttach, CodeInvalidVulnerabilitiesMode, CodeVulnerabilitiesFormat,
+		CodeVulnerabilitiesOffline, CodeVexWithoutVulnerabilities, CodeVexFile, CodePolicyFile, CodePolicyViolation,
+		CodeInvalidInputCount, CodeSbomDocument, CodePrune, CodeEmptyAttestation, CodeEmptyPublicKey, CodePublicKey,
+		CodeAttestation, CodeVerificationFailed, CodeImageNotFound, CodeRegistryAuth, CodeUnsupportedPlatform,
+		CodeArchiveUnreadable, CodeAnalysisTimeout, CodeInvalidTimeout, CodeTimeout, CodeCanceled,
+		CodeInvalidSbomAPIPath, CodeSbomAPIUnavailable, CodeSbomJobFailed, CodeSbomJobTimeout, CodeMissingSbomAPIPath,
+		CodeAnalyzerCrash, CodeInvalidRetryMaxAttempts, CodeInvalidRetryMaxBackoff,
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range codes {
+		require.False(t, seen[c.ID], "duplicate code %s", c.ID)
+		require.NotEmpty(t, c.Category)
+		seen[c.ID] = true
+	}
+}
+
+func Test_NewJSONError_GivenError_ShouldRenderCodeMessageAndDetail(t *testing.T) {
+	ef := NewErrorFactory(&zlog.Logger)
+
+	tests := map[string]struct {
+		err      error
+		expected string
+	}{
+		"container error": {
+			ef.NewError(CodeConfigFile, errors.New("bad yaml"), "The configuration file could not be read."),
+			`{"code":"SNYK-CONTAINER-0005","category":"input",` +
+				`"message":"The configuration file could not be read.","detail":"bad yaml"}`,
+		},
+		"api error": {
+			ef.NewError(CodeBadRequest, errors.New("400"), "Bad input.").WithAPIError("SNYK-0003", "req", "no org"),
+			`{"code":"SNYK-CONTAINER-0008","category":"api","message":"Bad input.","detail":"no org"}`,
+		},
+		"same detail": {
+			ef.NewError(CodeImageNotFound, errors.New("image not found"), "image not found"),
+			`{"code":"SNYK-CONTAINER-0042","category":"registry","message":"image not found"}`,
+		},
+		"other error": {
+			errors.New("boom"),
+			`{"code":"SNYK-CONTAINER-0001","category":"internal","message":"boom"}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewJSONError(tc.err)
+
+			require.JSONEq(t, tc.expected, err.Error())
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// jsonDocument is the JSON rendering of an error, for example:
+//
+//	{"code":"SNYK-CONTAINER-0015","category":"input","message":"The format provided (x) is not ...",
+//	 "detail":"invalid format provided (x)"}
+type jsonDocument struct {
+	Code     string   `json:"code"`
+	Category Category `json:"category"`
+	Message  string   `json:"message"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// JSONError is an error whose message is its JSON rendering, for automation to parse the output of a
+// failed workflow.
+type JSONError struct {
+	err error
+	doc jsonDocument
+}
+
+// NewJSONError renders the error as JSON. Errors without a code are reported as internal errors.
+func NewJSONError(err error) *JSONError {
+	doc := jsonDocument{Code: CodeInternal.ID, Category: CodeInternal.Category, Message: err.Error()}
+
+	var xerr *ContainerExtensionError
+	if errors.As(err, &xerr) {
+		doc.Code = xerr.code.ID
+		doc.Category = xerr.code.Category
+		doc.Message = xerr.userMsg
+		switch {
+		case xerr.detail != "":
+			doc.Detail = xerr.detail
+		case xerr.err != nil && xerr.err.Error() != xerr.userMsg:
+			doc.Detail = xerr.err.Error()
+		}
+	}
+	return &JSONError{err: err, doc: doc}
+}
+
+func (e *JSONError) Error() string {
+	out, err := json.Marshal(e.doc)
+	if err != nil {
+		return e.err.Error()
+	}
+	return string(out)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.err
+}
@@ -15,12 +15,21 @@
 package flags
 
 import (
+	"strings"
+
 	"github.com/spf13/pflag"
 )
 
+// EnvVarPrefix prefixes the environment variables flags can be set with, see WithEnvVar.
+const EnvVarPrefix = "SNYK_CONTAINER_"
+
 type BaseFlag struct {
 	Name    string
 	FlagSet *pflag.FlagSet
+	// EnvVar is the environment variable the flag can be set with instead of the command line, if any.
+	EnvVar string
+	// Sensitive marks flags holding secrets, whose values must not be shown.
+	Sensitive bool
 }
 
 func InitBaseFlag(name string) *BaseFlag {
@@ -30,6 +39,52 @@ func InitBaseFlag(name string) *BaseFlag {
 	}
 }
 
+// WithEnvVar lets the flag be set with the environment variable named after it, e.g. SNYK_CONTAINER_PLATFORM
+// for --platform, and names the variable in the usage of the flag. Flags are only bound on purpose, so
+// variables set for other reasons don't change what a workflow does.
+func WithEnvVar[F interface {
+	Flag
+	bindEnvVar()
+}](f F) F {
+	f.bindEnvVar()
+	return f
+}
+
+func (b *BaseFlag) bindEnvVar() {
+	b.EnvVar = EnvVarPrefix + strings.ToUpper(strings.ReplaceAll(b.Name, "-", "_"))
+	if pf := b.FlagSet.Lookup(b.Name); pf != nil {
+		pf.Usage += " (env: " + b.EnvVar + ")"
+	}
+}
+
 func (b BaseFlag) GetFlagSet() *pflag.FlagSet {
 	return b.FlagSet
 }
+
+func (b BaseFlag) GetName() string {
+	return b.Name
+}
+
+func (b BaseFlag) GetEnvVar() string {
+	return b.EnvVar
+}
+
+func (b BaseFlag) IsSensitive() bool {
+	return b.Sensitive
+}
+
+// Validate returns an error if the flag does not accept the value. Values passed on the command line are
+// validated when they are parsed, values taken from the environment or the configuration file are not.
+func (b BaseFlag) Validate(string) error {
+	return nil
+}
+
+// isSet returns true if the flag was passed on the command line, or set by Resolve, which sets the values it
+// takes from the environment or the configuration file as strings.
+func (b BaseFlag) isSet(value any) bool {
+	if pf := b.FlagSet.Lookup(b.Name); pf != nil && pf.Changed {
+		return true
+	}
+	_, ok := value.(string)
+	return ok
+}
@@ -31,3 +31,18 @@ func Test_InitBaseFlag_GivenName_ShouldInitBaseFlagNameAndInitNewEmptyFlagSet(t
 	require.IsType(t, &pflag.FlagSet{}, result.FlagSet)
 	require.False(t, result.FlagSet.HasFlags())
 }
+
+func Test_InitBaseFlag_GivenName_ShouldNotBindEnvVar(t *testing.T) {
+	result := flags.InitBaseFlag("nested-jars-depth")
+
+	require.Empty(t, result.GetEnvVar())
+	require.False(t, result.IsSensitive())
+}
+
+func Test_WithEnvVar_GivenFlag_ShouldDeriveEnvVarAndNameItInUsage(t *testing.T) {
+	result := flags.WithEnvVar(flags.NewIntFlag("nested-jars-depth", 0, "Depth of nested jars"))
+
+	require.Equal(t, "SNYK_CONTAINER_NESTED_JARS_DEPTH", result.GetEnvVar())
+	require.Equal(t, "Depth of nested jars (env: SNYK_CONTAINER_NESTED_JARS_DEPTH)",
+		result.FlagSet.Lookup("nested-jars-depth").Usage)
+}
@@ -15,7 +15,9 @@
 package flags
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/snyk/go-application-framework/pkg/configuration"
 )
@@ -44,6 +46,14 @@ func (f *BoolFlag) GetFlagValue(c configuration.Configuration) bool {
 	return c.GetBool(f.Name)
 }
 
+// Validate returns an error if the value is not a boolean.
+func (f *BoolFlag) Validate(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
 func (f *BoolFlag) GetAsCLIArgument(c configuration.Configuration) string {
 	if v := f.GetFlagValue(c); v {
 		return fmt.Sprintf("--%s", f.Name)
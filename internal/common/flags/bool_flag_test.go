@@ -74,3 +74,10 @@ func assertFlagSet(
 	}
 	require.Equal(t, expectedDefaultValue, defaultValue)
 }
+
+func Test_Validate_GivenBoolFlag_ShouldRejectValuesWhichAreNotBooleans(t *testing.T) {
+	unit := flags.NewBoolFlag(testBoolFlagName, false, testBoolFlagUsage)
+
+	require.NoError(t, unit.Validate("true"))
+	require.EqualError(t, unit.Validate("yes"), "must be true or false")
+}
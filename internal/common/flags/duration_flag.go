@@ -0,0 +1,87 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snyk/go-application-framework/pkg/configuration"
+)
+
+type DurationFlag struct {
+	*BaseFlag
+	defaultValue time.Duration
+}
+
+func NewDurationFlag(name string, defaultValue time.Duration, usage string) *DurationFlag {
+	f := DurationFlag{
+		BaseFlag:     InitBaseFlag(name),
+		defaultValue: defaultValue,
+	}
+	f.FlagSet.Duration(name, defaultValue, usage)
+	return &f
+}
+
+// GetFlagValue returns the value of the flag, or its default if the flag is not configured. Values which are
+// not durations are rejected by Resolve, like configuration.GetInt they are read as zero otherwise.
+func (f *DurationFlag) GetFlagValue(c configuration.Configuration) time.Duration {
+	return f.valueOf(c.Get(f.Name))
+}
+
+// Validate returns an error if the value is not a duration.
+func (f *DurationFlag) Validate(value string) error {
+	if _, err := time.ParseDuration(value); err != nil {
+		return errors.New("must be a duration, for example 30s or 5m")
+	}
+	return nil
+}
+
+// GetAsCLIArgument omits values equal to the default unless they were set, which leaves the default to the
+// receiving CLI.
+func (f *DurationFlag) GetAsCLIArgument(c configuration.Configuration) string {
+	value := c.Get(f.Name)
+	v := f.valueOf(value)
+	if v == f.defaultValue && !f.isSet(value) {
+		return ""
+	}
+	return fmt.Sprintf("--%s=%s", f.Name, v)
+}
+
+// isSet returns true if the flag was passed on the command line or set by Resolve. Unlike numbers, the
+// configuration returns the default of an unset duration flag as a string.
+func (f *DurationFlag) isSet(value any) bool {
+	pf := f.FlagSet.Lookup(f.Name)
+	if pf.Changed {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && s != pf.DefValue
+}
+
+func (f *DurationFlag) valueOf(value any) time.Duration {
+	switch v := value.(type) {
+	case nil:
+		return f.defaultValue
+	case time.Duration:
+		return v
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return 0
+}
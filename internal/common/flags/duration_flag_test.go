@@ -0,0 +1,94 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/stretchr/testify/require"
+)
+
+const testDurationFlagName = "test_duration_flag_name"
+const testDurationFlagUsage = "test_duration_flag_usage"
+
+func Test_GetFlagValue_GivenDurationFlag_ShouldReturnConfiguredValue(t *testing.T) {
+	tests := map[string]struct {
+		value    any
+		expected time.Duration
+	}{
+		"unset":          {nil, time.Hour},
+		"duration":       {time.Minute, time.Minute},
+		"string":         {"90m", 90 * time.Minute},
+		"not a duration": {"a day", 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := configuration.NewInMemory()
+			if tc.value != nil {
+				c.Set(testDurationFlagName, tc.value)
+			}
+
+			result := flags.NewDurationFlag(testDurationFlagName, time.Hour, testDurationFlagUsage).GetFlagValue(c)
+
+			require.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func Test_GetFlagValue_GivenParsedDurationFlag_ShouldReturnParsedValue(t *testing.T) {
+	c := configuration.New()
+
+	unit := flags.NewDurationFlag(testDurationFlagName, time.Hour, testDurationFlagUsage)
+	err := c.AddFlagSet(unit.FlagSet)
+	require.NoError(t, err)
+
+	require.Equal(t, time.Hour, unit.GetFlagValue(c))
+	require.Equal(t, "", unit.GetAsCLIArgument(c))
+
+	err = unit.FlagSet.Parse([]string{"--" + testDurationFlagName + "=12h"})
+	require.NoError(t, err)
+
+	require.Equal(t, 12*time.Hour, unit.GetFlagValue(c))
+	require.Equal(t, "--"+testDurationFlagName+"=12h0m0s", unit.GetAsCLIArgument(c))
+}
+
+func Test_GetAsCLIArgument_GivenDurationFlagSetToDefault_ShouldReturnFlagAsCliArgument(t *testing.T) {
+	c := configuration.New()
+	unit := flags.NewDurationFlag(testDurationFlagName, time.Hour, testDurationFlagUsage)
+	require.NoError(t, c.AddFlagSet(unit.FlagSet))
+
+	require.NoError(t, unit.FlagSet.Parse([]string{"--" + testDurationFlagName + "=1h"}))
+
+	require.Equal(t, "--"+testDurationFlagName+"=1h0m0s", unit.GetAsCLIArgument(c))
+}
+
+func Test_Validate_GivenDurationFlag_ShouldRejectValuesWhichAreNotDurations(t *testing.T) {
+	unit := flags.NewDurationFlag(testDurationFlagName, time.Hour, testDurationFlagUsage)
+
+	require.NoError(t, unit.Validate("90m"))
+	require.EqualError(t, unit.Validate("5 minutes"), "must be a duration, for example 30s or 5m")
+}
+
+func Test_Set_GivenInvalidDuration_ShouldReturnError(t *testing.T) {
+	unit := flags.NewDurationFlag(testDurationFlagName, time.Hour, testDurationFlagUsage)
+
+	err := unit.FlagSet.Lookup(testDurationFlagName).Value.Set("a day")
+
+	require.EqualError(t, err, `time: invalid duration "a day"`)
+}
@@ -0,0 +1,93 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/snyk/go-application-framework/pkg/configuration"
+)
+
+// EnumFlag is a string flag which only accepts the given values. An empty value means the flag is unset.
+type EnumFlag struct {
+	*BaseFlag
+	allowedValues []string
+}
+
+func NewEnumFlag(name string, defaultValue string, allowedValues []string, usage string) *EnumFlag {
+	f := EnumFlag{
+		BaseFlag:      InitBaseFlag(name),
+		allowedValues: allowedValues,
+	}
+	value := &enumValue{value: defaultValue, allowedValues: allowedValues}
+	f.FlagSet.Var(value, name, fmt.Sprintf("%s (%s)", usage, strings.Join(allowedValues, ", ")))
+	return &f
+}
+
+// AllowedValues returns the values the flag accepts.
+func (f *EnumFlag) AllowedValues() []string {
+	return f.allowedValues
+}
+
+// IsAllowed returns true if the flag accepts the value. Values passed on the command line are validated when
+// they are parsed, values configured otherwise need to be checked.
+func (f *EnumFlag) IsAllowed(value string) bool {
+	return slices.Contains(f.allowedValues, value)
+}
+
+// Validate returns an error if the flag does not accept the value. An empty value leaves the flag unset.
+func (f *EnumFlag) Validate(value string) error {
+	if value != "" && !f.IsAllowed(value) {
+		return fmt.Errorf("must be one of %s", strings.Join(f.allowedValues, ", "))
+	}
+	return nil
+}
+
+func (f *EnumFlag) GetFlagValue(c configuration.Configuration) string {
+	return c.GetString(f.Name)
+}
+
+func (f *EnumFlag) GetAsCLIArgument(c configuration.Configuration) string {
+	v := f.GetFlagValue(c)
+	if v == "" {
+		return ""
+	}
+	return fmt.Sprintf("--%s=%s", f.Name, v)
+}
+
+// enumValue is the pflag value of an EnumFlag, rejecting values which are not allowed.
+type enumValue struct {
+	value         string
+	allowedValues []string
+}
+
+func (v *enumValue) String() string {
+	return v.value
+}
+
+func (v *enumValue) Set(value string) error {
+	if !slices.Contains(v.allowedValues, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(v.allowedValues, ", "))
+	}
+	v.value = value
+	return nil
+}
+
+// Type is reported as string, so the configuration reads the value as such.
+func (v *enumValue) Type() string {
+	return "string"
+}
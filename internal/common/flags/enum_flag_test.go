@@ -0,0 +1,77 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags_test
+
+import (
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/stretchr/testify/require"
+)
+
+const testEnumFlagName = "test_enum_flag_name"
+const testEnumFlagUsage = "test_enum_flag_usage"
+
+var testEnumFlagValues = []string{"json", "xml"}
+
+func Test_NewEnumFlag_GivenAllowedValues_ShouldRenderThemInUsage(t *testing.T) {
+	result := flags.NewEnumFlag(testEnumFlagName, "", testEnumFlagValues, testEnumFlagUsage)
+
+	flag := result.FlagSet.Lookup(testEnumFlagName)
+	require.Equal(t, testEnumFlagUsage+" (json, xml)", flag.Usage)
+	require.Equal(t, "string", flag.Value.Type())
+	require.Equal(t, testEnumFlagValues, result.AllowedValues())
+}
+
+func Test_IsAllowed_GivenValue_ShouldReturnWhetherFlagAcceptsIt(t *testing.T) {
+	unit := flags.NewEnumFlag(testEnumFlagName, "", testEnumFlagValues, testEnumFlagUsage)
+
+	require.True(t, unit.IsAllowed("xml"))
+	require.False(t, unit.IsAllowed("yaml"))
+	require.False(t, unit.IsAllowed(""))
+}
+
+func Test_Parse_GivenAllowedEnumValue_ShouldSetValue(t *testing.T) {
+	c := configuration.New()
+
+	unit := flags.NewEnumFlag(testEnumFlagName, "", testEnumFlagValues, testEnumFlagUsage)
+	err := c.AddFlagSet(unit.FlagSet)
+	require.NoError(t, err)
+
+	require.Equal(t, "", unit.GetAsCLIArgument(c))
+
+	err = unit.FlagSet.Parse([]string{"--" + testEnumFlagName + "=xml"})
+	require.NoError(t, err)
+
+	require.Equal(t, "xml", unit.GetFlagValue(c))
+	require.Equal(t, "--"+testEnumFlagName+"=xml", unit.GetAsCLIArgument(c))
+}
+
+func Test_Set_GivenDisallowedEnumValue_ShouldReturnError(t *testing.T) {
+	unit := flags.NewEnumFlag(testEnumFlagName, "", testEnumFlagValues, testEnumFlagUsage)
+
+	err := unit.FlagSet.Lookup(testEnumFlagName).Value.Set("yaml")
+
+	require.EqualError(t, err, "must be one of json, xml")
+}
+
+func Test_Validate_GivenEnumValue_ShouldRejectDisallowedValues(t *testing.T) {
+	unit := flags.NewEnumFlag(testEnumFlagName, "", testEnumFlagValues, testEnumFlagUsage)
+
+	require.NoError(t, unit.Validate("xml"))
+	require.NoError(t, unit.Validate(""))
+	require.EqualError(t, unit.Validate("yaml"), "must be one of json, xml")
+}
@@ -20,6 +20,13 @@ import (
 )
 
 type Flag interface {
+	GetName() string
 	GetFlagSet() *pflag.FlagSet
 	GetAsCLIArgument(configuration.Configuration) string
+	// GetEnvVar returns the environment variable the flag can be set with, or "" if it has none.
+	GetEnvVar() string
+	// IsSensitive returns true if the value of the flag is a secret.
+	IsSensitive() bool
+	// Validate returns an error if the flag does not accept the value.
+	Validate(value string) error
 }
@@ -15,59 +15,239 @@
 package flags
 
 import (
-	"fmt"
+	"slices"
+	"time"
 
 	"github.com/snyk/container-cli/internal/workflows/sbom/constants"
 )
 
 var (
-	FlagExcludeAppVulns = NewBoolFlag(
+	FlagExcludeAppVulns = WithEnvVar(NewBoolFlag(
 		"exclude-app-vulns",
 		false,
 		"disable app-vulns",
-	)
-	FlagSbomFormat = NewStringFlag(
+	))
+	FlagSbomFormat = NewEnumFlag(
 		"format",
 		"",
-		fmt.Sprintf("Specify the SBOM output format. %s", constants.SbomValidFormats),
+		constants.SbomValidFormats,
+		"Specify the SBOM output format",
 	)
-	FlagPlatform = NewStringFlag(
-		"platform",
+	FlagTargetsFile = NewStringFlag(
+		"targets-file",
+		"",
+		"Path to a file listing additional container images, one per line, to generate SBOMs for",
+	)
+	FlagConcurrency = WithEnvVar(NewIntFlag(
+		"concurrency",
+		4,
+		"Maximum number of SBOMs generated in parallel when multiple images are given",
+	))
+	FlagTimeout = WithEnvVar(NewDurationFlag(
+		"timeout",
+		0,
+		"Abort the workflow if it takes longer than the given duration, e.g. 10m. An analysis of the image by the "+
+			"Snyk CLI in progress is finished first. 0 disables the timeout",
+	))
+	FlagRetryMaxAttempts = WithEnvVar(NewIntFlag(
+		"retry-max-attempts",
+		constants.DefaultRetryMaxAttempts,
+		"Maximum number of attempts of a request to the Snyk API which failed transiently, including the first "+
+			"one. 1 disables retries",
+	))
+	FlagRetryMaxBackoff = WithEnvVar(NewDurationFlag(
+		"retry-max-backoff",
+		constants.DefaultRetryMaxBackoff,
+		"Maximum wait before retrying a request to the Snyk API, including waits the API asks for",
+	))
+	FlagOffline = WithEnvVar(NewBoolFlag(
+		"offline",
+		false,
+		"Generate the SBOM locally from the image analysis, without sending it to the Snyk API",
+	))
+	FlagSbomAPIVersion = WithEnvVar(NewStringFlag(
+		"sbom-api-version",
+		"",
+		"The version of the Snyk SBOM API to use. It is requested as given, a warning is printed if the API "+
+			"reports it as deprecated (default: 2022-03-31~experimental)",
+	))
+	FlagSbomAPIPath = WithEnvVar(NewStringFlag(
+		"sbom-api-path",
+		"",
+		"The path of the Snyk SBOM API endpoint, {org_id} is replaced by the organization id "+
+			"(default: /hidden/orgs/{org_id}/sbom)",
+	))
+	FlagSbomAPIMode = WithEnvVar(NewEnumFlag(
+		"sbom-api-mode",
+		"",
+		constants.SbomAPIModes,
+		"How the Snyk SBOM API generates the SBOM, in the response or in a job which is polled until it "+
+			"finishes. The async mode requires --sbom-api-path (default: sync)",
+	))
+	FlagOutputFile = NewStringFlag(
+		"output-file",
+		"",
+		"Write the SBOM to the given file instead of stdout. "+
+			"Use the placeholders {name}, {tag}, {index} and {ext} to name the files of multiple images",
+	)
+	FlagSignKey = WithEnvVar(NewStringFlag(
+		"sign-key",
+		"",
+		"Sign the SBOM with the ECDSA or Ed25519 private key in the given PEM file and "+
+			"print it as an in-toto attestation in a DSSE envelope",
+	))
+	FlagAttach = NewBoolFlag(
+		"attach",
+		false,
+		"Push the SBOM to the registry of the image as an OCI referrer of the image. "+
+			"Use --username and --password to authenticate",
+	)
+	FlagVulnerabilities = NewEnumFlag(
+		"vulnerabilities",
+		"",
+		constants.VulnerabilitiesModes,
+		"Test the image for vulnerabilities and add them to the SBOM, either embedded into the "+
+			"CycloneDX document or as an accompanying OpenVEX document",
+	)
+	FlagVex = NewStringSliceFlag(
+		"vex",
+		nil,
+		"Apply the statements of the OpenVEX or CycloneDX VEX files to the vulnerabilities, "+
+			"suppressing those the image is not affected by. Requires --vulnerabilities",
+	)
+	FlagPolicy = NewStringFlag(
+		"policy",
+		"",
+		"Evaluate the SBOM against the YAML policy in the given file and fail if it lists denied licenses "+
+			"or packages, or packages lacking required fields",
+	)
+	FlagNoCache = WithEnvVar(NewBoolFlag(
+		"no-cache",
+		false,
+		"Analyse the image even if its depgraph is cached, and don't cache the depgraph",
+	))
+	FlagCacheDir = WithEnvVar(NewStringFlag(
+		"cache-dir",
+		"",
+		"The directory depgraphs are cached in (default: the snyk cache directory)",
+	))
+	FlagCacheTTL = WithEnvVar(NewDurationFlag(
+		"cache-ttl",
+		24*time.Hour,
+		"How long cached depgraphs are used for, e.g. 12h",
+	))
+	FlagCacheMaxSize = WithEnvVar(NewIntFlag(
+		"cache-max-size",
+		512,
+		"Maximum size of the depgraph cache in MB, the least recently used depgraphs are evicted",
+	))
+	FlagAll = NewBoolFlag(
+		"all",
+		false,
+		"Remove all cached depgraphs, not only the expired ones",
+	)
+	FlagPublicKey = WithEnvVar(NewStringFlag(
+		"public-key",
+		"",
+		"The PEM file with the ECDSA or Ed25519 public key to verify the attestation with",
+	))
+	FlagCompareTo = NewStringFlag(
+		"compare-to",
 		"",
-		fmt.Sprintf(
-			"For multi-architecture images, specify the platform for the container image. %s",
-			constants.ValidPlatforms,
-		),
+		"The container image or SBOM document to compare against",
+	)
+	FlagJSON = NewBoolFlag(
+		"json",
+		false,
+		"Print the result and errors as JSON, errors as {\"code\", \"message\", \"detail\"} documents",
 	)
-	FlagUsername = NewStringFlag(
+	FlagPlatform = WithEnvVar(NewEnumFlag(
+		"platform",
+		"",
+		constants.ValidPlatforms,
+		"For multi-architecture images, specify the platform for the container image",
+	))
+	FlagUsername = WithEnvVar(NewStringFlag(
 		"username",
 		"",
 		"Username for private registry authentication",
-	)
-	FlagPassword = NewStringFlag(
+	))
+	FlagPassword = WithEnvVar(NewSensitiveStringFlag(
 		"password",
 		"",
-		"Password for private registry authentication",
-	)
-	FlagExcludeNodeModules = NewBoolFlag(
+		"Password for private registry authentication, preferably set with the SNYK_CONTAINER_PASSWORD "+
+			"environment variable",
+	))
+	FlagExcludeNodeModules = WithEnvVar(NewBoolFlag(
 		"exclude-node-modules",
 		false,
 		"Exclude node_modules from scanning",
-	)
-	FlagNestedJarsDepth = NewStringFlag(
+	))
+	FlagNestedJarsDepth = WithEnvVar(NewIntFlag(
 		"nested-jars-depth",
-		"",
+		0,
 		"Maximum depth for nested JAR scanning",
-	)
+	))
+	FlagNativeAnalyzer = WithEnvVar(NewBoolFlag(
+		"native-analyzer",
+		false,
+		"Analyze images in-process instead of invoking the legacy CLI, pulling registry images directly. "+
+			"Only apk and dpkg operating system packages are detected. Images without them and images with "+
+			"application dependencies, unless --exclude-app-vulns is set, are still analyzed by the legacy CLI.",
+	))
 )
 
+// LegacyCLIFlags represents the flags that are forwarded to the legacy CLI when it performs the container analysis.
+var LegacyCLIFlags = []Flag{
+	FlagExcludeAppVulns,
+	FlagPlatform,
+	FlagExcludeNodeModules,
+	FlagNestedJarsDepth,
+}
+
+// RegistryCredentialsFlags represents the flags authenticating the container analysis with private registries.
+// They are passed to the legacy CLI through its environment, keeping them out of process listings and logs.
+var RegistryCredentialsFlags = []Flag{FlagUsername, FlagPassword}
+
+// CacheFlags represents the flags configuring the depgraph cache.
+var CacheFlags = []Flag{FlagCacheDir, FlagCacheTTL, FlagCacheMaxSize}
+
 // CommonFlags represents the flags that are shared between the top-level SBOM workflow
 // and the internal dependency graph workflow to control the container analysis.
-var CommonFlags = []Flag{
+var CommonFlags = slices.Concat([]Flag{FlagNativeAnalyzer}, LegacyCLIFlags, RegistryCredentialsFlags)
+
+// AllFlags represents the flags of all container workflows, which are the options the project configuration
+// file may set.
+var AllFlags = []Flag{
 	FlagExcludeAppVulns,
+	FlagSbomFormat,
+	FlagTargetsFile,
+	FlagConcurrency,
+	FlagTimeout,
+	FlagRetryMaxAttempts,
+	FlagRetryMaxBackoff,
+	FlagOffline,
+	FlagSbomAPIVersion,
+	FlagSbomAPIPath,
+	FlagSbomAPIMode,
+	FlagOutputFile,
+	FlagSignKey,
+	FlagAttach,
+	FlagVulnerabilities,
+	FlagVex,
+	FlagPolicy,
+	FlagNoCache,
+	FlagCacheDir,
+	FlagCacheTTL,
+	FlagCacheMaxSize,
+	FlagAll,
+	FlagPublicKey,
+	FlagCompareTo,
+	FlagJSON,
 	FlagPlatform,
 	FlagUsername,
 	FlagPassword,
 	FlagExcludeNodeModules,
 	FlagNestedJarsDepth,
+	FlagNativeAnalyzer,
 }
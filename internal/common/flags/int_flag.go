@@ -0,0 +1,79 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/snyk/go-application-framework/pkg/configuration"
+)
+
+type IntFlag struct {
+	*BaseFlag
+	defaultValue int
+}
+
+func NewIntFlag(name string, defaultValue int, usage string) *IntFlag {
+	f := IntFlag{
+		BaseFlag:     InitBaseFlag(name),
+		defaultValue: defaultValue,
+	}
+	f.FlagSet.Int(name, defaultValue, usage)
+	return &f
+}
+
+// GetFlagValue returns the value of the flag, or its default if the flag is not configured. Values which are
+// not numbers are rejected by Resolve, like configuration.GetInt they are read as zero otherwise.
+func (f *IntFlag) GetFlagValue(c configuration.Configuration) int {
+	return f.valueOf(c.Get(f.Name))
+}
+
+// Validate returns an error if the value is not a number.
+func (f *IntFlag) Validate(value string) error {
+	if _, err := strconv.Atoi(strings.TrimSpace(value)); err != nil {
+		return errors.New("must be a number")
+	}
+	return nil
+}
+
+// GetAsCLIArgument omits values equal to the default unless they were set, which leaves the default to the
+// receiving CLI.
+func (f *IntFlag) GetAsCLIArgument(c configuration.Configuration) string {
+	value := c.Get(f.Name)
+	v := f.valueOf(value)
+	if v == f.defaultValue && !f.isSet(value) {
+		return ""
+	}
+	return fmt.Sprintf("--%s=%d", f.Name, v)
+}
+
+func (f *IntFlag) valueOf(value any) int {
+	switch v := value.(type) {
+	case nil:
+		return f.defaultValue
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return 0
+}
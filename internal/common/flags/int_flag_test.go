@@ -0,0 +1,106 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags_test
+
+import (
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/stretchr/testify/require"
+)
+
+const testIntFlagName = "test_int_flag_name"
+const testIntFlagUsage = "test_int_flag_usage"
+
+func Test_NewIntFlag_GivenNameAndDefaultValueAndUsage_ShouldReturnIntFlagWithFlagSetPopulatedWithIntFlag(t *testing.T) {
+	result := flags.NewIntFlag(testIntFlagName, 4, testIntFlagUsage)
+
+	flag := result.FlagSet.Lookup(testIntFlagName)
+	require.Equal(t, testIntFlagUsage, flag.Usage)
+	defaultValue, err := result.FlagSet.GetInt(testIntFlagName)
+	require.NoError(t, err)
+	require.Equal(t, 4, defaultValue)
+}
+
+func Test_GetFlagValue_GivenIntFlag_ShouldReturnConfiguredValue(t *testing.T) {
+	tests := map[string]struct {
+		value    any
+		expected int
+	}{
+		"unset":        {nil, 4},
+		"int":          {8, 8},
+		"string":       {" 2 ", 2},
+		"not a number": {"many", 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := configuration.NewInMemory()
+			if tc.value != nil {
+				c.Set(testIntFlagName, tc.value)
+			}
+
+			result := flags.NewIntFlag(testIntFlagName, 4, testIntFlagUsage).GetFlagValue(c)
+
+			require.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func Test_GetAsCLIArgument_GivenIntFlagAndConfig_ShouldReturnFlagAsCliArgumentUnlessDefault(t *testing.T) {
+	c := configuration.New()
+
+	unit := flags.NewIntFlag(testIntFlagName, 4, testIntFlagUsage)
+	err := c.AddFlagSet(unit.FlagSet)
+	require.NoError(t, err)
+
+	require.Equal(t, "", unit.GetAsCLIArgument(c))
+
+	err = unit.FlagSet.Parse([]string{"--" + testIntFlagName + "=2"})
+	require.NoError(t, err)
+
+	require.Equal(t, "--"+testIntFlagName+"=2", unit.GetAsCLIArgument(c))
+}
+
+func Test_GetAsCLIArgument_GivenIntFlagSetToDefault_ShouldReturnFlagAsCliArgument(t *testing.T) {
+	tests := map[string]func(t *testing.T, unit *flags.IntFlag, c configuration.Configuration){
+		"command line": func(t *testing.T, unit *flags.IntFlag, _ configuration.Configuration) {
+			require.NoError(t, unit.FlagSet.Parse([]string{"--" + testIntFlagName + "=0"}))
+		},
+		"resolved": func(_ *testing.T, _ *flags.IntFlag, c configuration.Configuration) {
+			c.Set(testIntFlagName, "0")
+		},
+	}
+
+	for name, set := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := configuration.New()
+			unit := flags.NewIntFlag(testIntFlagName, 0, testIntFlagUsage)
+			require.NoError(t, c.AddFlagSet(unit.FlagSet))
+
+			set(t, unit, c)
+
+			require.Equal(t, "--"+testIntFlagName+"=0", unit.GetAsCLIArgument(c))
+		})
+	}
+}
+
+func Test_Validate_GivenIntFlag_ShouldRejectValuesWhichAreNotNumbers(t *testing.T) {
+	unit := flags.NewIntFlag(testIntFlagName, 4, testIntFlagUsage)
+
+	require.NoError(t, unit.Validate(" 2 "))
+	require.EqualError(t, unit.Validate("many"), "must be a number")
+}
@@ -0,0 +1,137 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the project configuration file read from the working directory. It sets flags by
+// their names, for example:
+//
+//	platform: linux/arm64
+//	exclude-app-vulns: true
+//	vex: [vex/base.openvex.json, vex/app.openvex.json]
+const ConfigFileName = ".snyk-container.yaml"
+
+// Source is where the effective value of a flag was taken from.
+type Source string
+
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Setting is a flag and where its effective value was taken from.
+type Setting struct {
+	Flag   Flag
+	Source Source
+}
+
+// Resolve sets the flags which were not passed on the command line from their environment variables, if they
+// have one, or the project configuration file, in this order of precedence, and returns where the values were
+// taken from.
+// Values the flags do not accept are rejected, naming the variable or the option they were taken from.
+func Resolve(config configuration.Configuration, flags []Flag) ([]Setting, error) {
+	file, err := loadConfigFile(ConfigFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]Setting, 0, len(flags))
+	for _, f := range flags {
+		source := SourceDefault
+		if pf := f.GetFlagSet().Lookup(f.GetName()); pf != nil && pf.Changed {
+			source = SourceFlag
+		} else if v, ok := lookupEnv(f); ok {
+			if err := f.Validate(v); err != nil {
+				return nil, fmt.Errorf("%s: invalid value %q: %w", f.GetEnvVar(), v, err)
+			}
+			config.Set(f.GetName(), v)
+			source = SourceEnv
+		} else if v, ok := file[f.GetName()]; ok {
+			if s, isString := v.(string); isString {
+				if err := f.Validate(s); err != nil {
+					return nil, fmt.Errorf("%s: option %q: invalid value %q: %w", ConfigFileName, f.GetName(), s, err)
+				}
+			}
+			config.Set(f.GetName(), v)
+			source = SourceFile
+		}
+		settings = append(settings, Setting{Flag: f, Source: source})
+	}
+	return settings, nil
+}
+
+// lookupEnv returns the value of the environment variable of the flag, unless it has none.
+func lookupEnv(f Flag) (string, bool) {
+	if f.GetEnvVar() == "" {
+		return "", false
+	}
+	return os.LookupEnv(f.GetEnvVar())
+}
+
+// loadConfigFile returns the values of the configuration file by flag name, which are strings or lists of
+// strings like the values passed on the command line. A missing file sets no values.
+func loadConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]any, len(raw))
+	for name, v := range raw {
+		i := slices.IndexFunc(AllFlags, func(f Flag) bool { return f.GetName() == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%s: unknown option %q", path, name)
+		}
+		_, isList := AllFlags[i].(*StringSliceFlag)
+
+		switch v := v.(type) {
+		case map[string]any:
+			return nil, fmt.Errorf("%s: option %q must be a value or a list of values", path, name)
+		case []any:
+			if !isList {
+				return nil, fmt.Errorf("%s: option %q takes a single value", path, name)
+			}
+			list := make([]string, len(v))
+			for i, e := range v {
+				list[i] = fmt.Sprint(e)
+			}
+			values[name] = list
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
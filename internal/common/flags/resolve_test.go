@@ -0,0 +1,162 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Resolve_GivenEnvAndConfigFile_ShouldPreferEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeConfigFile(t, `
+platform: linux/arm64
+vex: [base.openvex.json, app.openvex.json]
+password: from-file
+concurrency: 2
+`)
+	t.Setenv(flags.FlagPassword.GetEnvVar(), "from-env")
+	config := configuration.NewInMemory()
+
+	result, err := flags.Resolve(config, []flags.Flag{
+		flags.FlagPlatform, flags.FlagVex, flags.FlagPassword, flags.FlagConcurrency, flags.FlagOffline,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []flags.Setting{
+		{Flag: flags.FlagPlatform, Source: flags.SourceFile},
+		{Flag: flags.FlagVex, Source: flags.SourceFile},
+		{Flag: flags.FlagPassword, Source: flags.SourceEnv},
+		{Flag: flags.FlagConcurrency, Source: flags.SourceFile},
+		{Flag: flags.FlagOffline, Source: flags.SourceDefault},
+	}, result)
+	require.Equal(t, "linux/arm64", flags.FlagPlatform.GetFlagValue(config))
+	require.Equal(t, []string{"base.openvex.json", "app.openvex.json"}, flags.FlagVex.GetFlagValue(config))
+	require.Equal(t, "from-env", flags.FlagPassword.GetFlagValue(config))
+	require.Equal(t, 2, flags.FlagConcurrency.GetFlagValue(config))
+	require.False(t, flags.FlagOffline.GetFlagValue(config))
+}
+
+func Test_Resolve_GivenFlagOnCommandLine_ShouldPreferFlag(t *testing.T) {
+	unit := flags.WithEnvVar(flags.NewStringFlag("resolve-test", "", "usage"))
+	t.Setenv(unit.GetEnvVar(), "from-env")
+	config := configuration.New()
+	require.NoError(t, config.AddFlagSet(unit.FlagSet))
+	require.NoError(t, unit.FlagSet.Parse([]string{"--resolve-test=from-flag"}))
+
+	result, err := flags.Resolve(config, []flags.Flag{unit})
+	require.NoError(t, err)
+
+	require.Equal(t, flags.SourceFlag, result[0].Source)
+	require.Equal(t, "from-flag", unit.GetFlagValue(config))
+}
+
+func Test_Resolve_GivenFlagWithoutEnvVar_ShouldIgnoreEnvironment(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("SNYK_CONTAINER_JSON", "true")
+	config := configuration.NewInMemory()
+
+	result, err := flags.Resolve(config, []flags.Flag{flags.FlagJSON})
+	require.NoError(t, err)
+
+	require.Empty(t, flags.FlagJSON.GetEnvVar())
+	require.Equal(t, flags.SourceDefault, result[0].Source)
+	require.False(t, flags.FlagJSON.GetFlagValue(config))
+}
+
+func Test_Resolve_GivenInvalidConfigFile_ShouldReturnError(t *testing.T) {
+	tests := map[string]struct {
+		content       string
+		expectedError string
+	}{
+		"unknown option": {"plattform: linux/arm64", `.snyk-container.yaml: unknown option "plattform"`},
+		"list":           {"platform: [linux/arm64]", `.snyk-container.yaml: option "platform" takes a single value`},
+		"map":            {"vex: {file: a.json}", `.snyk-container.yaml: option "vex" must be a value or a list of values`},
+		"syntax":         {"platform: [", ".snyk-container.yaml: yaml:"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			writeConfigFile(t, tc.content)
+
+			_, err := flags.Resolve(configuration.NewInMemory(), []flags.Flag{flags.FlagPlatform})
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_Resolve_GivenInvalidValue_ShouldReturnErrorNamingItsSource(t *testing.T) {
+	const durationError = "must be a duration, for example 30s or 5m"
+	tests := map[string]struct {
+		flag          flags.Flag
+		env           string
+		content       string
+		expectedError string
+	}{
+		"enum env": {
+			flag:          flags.FlagPlatform,
+			env:           "bogus",
+			expectedError: `SNYK_CONTAINER_PLATFORM: invalid value "bogus": must be one of `,
+		},
+		"enum config file": {
+			flag:          flags.FlagPlatform,
+			content:       "platform: bogus",
+			expectedError: `.snyk-container.yaml: option "platform": invalid value "bogus": must be one of `,
+		},
+		"bool env": {
+			flag:          flags.FlagOffline,
+			env:           "yes",
+			expectedError: `SNYK_CONTAINER_OFFLINE: invalid value "yes": must be true or false`,
+		},
+		"duration env": {
+			flag:          flags.FlagTimeout,
+			env:           "5 minutes",
+			expectedError: `SNYK_CONTAINER_TIMEOUT: invalid value "5 minutes": ` + durationError,
+		},
+		"duration config file": {
+			flag:          flags.FlagTimeout,
+			content:       "timeout: 5 minutes",
+			expectedError: `.snyk-container.yaml: option "timeout": invalid value "5 minutes": ` + durationError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if tc.env != "" {
+				t.Setenv(tc.flag.GetEnvVar(), tc.env)
+			}
+			if tc.content != "" {
+				writeConfigFile(t, tc.content)
+			}
+			config := configuration.NewInMemory()
+
+			_, err := flags.Resolve(config, []flags.Flag{tc.flag})
+
+			require.ErrorContains(t, err, tc.expectedError)
+			require.Nil(t, config.Get(tc.flag.GetName()))
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(flags.ConfigFileName, []byte(content), 0o600))
+}
@@ -17,6 +17,7 @@ package flags
 import (
 	"fmt"
 
+	"github.com/snyk/container-cli/internal/common/redact"
 	"github.com/snyk/go-application-framework/pkg/configuration"
 )
 
@@ -32,6 +33,13 @@ func NewStringFlag(name string, defaultValue string, usage string) *StringFlag {
 	return &f
 }
 
+// NewSensitiveStringFlag returns a string flag holding a secret, such as a password.
+func NewSensitiveStringFlag(name string, defaultValue string, usage string) *StringFlag {
+	f := NewStringFlag(name, defaultValue, usage)
+	f.Sensitive = true
+	return f
+}
+
 func NewShortHandStringFlag(name string, shorthand string, defaultValue string, usage string) *StringFlag {
 	f := StringFlag{
 		BaseFlag: InitBaseFlag(name),
@@ -40,8 +48,14 @@ func NewShortHandStringFlag(name string, shorthand string, defaultValue string,
 	return &f
 }
 
+// GetFlagValue returns the value of the flag. Values of sensitive flags are registered to be redacted
+// from log and error messages.
 func (f *StringFlag) GetFlagValue(c configuration.Configuration) string {
-	return c.GetString(f.Name)
+	v := c.GetString(f.Name)
+	if f.Sensitive {
+		redact.Add(v)
+	}
+	return v
 }
 
 func (f *StringFlag) GetAsCLIArgument(c configuration.Configuration) string {
@@ -0,0 +1,62 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snyk/go-application-framework/pkg/configuration"
+)
+
+type StringSliceFlag struct {
+	*BaseFlag
+}
+
+// NewStringSliceFlag returns a flag which takes comma-separated values and may be repeated.
+func NewStringSliceFlag(name string, defaultValue []string, usage string) *StringSliceFlag {
+	f := StringSliceFlag{
+		BaseFlag: InitBaseFlag(name),
+	}
+	f.FlagSet.StringSlice(name, defaultValue, usage)
+	return &f
+}
+
+// GetFlagValue returns the values of the flag. Values configured as a single string are split at commas.
+func (f *StringSliceFlag) GetFlagValue(c configuration.Configuration) []string {
+	var values []string
+	switch v := c.Get(f.Name).(type) {
+	case []string:
+		values = v
+	case string:
+		values = strings.Split(v, ",")
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (f *StringSliceFlag) GetAsCLIArgument(c configuration.Configuration) string {
+	v := f.GetFlagValue(c)
+	if len(v) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("--%s=%s", f.Name, strings.Join(v, ","))
+}
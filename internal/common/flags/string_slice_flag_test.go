@@ -0,0 +1,70 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flags_test
+
+import (
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/stretchr/testify/require"
+)
+
+const testStringSliceFlagName = "test_string_slice_flag_name"
+const testStringSliceFlagUsage = "test_string_slice_flag_usage"
+
+func Test_GetFlagValue_GivenStringSliceFlag_ShouldReturnConfiguredValues(t *testing.T) {
+	tests := map[string]struct {
+		value    any
+		expected []string
+	}{
+		"unset":        {nil, []string{}},
+		"slice":        {[]string{"a", "b"}, []string{"a", "b"}},
+		"string":       {"a, b,,c", []string{"a", "b", "c"}},
+		"empty string": {"", []string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := configuration.NewInMemory()
+			if tc.value != nil {
+				c.Set(testStringSliceFlagName, tc.value)
+			}
+
+			result := flags.NewStringSliceFlag(testStringSliceFlagName, nil, testStringSliceFlagUsage).GetFlagValue(c)
+
+			require.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func Test_GetAsCLIArgument_GivenRepeatedStringSliceFlag_ShouldReturnCommaSeparatedValues(t *testing.T) {
+	c := configuration.New()
+
+	unit := flags.NewStringSliceFlag(testStringSliceFlagName, nil, testStringSliceFlagUsage)
+	err := c.AddFlagSet(unit.FlagSet)
+	require.NoError(t, err)
+
+	require.Equal(t, "", unit.GetAsCLIArgument(c))
+
+	err = unit.FlagSet.Parse([]string{
+		"--" + testStringSliceFlagName + "=a.json,b.json",
+		"--" + testStringSliceFlagName + "=c.json",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"a.json", "b.json", "c.json"}, unit.GetFlagValue(c))
+	require.Equal(t, "--"+testStringSliceFlagName+"=a.json,b.json,c.json", unit.GetAsCLIArgument(c))
+}
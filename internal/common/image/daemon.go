@@ -0,0 +1,147 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// dockerHostEnv overrides the address of the docker daemon, just like it does for the docker CLI.
+	dockerHostEnv     = "DOCKER_HOST"
+	defaultDockerHost = "unix:///var/run/docker.sock"
+)
+
+// exportFromDaemon exports the image from the docker daemon into a temporary tarball, which is the same
+// `docker save` would write. The tarball is removed once the source is closed.
+func exportFromDaemon(name string) (*tarSource, error) {
+	client, baseURL, err := daemonClient(os.Getenv(dockerHostEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(baseURL + "/images/get?" + url.Values{"names": {name}}.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the docker daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not export %s from the docker daemon: %s", name, daemonErrorMessage(resp))
+	}
+
+	tmp, err := writeTemp(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not export %s from the docker daemon: %w", name, err)
+	}
+	return indexTar(tmp, name, true)
+}
+
+// inspectDaemon reads the metadata of an image of the docker daemon without exporting it. The name and tag are
+// the ones `docker save` would record for name; images referenced by id don't have any.
+func inspectDaemon(name string) (*Image, error) {
+	client, baseURL, err := daemonClient(os.Getenv(dockerHostEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	// image references don't contain characters which need escaping, and the daemon expects the slashes of
+	// repository paths unescaped.
+	resp, err := client.Get(baseURL + "/images/" + name + "/json")
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the docker daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not inspect %s in the docker daemon: %s", name, daemonErrorMessage(resp))
+	}
+
+	var inspect struct {
+		ID           string   `json:"Id"`
+		RepoTags     []string `json:"RepoTags"`
+		OS           string   `json:"Os"`
+		Architecture string   `json:"Architecture"`
+		Variant      string   `json:"Variant"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&inspect); err != nil {
+		return nil, fmt.Errorf("could not parse the inspection of %s: %w", name, err)
+	}
+
+	img := &Image{
+		ConfigDigest: digest.Digest(inspect.ID),
+		Platform:     formatPlatform(inspect.OS, inspect.Architecture, inspect.Variant),
+		src:          memSource{},
+	}
+	for _, tag := range inspect.RepoTags {
+		if tag == name || tag == name+":latest" {
+			img.Name, img.Tag = SplitTag(tag)
+			break
+		}
+	}
+	return img, nil
+}
+
+// daemonClient returns an HTTP client talking to the docker daemon at host, along with the base URL of its API.
+// Only unix sockets and plain TCP connections are supported.
+func daemonClient(host string) (*http.Client, string, error) {
+	if host == "" {
+		host = defaultDockerHost
+	}
+
+	scheme, addr, ok := strings.Cut(host, "://")
+	if !ok {
+		return nil, "", fmt.Errorf("invalid docker host %q", host)
+	}
+
+	switch scheme {
+	case "unix":
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", addr)
+			},
+		}
+		// the host is ignored when dialing the socket, but is required to form a valid URL.
+		return &http.Client{Transport: transport}, "http://docker", nil
+	case "tcp", "http":
+		return &http.Client{}, "http://" + addr, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported docker host %q", host)
+	}
+}
+
+// daemonErrorMessage extracts the message of an error response of the docker daemon.
+func daemonErrorMessage(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var daemonErr struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &daemonErr); err == nil && daemonErr.Message != "" {
+		return daemonErr.Message
+	}
+	return resp.Status
+}
@@ -0,0 +1,261 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	dockerManifestFile = "manifest.json"
+	ociIndexFile       = "index.json"
+)
+
+// Image is a container image read from a local archive or layout directory.
+type Image struct {
+	// Name is the image name without tag or digest, e.g. "nginx" or "registry.example.com/app".
+	Name string
+	// Tag is the image tag if the archive records one.
+	Tag string
+	// ManifestDigest is the digest of the image manifest. It is empty for docker archives, which don't store manifests.
+	ManifestDigest digest.Digest
+	// ConfigDigest is the digest of the image configuration, also known as the image id.
+	ConfigDigest digest.Digest
+	// Platform is the image platform in the "os/arch[/variant]" notation.
+	Platform string
+
+	src    fileSource
+	layers []string
+}
+
+// Open reads the image at ref. If the reference points to a multi-platform image, platform
+// selects the image to read; an empty platform prefers linux/amd64.
+func Open(ref Reference, platform string) (*Image, error) {
+	src, err := openSource(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := readImage(src, ref, platform)
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+	return img, nil
+}
+
+// readImage reads the image from src, detecting whether it is a docker archive or an OCI layout.
+func readImage(src fileSource, ref Reference, platform string) (*Image, error) {
+	var img *Image
+	var err error
+	switch {
+	case src.Exists(dockerManifestFile):
+		img, err = readDockerArchive(src)
+	case src.Exists(ociIndexFile):
+		img, err = readOCILayout(src, ref.Tag, platform)
+	default:
+		err = errors.New("neither manifest.json nor index.json found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read image %s: %w", ref.Path, err)
+	}
+	return img, nil
+}
+
+func openSource(ref Reference) (fileSource, error) {
+	switch ref.Transport {
+	case TransportOCILayout:
+		return &dirSource{root: ref.Path}, nil
+	case TransportDockerDaemon:
+		return exportFromDaemon(ref.Path)
+	default:
+		return newTarSource(ref.Path)
+	}
+}
+
+// Close releases the underlying archive.
+func (img *Image) Close() error {
+	return img.src.Close()
+}
+
+// WalkLayers calls fn for every entry of every layer, starting with the base layer.
+func (img *Image) WalkLayers(fn func(layer int, hdr *tar.Header, r io.Reader) error) error {
+	for i, name := range img.layers {
+		if err := img.walkLayer(i, name, fn); err != nil {
+			return fmt.Errorf("could not read layer %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (img *Image) walkLayer(i int, name string, fn func(layer int, hdr *tar.Header, r io.Reader) error) error {
+	rc, err := img.src.Open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	r, err := decompress(rc)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(i, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress detects the compression from its magic bytes rather than from media types or file extensions,
+// because docker archives don't record media types and tarballs are not always named after their compression.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		dec, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// isCompressed returns true if the content starts with the magic bytes of a supported compression.
+func isCompressed(magic []byte) bool {
+	return bytes.HasPrefix(magic, gzipMagic) || bytes.HasPrefix(magic, zstdMagic)
+}
+
+type dockerManifestEntry struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type imageConfig struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+func readDockerArchive(src fileSource) (*Image, error) {
+	var manifest []dockerManifestEntry
+	if err := readJSON(src, dockerManifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest) == 0 {
+		return nil, fmt.Errorf("%s does not contain any images", dockerManifestFile)
+	}
+
+	// docker archives may contain several images, we analyse the first one just like `docker load` would tag it.
+	entry := manifest[0]
+	img := &Image{src: src, layers: entry.Layers}
+	if len(entry.RepoTags) > 0 {
+		img.Name, img.Tag = SplitTag(entry.RepoTags[0])
+	}
+
+	configBytes, err := readAll(src, entry.Config)
+	if err != nil {
+		return nil, err
+	}
+	img.ConfigDigest = digest.FromBytes(configBytes)
+	if img.Platform, err = platformFromConfig(configBytes); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// SplitTag splits "name:tag" into its parts. Colons that are part of a registry host port are left untouched.
+func SplitTag(ref string) (name, tag string) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i+1:], "/") {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+func platformFromConfig(configBytes []byte) (string, error) {
+	var c imageConfig
+	if err := json.Unmarshal(configBytes, &c); err != nil {
+		return "", fmt.Errorf("could not parse image config: %w", err)
+	}
+	return formatPlatform(c.OS, c.Architecture, c.Variant), nil
+}
+
+func formatPlatform(os, arch, variant string) string {
+	if os == "" || arch == "" {
+		return ""
+	}
+	p := os + "/" + arch
+	if variant != "" {
+		p += "/" + variant
+	}
+	return p
+}
+
+func readJSON(src fileSource, name string, v any) error {
+	b, err := readAll(src, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("could not parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func readAll(src fileSource, name string) ([]byte, error) {
+	rc, err := src.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
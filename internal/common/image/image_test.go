@@ -0,0 +1,338 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"archive/tar"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	type test struct {
+		input    string
+		expected image.Reference
+		ok       bool
+	}
+
+	tests := map[string]test{
+		"docker-archive": {
+			"docker-archive:/tmp/nginx.tar",
+			image.Reference{Transport: image.TransportDockerArchive, Path: "/tmp/nginx.tar"}, true,
+		},
+		"oci-archive": {
+			"oci-archive:app.tar",
+			image.Reference{Transport: image.TransportOCIArchive, Path: "app.tar"}, true,
+		},
+		"kaniko-archive": {
+			"kaniko-archive:/out/image.tar",
+			image.Reference{Transport: image.TransportKanikoArchive, Path: "/out/image.tar"}, true,
+		},
+		"oci layout": {
+			"oci:/tmp/layout",
+			image.Reference{Transport: image.TransportOCILayout, Path: "/tmp/layout"}, true,
+		},
+		"oci layout with tag": {
+			"oci:/tmp/layout:1.25",
+			image.Reference{Transport: image.TransportOCILayout, Path: "/tmp/layout", Tag: "1.25"}, true,
+		},
+		"oci layout with windows path": {
+			`oci:C:\layout`,
+			image.Reference{Transport: image.TransportOCILayout, Path: `C:\layout`}, true,
+		},
+		"bare tarball": {
+			"./image.tar",
+			image.Reference{Transport: image.TransportArchive, Path: "./image.tar"}, true,
+		},
+		"gzip compressed tarball": {
+			"/tmp/image.tar.gz",
+			image.Reference{Transport: image.TransportArchive, Path: "/tmp/image.tar.gz"}, true,
+		},
+		"tgz tarball": {
+			"image.tgz",
+			image.Reference{Transport: image.TransportArchive, Path: "image.tgz"}, true,
+		},
+		"zstd compressed tarball": {
+			"image.tar.zst",
+			image.Reference{Transport: image.TransportArchive, Path: "image.tar.zst"}, true,
+		},
+		"docker daemon": {
+			"docker-daemon:nginx:1.25",
+			image.Reference{Transport: image.TransportDockerDaemon, Path: "nginx:1.25"}, true,
+		},
+		"docker daemon with registry": {
+			"docker-daemon:localhost:5000/app@sha256:abc",
+			image.Reference{Transport: image.TransportDockerDaemon, Path: "localhost:5000/app@sha256:abc"}, true,
+		},
+		"registry image":  {"alpine:3.17", image.Reference{}, false},
+		"other extension": {"image.zip", image.Reference{}, false},
+		"empty":           {"", image.Reference{}, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, ok := image.ParseReference(tc.input)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, ref)
+		})
+	}
+}
+
+func TestOpen_GivenDockerArchive_ShouldReadMetadataAndLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	imagetest.WriteDockerArchive(t, path, []string{"registry.example.com:5000/team/app:1.2.3"},
+		imagetest.Layer{"etc/hostname": "base"},
+		imagetest.Layer{"etc/hostname": "top"},
+	)
+
+	img, err := image.Open(image.Reference{Transport: image.TransportDockerArchive, Path: path}, "")
+	require.NoError(t, err)
+	defer img.Close()
+
+	require.Equal(t, "registry.example.com:5000/team/app", img.Name)
+	require.Equal(t, "1.2.3", img.Tag)
+	require.Equal(t, digest.FromBytes(imagetest.Config), img.ConfigDigest)
+	require.Empty(t, img.ManifestDigest)
+	require.Equal(t, "linux/amd64", img.Platform)
+	require.Equal(t, []string{"base", "top"}, readFile(t, img, "etc/hostname"))
+}
+
+func TestOpen_GivenOCIArchive_ShouldReadMetadataAndLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	manifestDigest := imagetest.WriteOCIArchive(t, path, "3.17", imagetest.Layer{"etc/hostname": "oci"})
+
+	img, err := image.Open(image.Reference{Transport: image.TransportOCIArchive, Path: path}, "")
+	require.NoError(t, err)
+	defer img.Close()
+
+	require.Equal(t, "", img.Name)
+	require.Equal(t, "3.17", img.Tag)
+	require.Equal(t, manifestDigest, img.ManifestDigest)
+	require.Equal(t, digest.FromBytes(imagetest.Config), img.ConfigDigest)
+	require.Equal(t, []string{"oci"}, readFile(t, img, "etc/hostname"))
+}
+
+func TestOpen_GivenOCILayout_ShouldSelectImageByTag(t *testing.T) {
+	dir := t.TempDir()
+	imagetest.WriteOCILayout(t, dir, "docker.io/library/alpine:3.17", imagetest.Layer{"etc/hostname": "layout"})
+
+	img, err := image.Open(image.Reference{Transport: image.TransportOCILayout, Path: dir, Tag: "3.17"}, "")
+	require.NoError(t, err)
+	defer img.Close()
+
+	require.Equal(t, "docker.io/library/alpine", img.Name)
+	require.Equal(t, "3.17", img.Tag)
+
+	_, err = image.Open(image.Reference{Transport: image.TransportOCILayout, Path: dir, Tag: "3.18"}, "")
+	require.ErrorContains(t, err, `no image tagged "3.18" found`)
+}
+
+func TestOpen_GivenTarballWithoutManifest_ShouldReturnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	require.NoError(t, os.WriteFile(path, imagetest.LayerTar(t, imagetest.Layer{"hello": "world"}), 0o600))
+
+	_, err := image.Open(image.Reference{Transport: image.TransportArchive, Path: path}, "")
+	require.ErrorContains(t, err, "neither manifest.json nor index.json found")
+}
+
+func TestOpen_GivenCompressedTarball_ShouldDecompressIt(t *testing.T) {
+	dir := t.TempDir()
+	layer := imagetest.Layer{"etc/hostname": "compressed"}
+	dockerArchive := imagetest.DockerArchive(t, []string{"nginx:1.25"}, layer)
+	ociFiles, _ := imagetest.OCILayout(t, "alpine:3.17", layer)
+	ociNames := make([]string, 0, len(ociFiles))
+	for name := range ociFiles {
+		ociNames = append(ociNames, name)
+	}
+	ociArchive := imagetest.Tar(t, ociNames, ociFiles)
+
+	tests := map[string]struct {
+		file         string
+		content      []byte
+		expectedName string
+	}{
+		"gzip docker archive": {"docker.tar.gz", imagetest.Gzip(t, dockerArchive), "nginx"},
+		"tgz oci archive":     {"oci.tgz", imagetest.Gzip(t, ociArchive), "alpine"},
+		"zstd docker archive": {"docker.tar.zst", imagetest.Zstd(t, dockerArchive), "nginx"},
+		"zstd oci archive":    {"oci.tar.zst", imagetest.Zstd(t, ociArchive), "alpine"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.file)
+			require.NoError(t, os.WriteFile(path, tc.content, 0o600))
+			ref, ok := image.ParseReference(path)
+			require.True(t, ok)
+
+			img, err := image.Open(ref, "")
+			require.NoError(t, err)
+			defer img.Close()
+
+			require.Equal(t, tc.expectedName, img.Name)
+			require.Equal(t, digest.FromBytes(imagetest.Config), img.ConfigDigest)
+			require.Equal(t, []string{"compressed"}, readFile(t, img, "etc/hostname"))
+		})
+	}
+}
+
+func TestOpen_GivenDockerDaemon_ShouldExportImage(t *testing.T) {
+	archive := imagetest.DockerArchive(t, []string{"nginx:1.25"}, imagetest.Layer{"etc/hostname": "daemon"})
+	imagetest.DockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/get" || r.URL.Query().Get("names") != "nginx:1.25" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"reference does not exist"}`))
+			return
+		}
+		_, _ = w.Write(archive)
+	})
+
+	img, err := image.Open(image.Reference{Transport: image.TransportDockerDaemon, Path: "nginx:1.25"}, "")
+	require.NoError(t, err)
+	defer img.Close()
+
+	require.Equal(t, "nginx", img.Name)
+	require.Equal(t, "1.25", img.Tag)
+	require.Equal(t, []string{"daemon"}, readFile(t, img, "etc/hostname"))
+
+	_, err = image.Open(image.Reference{Transport: image.TransportDockerDaemon, Path: "nginx:1.26"}, "")
+	require.ErrorContains(t, err, "could not export nginx:1.26 from the docker daemon: reference does not exist")
+}
+
+func TestInspect_GivenCompressedTarball_ShouldReadMetadata(t *testing.T) {
+	dir := t.TempDir()
+	layer := imagetest.Layer{"etc/hostname": "compressed"}
+	dockerArchive := imagetest.DockerArchive(t, []string{"nginx:1.25"}, layer)
+	ociFiles, manifestDigest := imagetest.OCILayout(t, "alpine:3.17", layer)
+	ociNames := make([]string, 0, len(ociFiles))
+	for name := range ociFiles {
+		ociNames = append(ociNames, name)
+	}
+	ociArchive := imagetest.Tar(t, ociNames, ociFiles)
+
+	tests := map[string]struct {
+		file                   string
+		content                []byte
+		expectedName           string
+		expectedTag            string
+		expectedManifestDigest digest.Digest
+	}{
+		"gzip docker archive": {"docker.tar.gz", imagetest.Gzip(t, dockerArchive), "nginx", "1.25", ""},
+		"zstd oci archive":    {"oci.tar.zst", imagetest.Zstd(t, ociArchive), "alpine", "3.17", manifestDigest},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.file)
+			require.NoError(t, os.WriteFile(path, tc.content, 0o600))
+			ref, ok := image.ParseReference(path)
+			require.True(t, ok)
+
+			img, err := image.Inspect(ref, "")
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedName, img.Name)
+			require.Equal(t, tc.expectedTag, img.Tag)
+			require.Equal(t, tc.expectedManifestDigest, img.ManifestDigest)
+			require.Equal(t, digest.FromBytes(imagetest.Config), img.ConfigDigest)
+			require.Equal(t, "linux/amd64", img.Platform)
+		})
+	}
+}
+
+func TestInspect_GivenDockerDaemon_ShouldNotExportImage(t *testing.T) {
+	imageID := digest.FromBytes(imagetest.Config)
+	imagetest.DockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/nginx:1.25/json" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such image"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Id":"` + imageID.String() + `","RepoTags":["nginx:latest","nginx:1.25"],` +
+			`"Os":"linux","Architecture":"arm64","Variant":"v8"}`))
+	})
+
+	img, err := image.Inspect(image.Reference{Transport: image.TransportDockerDaemon, Path: "nginx:1.25"}, "")
+	require.NoError(t, err)
+
+	require.Equal(t, "nginx", img.Name)
+	require.Equal(t, "1.25", img.Tag)
+	require.Equal(t, imageID, img.ConfigDigest)
+	require.Empty(t, img.ManifestDigest)
+	require.Equal(t, "linux/arm64/v8", img.Platform)
+
+	_, err = image.Inspect(image.Reference{Transport: image.TransportDockerDaemon, Path: "nginx:1.26"}, "")
+	require.ErrorContains(t, err, "could not inspect nginx:1.26 in the docker daemon: No such image")
+}
+
+func TestSelectPlatform(t *testing.T) {
+	descs := []image.Descriptor{
+		{Digest: "sha256:arm", Platform: &image.Platform{OS: "linux", Architecture: "arm64"}},
+		{Digest: "sha256:amd", Platform: &image.Platform{OS: "linux", Architecture: "amd64"}},
+		{Digest: "sha256:armv7", Platform: &image.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+	}
+
+	d, err := image.SelectPlatform(descs, "")
+	require.NoError(t, err)
+	require.Equal(t, digest.Digest("sha256:amd"), d.Digest)
+
+	d, err = image.SelectPlatform(descs, "linux/arm/v7")
+	require.NoError(t, err)
+	require.Equal(t, digest.Digest("sha256:armv7"), d.Digest)
+
+	_, err = image.SelectPlatform(descs, "linux/s390x")
+	require.ErrorContains(t, err, "no image found for platform linux/s390x")
+}
+
+func TestSplitTag(t *testing.T) {
+	tests := map[string][2]string{
+		"alpine:3.17":                    {"alpine", "3.17"},
+		"alpine":                         {"alpine", ""},
+		"localhost:5000/app":             {"localhost:5000/app", ""},
+		"localhost:5000/app:1.0":         {"localhost:5000/app", "1.0"},
+		"docker.io/library/nginx:1.25.3": {"docker.io/library/nginx", "1.25.3"},
+	}
+
+	for input, expected := range tests {
+		t.Run(input, func(t *testing.T) {
+			name, tag := image.SplitTag(input)
+			require.Equal(t, expected, [2]string{name, tag})
+		})
+	}
+}
+
+// readFile returns the content of the file in every layer that contains it.
+func readFile(t *testing.T, img *image.Image, name string) []string {
+	t.Helper()
+
+	var contents []string
+	err := img.WalkLayers(func(_ int, hdr *tar.Header, r io.Reader) error {
+		if hdr.Name != name {
+			return nil
+		}
+		b, err := io.ReadAll(r)
+		contents = append(contents, string(b))
+		return err
+	})
+	require.NoError(t, err)
+	return contents
+}
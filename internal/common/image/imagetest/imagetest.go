@@ -0,0 +1,231 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imagetest builds small container images on disk for tests.
+package imagetest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+// Layer maps file paths to their content. An empty content for a ".wh." file creates a whiteout.
+type Layer map[string]string
+
+// Config is the image configuration written into every test image.
+var Config = []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]}}`)
+
+// LayerTar returns the uncompressed tarball of the layer.
+func LayerTar(t *testing.T, layer Layer) []byte {
+	t.Helper()
+
+	names := make([]string, 0, len(layer))
+	for name := range layer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make(map[string][]byte, len(layer))
+	for _, name := range names {
+		files[name] = []byte(layer[name])
+	}
+	return Tar(t, names, files)
+}
+
+// Tar returns a tarball holding the files in the given order.
+func Tar(t *testing.T, names []string, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range names {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(files[name])
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+// Gzip compresses b.
+func Gzip(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// Zstd compresses b.
+func Zstd(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = zw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// DockerArchive returns the content of a `docker save` tarball holding a single image.
+func DockerArchive(t *testing.T, repoTags []string, layers ...Layer) []byte {
+	t.Helper()
+
+	configName := digest.FromBytes(Config).Encoded() + ".json"
+	names := []string{configName}
+	files := map[string][]byte{configName: Config}
+
+	layerNames := make([]string, 0, len(layers))
+	for _, l := range layers {
+		content := LayerTar(t, l)
+		name := digest.FromBytes(content).Encoded() + "/layer.tar"
+		layerNames = append(layerNames, name)
+		names = append(names, name)
+		files[name] = content
+	}
+
+	manifest, err := json.Marshal([]map[string]any{{
+		"Config":   configName,
+		"RepoTags": repoTags,
+		"Layers":   layerNames,
+	}})
+	require.NoError(t, err)
+	names = append(names, "manifest.json")
+	files["manifest.json"] = manifest
+
+	return Tar(t, names, files)
+}
+
+// WriteDockerArchive writes a `docker save` tarball to path.
+func WriteDockerArchive(t *testing.T, path string, repoTags []string, layers ...Layer) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, DockerArchive(t, repoTags, layers...), 0o600))
+}
+
+// OCILayout returns the files of an OCI image layout holding a single image, together with the manifest digest.
+// The refName is recorded in the "org.opencontainers.image.ref.name" annotation, unless it is empty.
+func OCILayout(t *testing.T, refName string, layers ...Layer) (map[string][]byte, digest.Digest) {
+	t.Helper()
+
+	files := map[string][]byte{
+		"oci-layout": []byte(`{"imageLayoutVersion":"1.0.0"}`),
+	}
+	addBlob := func(mediaType string, b []byte) map[string]any {
+		d := digest.FromBytes(b)
+		files["blobs/sha256/"+d.Encoded()] = b
+		return map[string]any{"mediaType": mediaType, "digest": d, "size": len(b)}
+	}
+
+	layerDescs := make([]map[string]any, 0, len(layers))
+	for _, l := range layers {
+		layerDescs = append(layerDescs, addBlob("application/vnd.oci.image.layer.v1.tar+gzip", Gzip(t, LayerTar(t, l))))
+	}
+
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        addBlob("application/vnd.oci.image.config.v1+json", Config),
+		"layers":        layerDescs,
+	})
+	require.NoError(t, err)
+
+	manifestDesc := addBlob("application/vnd.oci.image.manifest.v1+json", manifest)
+	if refName != "" {
+		manifestDesc["annotations"] = map[string]string{"org.opencontainers.image.ref.name": refName}
+	}
+
+	index, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"manifests":     []map[string]any{manifestDesc},
+	})
+	require.NoError(t, err)
+	files["index.json"] = index
+
+	return files, digest.FromBytes(manifest)
+}
+
+// WriteOCILayout writes an OCI image layout into dir and returns the manifest digest.
+func WriteOCILayout(t *testing.T, dir, refName string, layers ...Layer) digest.Digest {
+	t.Helper()
+
+	files, manifestDigest := OCILayout(t, refName, layers...)
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, content, 0o600))
+	}
+	return manifestDigest
+}
+
+// WriteOCIArchive writes an OCI image layout tarball to path and returns the manifest digest.
+func WriteOCIArchive(t *testing.T, path, refName string, layers ...Layer) digest.Digest {
+	t.Helper()
+
+	files, manifestDigest := OCILayout(t, refName, layers...)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	require.NoError(t, os.WriteFile(path, Tar(t, names, files), 0o600))
+	return manifestDigest
+}
+
+// DockerDaemon serves the docker API with handler on a unix socket, which DOCKER_HOST points to for the test.
+func DockerDaemon(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+
+	// unix socket paths are limited in length, so we don't use the long test specific temp dir.
+	dir, err := os.MkdirTemp("", "docker")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := &http.Server{ReadHeaderTimeout: time.Second, Handler: handler}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+}
+
+// NoDockerDaemon points DOCKER_HOST to a socket nobody listens on for the test.
+func NoDockerDaemon(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///nonexistent/docker.sock")
+}
@@ -0,0 +1,121 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// maxMetadataSize bounds the files kept in memory while scanning a compressed tarball for the metadata of
+// its image. Manifests and configurations are far smaller.
+const maxMetadataSize = 4 << 20
+
+// Inspect reads the metadata of the image at ref without preparing its layers to be walked: compressed
+// tarballs are scanned as a stream rather than decompressed to disk, and images of the docker daemon are
+// inspected rather than exported. The layers of the returned image can't be walked.
+func Inspect(ref Reference, platform string) (*Image, error) {
+	switch ref.Transport {
+	case TransportDockerDaemon:
+		return inspectDaemon(ref.Path)
+	case TransportOCILayout:
+		// layout directories are read in place, which is as cheap as it gets.
+	default:
+		img, err := inspectCompressed(ref, platform)
+		if img != nil || err != nil {
+			return img, err
+		}
+	}
+
+	img, err := Open(ref, platform)
+	if err != nil {
+		return nil, err
+	}
+	return img, img.Close()
+}
+
+// inspectCompressed reads the metadata of the image in a compressed tarball by scanning the decompressed stream
+// for manifests and configurations. It returns a nil image if the tarball is not compressed, in which case it is
+// indexed in place by Open instead.
+func inspectCompressed(ref Reference, platform string) (*Image, error) {
+	f, err := os.Open(ref.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	compressed, err := isCompressedFile(f)
+	if err != nil || !compressed {
+		return nil, err
+	}
+
+	r, err := decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress %s: %w", ref.Path, err)
+	}
+	defer r.Close()
+
+	src := memSource{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read tar archive %s: %w", ref.Path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxMetadataSize {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tar archive %s: %w", ref.Path, err)
+		}
+		// metadata is JSON, layers which happen to be small enough are dropped again.
+		if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			src[path.Clean(hdr.Name)] = content
+		}
+	}
+
+	return readImage(src, ref, platform)
+}
+
+// memSource serves the metadata files of an image from memory.
+type memSource map[string][]byte
+
+func (s memSource) Open(name string) (io.ReadCloser, error) {
+	content, ok := s[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s memSource) Exists(name string) bool {
+	_, ok := s[path.Clean(name)]
+	return ok
+}
+
+func (s memSource) Close() error {
+	return nil
+}
@@ -0,0 +1,192 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	AnnotationRefName           = "org.opencontainers.image.ref.name"
+	AnnotationContainerdImgName = "io.containerd.image.name"
+
+	defaultPlatform = "linux/amd64"
+)
+
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p *Platform) String() string {
+	if p == nil {
+		return ""
+	}
+	return formatPlatform(p.OS, p.Architecture, p.Variant)
+}
+
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       digest.Digest     `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+}
+
+type Index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// IsIndex returns true if the media type denotes a multi-platform index.
+func IsIndex(mediaType string) bool {
+	return mediaType == MediaTypeOCIIndex || mediaType == MediaTypeDockerManifestList
+}
+
+func blobPath(d digest.Digest) string {
+	return fmt.Sprintf("blobs/%s/%s", d.Algorithm(), d.Encoded())
+}
+
+func readOCILayout(src fileSource, tag, platform string) (*Image, error) {
+	var index Index
+	if err := readJSON(src, ociIndexFile, &index); err != nil {
+		return nil, err
+	}
+
+	top, err := selectByTag(index.Manifests, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{src: src}
+	img.Name, img.Tag = nameFromAnnotations(top.Annotations)
+
+	desc := top
+	for IsIndex(desc.MediaType) {
+		var nested Index
+		if err := readJSON(src, blobPath(desc.Digest), &nested); err != nil {
+			return nil, err
+		}
+		if desc, err = SelectPlatform(nested.Manifests, platform); err != nil {
+			return nil, err
+		}
+	}
+
+	var manifest Manifest
+	if err := readJSON(src, blobPath(desc.Digest), &manifest); err != nil {
+		return nil, err
+	}
+	img.ManifestDigest = desc.Digest
+	img.ConfigDigest = manifest.Config.Digest
+	for _, l := range manifest.Layers {
+		img.layers = append(img.layers, blobPath(l.Digest))
+	}
+
+	configBytes, err := readAll(src, blobPath(manifest.Config.Digest))
+	if err != nil {
+		return nil, err
+	}
+	if img.Platform, err = platformFromConfig(configBytes); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// selectByTag picks the top-level descriptor of an OCI layout. Without a tag the layout must hold
+// exactly one image (which may still be a multi-platform index).
+func selectByTag(descs []Descriptor, tag string) (Descriptor, error) {
+	if tag == "" {
+		switch len(descs) {
+		case 0:
+			return Descriptor{}, errors.New("the image index is empty")
+		case 1:
+			return descs[0], nil
+		default:
+			return Descriptor{}, fmt.Errorf(
+				"the image index contains %d images, please select one by appending :<tag> to the reference", len(descs))
+		}
+	}
+
+	for _, d := range descs {
+		refName := d.Annotations[AnnotationRefName]
+		if refName == tag || strings.HasSuffix(refName, ":"+tag) {
+			return d, nil
+		}
+	}
+	return Descriptor{}, fmt.Errorf("no image tagged %q found", tag)
+}
+
+// ErrPlatformNotFound is returned if a multi-platform image has no image for the requested platform.
+var ErrPlatformNotFound = errors.New("no image found for platform")
+
+// SelectPlatform picks the manifest matching the platform from the manifests of an index.
+func SelectPlatform(descs []Descriptor, platform string) (Descriptor, error) {
+	if len(descs) == 0 {
+		return Descriptor{}, errors.New("the image index is empty")
+	}
+
+	want := platform
+	if want == "" {
+		want = defaultPlatform
+	}
+	for _, d := range descs {
+		if d.Platform.String() == want {
+			return d, nil
+		}
+	}
+
+	if platform == "" {
+		return descs[0], nil
+	}
+	return Descriptor{}, fmt.Errorf("%w %s", ErrPlatformNotFound, platform)
+}
+
+// nameFromAnnotations derives image name and tag from the annotations that containerd, buildkit and
+// skopeo attach to the descriptors of an OCI layout.
+func nameFromAnnotations(annotations map[string]string) (name, tag string) {
+	if full := annotations[AnnotationContainerdImgName]; full != "" {
+		return SplitTag(full)
+	}
+
+	refName := annotations[AnnotationRefName]
+	// the ref name annotation is either a bare tag or a fully qualified reference.
+	if strings.ContainsAny(refName, ":/") {
+		return SplitTag(refName)
+	}
+	return "", refName
+}
@@ -0,0 +1,93 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"strings"
+)
+
+// Transport identifies how a local image is stored on disk.
+type Transport string
+
+const (
+	TransportDockerArchive Transport = "docker-archive"
+	TransportOCIArchive    Transport = "oci-archive"
+	TransportKanikoArchive Transport = "kaniko-archive"
+	TransportOCILayout     Transport = "oci"
+	// TransportDockerDaemon is an image stored by the local docker daemon, which is exported like `docker save` does.
+	TransportDockerDaemon Transport = "docker-daemon"
+	// TransportArchive is a bare tarball whose layout (docker or OCI) is detected from its contents.
+	TransportArchive Transport = "archive"
+)
+
+// archiveExtensions are the file extensions of tarballs which are recognised without a transport prefix.
+// Compressed tarballs are decompressed before they are read.
+var archiveExtensions = []string{".tar", ".tar.gz", ".tgz", ".tar.zst"}
+
+// Reference points to a local image, e.g. "docker-archive:/tmp/nginx.tar", "oci:/tmp/layout:1.25" or
+// "docker-daemon:nginx:1.25".
+type Reference struct {
+	Transport Transport
+	// Path is the archive or layout directory. For the docker daemon it is the image reference the daemon knows
+	// the image by.
+	Path string
+	// Tag optionally selects an image from an OCI layout by its "org.opencontainers.image.ref.name" annotation.
+	Tag string
+}
+
+// ParseReference parses a local image input. The boolean result is false if the input does not
+// refer to a local image, e.g. for registry references such as "alpine:3.17".
+func ParseReference(input string) (Reference, bool) {
+	for _, t := range []Transport{TransportDockerArchive, TransportOCIArchive, TransportKanikoArchive} {
+		if path, ok := strings.CutPrefix(input, string(t)+":"); ok {
+			return Reference{Transport: t, Path: path}, true
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(input, string(TransportOCILayout)+":"); ok {
+		path, tag := splitLayoutTag(rest)
+		return Reference{Transport: TransportOCILayout, Path: path, Tag: tag}, true
+	}
+
+	if name, ok := strings.CutPrefix(input, string(TransportDockerDaemon)+":"); ok {
+		return Reference{Transport: TransportDockerDaemon, Path: name}, true
+	}
+
+	if IsArchivePath(input) {
+		return Reference{Transport: TransportArchive, Path: input}, true
+	}
+
+	return Reference{}, false
+}
+
+// IsArchivePath returns true if the path has the extension of an image tarball, e.g. ".tar" or ".tar.gz".
+func IsArchivePath(path string) bool {
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLayoutTag splits "<dir>[:<tag>]". A colon only separates a tag if it appears after the last
+// path separator, so that Windows drive letters and directories containing colons keep working.
+func splitLayoutTag(input string) (path, tag string) {
+	i := strings.LastIndex(input, ":")
+	if i <= 1 || strings.ContainsAny(input[i+1:], `/\`) {
+		return input, ""
+	}
+	return input[:i], input[i+1:]
+}
@@ -0,0 +1,195 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// fileSource provides random access to the files of an image archive or layout directory.
+type fileSource interface {
+	Open(name string) (io.ReadCloser, error)
+	Exists(name string) bool
+	Close() error
+}
+
+// dirSource serves files from a directory, e.g. an OCI image layout.
+type dirSource struct {
+	root string
+}
+
+func (s *dirSource) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.root, filepath.FromSlash(path.Clean(name))))
+}
+
+func (s *dirSource) Exists(name string) bool {
+	_, err := os.Stat(filepath.Join(s.root, filepath.FromSlash(path.Clean(name))))
+	return err == nil
+}
+
+func (s *dirSource) Close() error {
+	return nil
+}
+
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+// tarSource serves files from a tarball. The tarball is indexed once so that individual files can be
+// read without re-scanning the whole archive, which requires compressed tarballs to be decompressed
+// into a temporary file first.
+type tarSource struct {
+	file    *os.File
+	entries map[string]tarEntry
+	// temporary is set if file was created by us and needs to be removed once the source is closed.
+	temporary bool
+}
+
+func newTarSource(name string) (*tarSource, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	compressed, err := isCompressedFile(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if !compressed {
+		return indexTar(f, name, false)
+	}
+
+	tmp, err := decompressToTemp(f)
+	_ = f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress %s: %w", name, err)
+	}
+	return indexTar(tmp, name, true)
+}
+
+// isCompressedFile returns true if the file starts with the magic bytes of a supported compression.
+func isCompressedFile(f *os.File) (bool, error) {
+	magic := make([]byte, len(zstdMagic))
+	n, err := f.ReadAt(magic, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return isCompressed(magic[:n]), nil
+}
+
+// decompressToTemp writes the decompressed content of r into a temporary file, which is returned
+// positioned at its start.
+func decompressToTemp(r io.Reader) (*os.File, error) {
+	dr, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+
+	return writeTemp(dr)
+}
+
+// writeTemp copies r into a new temporary file, which is returned positioned at its start.
+func writeTemp(r io.Reader) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "snyk-container-image-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		removeTemp(tmp)
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		removeTemp(tmp)
+		return nil, err
+	}
+	return tmp, nil
+}
+
+func removeTemp(f *os.File) {
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// indexTar records the offsets of the regular files in the tarball f, which is named name in errors.
+// f is closed if indexing fails.
+func indexTar(f *os.File, name string, temporary bool) (*tarSource, error) {
+	closeFile := func() {
+		if temporary {
+			removeTemp(f)
+		} else {
+			_ = f.Close()
+		}
+	}
+
+	entries := make(map[string]tarEntry)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			closeFile()
+			return nil, fmt.Errorf("could not read tar archive %s: %w", name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// the tar reader does not buffer, so after reading the header the
+		// file offset points at the start of the entry's content.
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			closeFile()
+			return nil, fmt.Errorf("could not index tar archive %s: %w", name, err)
+		}
+		entries[path.Clean(hdr.Name)] = tarEntry{offset: offset, size: hdr.Size}
+	}
+
+	return &tarSource{file: f, entries: entries, temporary: temporary}, nil
+}
+
+func (s *tarSource) Open(name string) (io.ReadCloser, error) {
+	e, ok := s.entries[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return io.NopCloser(io.NewSectionReader(s.file, e.offset, e.size)), nil
+}
+
+func (s *tarSource) Exists(name string) bool {
+	_, ok := s.entries[path.Clean(name)]
+	return ok
+}
+
+func (s *tarSource) Close() error {
+	err := s.file.Close()
+	if s.temporary {
+		if rmErr := os.Remove(s.file.Name()); rmErr != nil && err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
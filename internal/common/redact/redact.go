@@ -0,0 +1,80 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact hides secrets, such as the values of sensitive flags, in log and error messages. Secrets
+// are registered for the lifetime of the process when they are read.
+package redact
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces secrets.
+const Mask = "********"
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Add registers a secret to be redacted. Empty secrets are ignored.
+func Add(secret string) {
+	if secret == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Contains(secrets, secret) {
+		secrets = append(secrets, secret)
+		// replace longer secrets first, so secrets containing others are fully masked.
+		slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	}
+}
+
+// String returns s with all registered secrets masked.
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// Error returns err with all registered secrets masked in its message. The original error can still be
+// inspected with errors.Is and errors.As.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := err.Error(); String(msg) != msg {
+		return &redactedError{err: err}
+	}
+	return err
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,45 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_String_GivenRegisteredSecrets_ShouldMaskThem(t *testing.T) {
+	Add("s3cr3t")
+	Add("s3cr3t-and-more")
+	Add("")
+
+	require.Equal(t, "--password=******** and ********", String("--password=s3cr3t and s3cr3t-and-more"))
+	require.Equal(t, "nothing to hide", String("nothing to hide"))
+}
+
+func Test_Error_GivenErrorContainingSecret_ShouldMaskMessageAndKeepChain(t *testing.T) {
+	Add("hunter2")
+	err := fmt.Errorf("login with hunter2 failed: %w", fs.ErrPermission)
+
+	result := Error(err)
+
+	require.EqualError(t, result, "login with ******** failed: permission denied")
+	require.True(t, errors.Is(result, fs.ErrPermission))
+	require.Same(t, fs.ErrPermission, Error(fs.ErrPermission))
+	require.NoError(t, Error(nil))
+}
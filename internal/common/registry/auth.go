@@ -0,0 +1,136 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Credentials authenticate against a registry. Empty credentials request anonymous access.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// challenge is a parsed WWW-Authenticate header, e.g. `Bearer realm="https://auth.docker.io/token",service="x"`.
+type challenge struct {
+	scheme string
+	params map[string]string
+}
+
+func parseChallenge(header string) challenge {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	c := challenge{scheme: strings.ToLower(scheme), params: make(map[string]string)}
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				c.params[key] = value[1:]
+				break
+			}
+			c.params[key] = value[1 : end+1]
+			value = value[end+2:]
+		} else {
+			v, _, _ := strings.Cut(value, ",")
+			c.params[key] = strings.TrimSpace(v)
+			value = value[len(v):]
+		}
+		rest = strings.TrimLeft(value, ", ")
+	}
+	return c
+}
+
+// authorize answers the challenge of a registry and returns the value of the Authorization header to retry with.
+func (s *session) authorize(ctx context.Context, header string) (string, error) {
+	c := parseChallenge(header)
+	switch c.scheme {
+	case "basic":
+		if s.creds.Username == "" {
+			return "", fmt.Errorf("%w: registry %s requires credentials", ErrUnauthorized, s.host)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(s.creds.Username+":"+s.creds.Password)), nil
+	case "bearer":
+		token, err := s.fetchToken(ctx, c)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication scheme %q of registry %s", c.scheme, s.host)
+	}
+}
+
+// fetchToken requests a token with access to the repository from the token server.
+func (s *session) fetchToken(ctx context.Context, c challenge) (string, error) {
+	realm, err := url.Parse(c.params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q of registry %s", c.params["realm"], s.host)
+	}
+
+	query := realm.Query()
+	if service := c.params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:%s", s.repository, s.actions))
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if s.creds.Username != "" {
+		req.SetBasicAuth(s.creds.Username, s.creds.Password)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request a token from %s: %w", realm.Host, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", responseError(res, "token request to "+realm.Host+" failed")
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxManifestSize)).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response from %s: %w", realm.Host, err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response from %s does not contain a token", realm.Host)
+}
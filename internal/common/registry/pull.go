@@ -0,0 +1,138 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/snyk/container-cli/internal/common/image"
+)
+
+// ociLayoutFile marks a directory as an OCI image layout.
+const ociLayoutFile = "oci-layout"
+
+// Pull downloads the image the reference points to into dir, which is written as an OCI image layout that
+// image.Open reads like any other local image. If the reference points to a multi-platform index, only the
+// image for the platform is downloaded; an empty platform prefers linux/amd64.
+func (c *Client) Pull(
+	ctx context.Context,
+	imageRef, platform string,
+	creds Credentials,
+	dir string,
+) (*ResolvedImage, error) {
+	s, ref, err := c.newSession(imageRef, creds, actionsPull)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.resolve(ctx, ref, platform)
+	if err != nil {
+		return nil, err
+	}
+	desc, content, err := s.fetchManifest(ctx, resolved.Image.Digest.String())
+	if err != nil {
+		return nil, err
+	}
+	if desc.Digest != resolved.Image.Digest {
+		return nil, fmt.Errorf("manifest of %s does not match its digest %s", imageRef, resolved.Image.Digest)
+	}
+
+	var manifest image.Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid image manifest: %w", err)
+	}
+	c.logger.Debug().Msgf("pulling %s@%s with %d layers", s.repository, desc.Digest, len(manifest.Layers))
+
+	if err := writeBlob(dir, desc.Digest, content); err != nil {
+		return nil, err
+	}
+	for _, blob := range append([]image.Descriptor{manifest.Config}, manifest.Layers...) {
+		if err := s.pullBlob(ctx, dir, blob.Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	index, err := json.Marshal(image.Index{
+		SchemaVersion: 2,
+		MediaType:     image.MediaTypeOCIIndex,
+		Manifests:     []image.Descriptor{resolved.Image},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal image index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), index, 0o600); err != nil {
+		return nil, err
+	}
+	layout := []byte(`{"imageLayoutVersion":"1.0.0"}`)
+	if err := os.WriteFile(filepath.Join(dir, ociLayoutFile), layout, 0o600); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// pullBlob streams the blob into the layout directory, verifying its content against the digest.
+func (s *session) pullBlob(ctx context.Context, dir string, d digest.Digest) error {
+	if err := d.Validate(); err != nil {
+		return fmt.Errorf("invalid blob digest %q: %w", d, err)
+	}
+
+	res, err := s.do(ctx, http.MethodGet, s.url("blobs", d.String()), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(res, "could not pull blob "+d.String())
+	}
+
+	path := blobPath(dir, d)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	verifier := d.Verifier()
+	if _, err := io.Copy(f, io.TeeReader(res.Body, verifier)); err != nil {
+		return fmt.Errorf("failed to pull blob %s: %w", d, err)
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("content of blob %s does not match its digest", d)
+	}
+	return f.Close()
+}
+
+func writeBlob(dir string, d digest.Digest, content []byte) error {
+	path := blobPath(dir, d)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+func blobPath(dir string, d digest.Digest) string {
+	return filepath.Join(dir, "blobs", d.Algorithm().String(), d.Encoded())
+}
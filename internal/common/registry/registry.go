@@ -0,0 +1,438 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registry pulls images from and pushes artifacts to container registries using the OCI distribution API.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/image"
+)
+
+const (
+	// MediaTypeEmptyJSON is the media type of the empty config of artifact manifests.
+	MediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+
+	// AnnotationCreated is the annotation recording when an artifact was created.
+	AnnotationCreated = "org.opencontainers.image.created"
+
+	// headerOCISubject is set by registries which support the referrers API when a manifest with a subject is pushed.
+	headerOCISubject = "OCI-Subject"
+
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+
+	maxManifestSize = 4 << 20
+
+	actionsPull = "pull"
+	actionsPush = "pull,push"
+)
+
+// ErrUnauthorized is returned if the registry denied access with the given credentials.
+var ErrUnauthorized = errors.New("access denied by the registry")
+
+// ErrNotFound is returned if the registry doesn't hold the requested image or blob.
+var ErrNotFound = errors.New("not found in the registry")
+
+// emptyJSON is the content of the empty config blob.
+var emptyJSON = []byte("{}")
+
+var manifestMediaTypes = []string{
+	image.MediaTypeOCIManifest,
+	image.MediaTypeOCIIndex,
+	image.MediaTypeDockerManifest,
+	image.MediaTypeDockerManifestList,
+}
+
+// ClientConfig represents the configuration for Client
+type ClientConfig struct {
+	Client *http.Client
+	Logger *zerolog.Logger
+	// Clock returns the current time, it defaults to time.Now.
+	Clock func() time.Time
+}
+
+// Client represents the client for container registries
+type Client struct {
+	client *http.Client
+	logger *zerolog.Logger
+	clock  func() time.Time
+}
+
+// NewClient creates a new Client value
+func NewClient(conf ClientConfig) *Client {
+	clock := conf.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	return &Client{
+		client: conf.Client,
+		logger: conf.Logger,
+		clock:  clock,
+	}
+}
+
+// ResolvedImage is an image reference resolved to the digests of its manifests.
+type ResolvedImage struct {
+	// Descriptor describes the manifest the reference points to, which may be a multi-platform index.
+	Descriptor image.Descriptor
+	// Image describes the manifest of the image for the platform. It equals Descriptor unless the
+	// reference points to an index.
+	Image image.Descriptor
+	// Platform is the platform of the image if it is known.
+	Platform string
+}
+
+// Resolve resolves the image reference to the digest of its manifest. If the reference points to a
+// multi-platform index, the manifest of the image for the platform is resolved as well; an empty
+// platform prefers linux/amd64.
+func (c *Client) Resolve(ctx context.Context, imageRef, platform string, creds Credentials) (*ResolvedImage, error) {
+	s, ref, err := c.newSession(imageRef, creds, actionsPull)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolve(ctx, ref, platform)
+}
+
+// Artifact is a document attached to an image as an OCI referrer.
+type Artifact struct {
+	// ArtifactType identifies the kind of artifact, e.g. "application/vnd.cyclonedx+json".
+	ArtifactType string
+	MediaType    string
+	Content      []byte
+}
+
+// PushReferrer pushes the artifact as an OCI 1.1 referrer of the image. If platform is set and the image is
+// a multi-platform index, the artifact refers to the image of that platform. It returns the descriptor of the
+// pushed artifact manifest.
+func (c *Client) PushReferrer(
+	ctx context.Context,
+	imageRef, platform string,
+	creds Credentials,
+	artifact Artifact,
+) (image.Descriptor, error) {
+	s, ref, err := c.newSession(imageRef, creds, actionsPush)
+	if err != nil {
+		return image.Descriptor{}, err
+	}
+
+	resolved, err := s.resolve(ctx, ref, platform)
+	if err != nil {
+		return image.Descriptor{}, err
+	}
+	subject := resolved.Descriptor
+	if platform != "" {
+		subject = resolved.Image
+	}
+	c.logger.Debug().Msgf("attaching %s to %s@%s", artifact.ArtifactType, s.repository, subject.Digest)
+
+	layer := image.Descriptor{
+		MediaType: artifact.MediaType,
+		Digest:    digest.FromBytes(artifact.Content),
+		Size:      int64(len(artifact.Content)),
+	}
+	config := image.Descriptor{
+		MediaType: MediaTypeEmptyJSON,
+		Digest:    digest.FromBytes(emptyJSON),
+		Size:      int64(len(emptyJSON)),
+	}
+	if err := s.pushBlob(ctx, layer.Digest, artifact.Content); err != nil {
+		return image.Descriptor{}, err
+	}
+	if err := s.pushBlob(ctx, config.Digest, emptyJSON); err != nil {
+		return image.Descriptor{}, err
+	}
+
+	created := c.clock().UTC().Format(time.RFC3339)
+	manifest, err := json.Marshal(image.Manifest{
+		SchemaVersion: 2,
+		MediaType:     image.MediaTypeOCIManifest,
+		ArtifactType:  artifact.ArtifactType,
+		Config:        config,
+		Layers:        []image.Descriptor{layer},
+		Subject:       &subject,
+		Annotations:   map[string]string{AnnotationCreated: created},
+	})
+	if err != nil {
+		return image.Descriptor{}, fmt.Errorf("failed to marshal artifact manifest: %w", err)
+	}
+
+	desc := image.Descriptor{
+		MediaType:    image.MediaTypeOCIManifest,
+		Digest:       digest.FromBytes(manifest),
+		Size:         int64(len(manifest)),
+		ArtifactType: artifact.ArtifactType,
+		Annotations:  map[string]string{AnnotationCreated: created},
+	}
+	res, err := s.putManifest(ctx, desc.Digest.String(), desc.MediaType, manifest)
+	if err != nil {
+		return image.Descriptor{}, err
+	}
+
+	// registries without support for the referrers API don't know about the subject, in which case
+	// clients find referrers through an index tagged with the digest of the subject.
+	if res.Header.Get(headerOCISubject) == "" {
+		c.logger.Debug().Msg("registry does not support the referrers API, updating the referrers tag")
+		if err := s.updateReferrersTag(ctx, subject.Digest, desc); err != nil {
+			return image.Descriptor{}, err
+		}
+	}
+	return desc, nil
+}
+
+// session holds the state of the requests to a single repository.
+type session struct {
+	client     *http.Client
+	host       string
+	repository string
+	// actions are the actions on the repository the session requests access to, e.g. "pull,push".
+	actions string
+	creds   Credentials
+	// authorization is the Authorization header obtained by answering the challenge of the registry.
+	authorization string
+}
+
+// newSession returns a session for the repository of the image and the tag or digest the image refers to.
+func (c *Client) newSession(imageRef string, creds Credentials, actions string) (*session, string, error) {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not parse image reference: %w", err)
+	}
+
+	host := reference.Domain(named)
+	if host == dockerHubDomain {
+		host = dockerHubRegistry
+	}
+
+	ref := "latest"
+	if canonical, ok := named.(reference.Canonical); ok {
+		ref = canonical.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		ref = tagged.Tag()
+	}
+
+	return &session{
+		client:     c.client,
+		host:       host,
+		repository: reference.Path(named),
+		actions:    actions,
+		creds:      creds,
+	}, ref, nil
+}
+
+// resolve returns the manifest the reference points to and, if that is an index, the manifest of the
+// image for the platform.
+func (s *session) resolve(ctx context.Context, ref, platform string) (*ResolvedImage, error) {
+	desc, body, err := s.fetchManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved := &ResolvedImage{Descriptor: desc, Image: desc, Platform: platform}
+	if !image.IsIndex(desc.MediaType) {
+		return resolved, nil
+	}
+
+	var index image.Index
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("invalid image index: %w", err)
+	}
+	selected, err := image.SelectPlatform(index.Manifests, platform)
+	if err != nil {
+		return nil, err
+	}
+	resolved.Image = image.Descriptor{MediaType: selected.MediaType, Digest: selected.Digest, Size: selected.Size}
+	if selected.Platform != nil {
+		resolved.Platform = selected.Platform.String()
+	}
+	return resolved, nil
+}
+
+// fetchManifest returns the descriptor and content of the manifest the reference points to.
+func (s *session) fetchManifest(ctx context.Context, ref string) (image.Descriptor, []byte, error) {
+	res, err := s.do(ctx, http.MethodGet, s.url("manifests", ref), nil,
+		http.Header{"Accept": {strings.Join(manifestMediaTypes, ", ")}})
+	if err != nil {
+		return image.Descriptor{}, nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return image.Descriptor{}, nil, responseError(res, "could not find image "+s.repository+":"+ref)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxManifestSize))
+	if err != nil {
+		return image.Descriptor{}, nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return image.Descriptor{
+		MediaType: res.Header.Get("Content-Type"),
+		Digest:    digest.FromBytes(body),
+		Size:      int64(len(body)),
+	}, body, nil
+}
+
+// pushBlob uploads the blob unless the repository already holds it.
+func (s *session) pushBlob(ctx context.Context, d digest.Digest, content []byte) error {
+	res, err := s.do(ctx, http.MethodHead, s.url("blobs", d.String()), nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	res, err = s.do(ctx, http.MethodPost, s.url("blobs", "uploads")+"/", nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		return responseError(res, "could not start blob upload")
+	}
+
+	location, err := res.Request.URL.Parse(res.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid blob upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", d.String())
+	location.RawQuery = query.Encode()
+
+	res, err = s.do(ctx, http.MethodPut, location.String(), content,
+		http.Header{"Content-Type": {"application/octet-stream"}})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return responseError(res, "could not upload blob "+d.String())
+	}
+	return nil
+}
+
+// putManifest uploads the manifest under the reference, which is either its digest or a tag.
+func (s *session) putManifest(ctx context.Context, ref, mediaType string, manifest []byte) (*http.Response, error) {
+	res, err := s.do(ctx, http.MethodPut, s.url("manifests", ref), manifest, http.Header{"Content-Type": {mediaType}})
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return nil, responseError(res, "could not push manifest "+ref)
+	}
+	return res, nil
+}
+
+// updateReferrersTag adds the artifact to the index tagged "<alg>-<digest>" of the subject, as defined by the
+// referrers tag schema of the OCI distribution spec.
+func (s *session) updateReferrersTag(ctx context.Context, subject digest.Digest, desc image.Descriptor) error {
+	tag := subject.Algorithm().String() + "-" + subject.Encoded()
+
+	index := image.Index{SchemaVersion: 2, MediaType: image.MediaTypeOCIIndex}
+	res, err := s.do(ctx, http.MethodGet, s.url("manifests", tag), nil,
+		http.Header{"Accept": {image.MediaTypeOCIIndex}})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(io.LimitReader(res.Body, maxManifestSize)).Decode(&index); err != nil {
+			return fmt.Errorf("invalid referrers index %s: %w", tag, err)
+		}
+	case http.StatusNotFound:
+	default:
+		return responseError(res, "could not read referrers index "+tag)
+	}
+
+	index.Manifests = append(index.Manifests, desc)
+	body, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal referrers index: %w", err)
+	}
+	_, err = s.putManifest(ctx, tag, image.MediaTypeOCIIndex, body)
+	return err
+}
+
+func (s *session) url(kind, ref string) string {
+	return fmt.Sprintf("https://%s/v2/%s/%s/%s", s.host, s.repository, kind, url.PathEscape(ref))
+}
+
+// do sends the request, answering an authentication challenge of the registry once.
+func (s *session) do(
+	ctx context.Context,
+	method, rawURL string,
+	body []byte,
+	header http.Header,
+) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if s.authorization != "" {
+			req.Header.Set("Authorization", s.authorization)
+		}
+		res, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request to registry %s failed: %w", s.host, err)
+		}
+		return res, nil
+	}
+
+	res, err := send()
+	if err != nil || res.StatusCode != http.StatusUnauthorized || s.authorization != "" {
+		return res, err
+	}
+	res.Body.Close()
+
+	if s.authorization, err = s.authorize(ctx, res.Header.Get("WWW-Authenticate")); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+// responseError describes an unsuccessful registry response.
+func responseError(res *http.Response, msg string) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	err := fmt.Errorf("%s (status: %s)", msg, res.Status)
+	if detail := strings.TrimSpace(string(body)); detail != "" {
+		err = fmt.Errorf("%w: %s", err, detail)
+	}
+	switch res.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,244 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/snyk/container-cli/internal/common/registry"
+	"github.com/snyk/container-cli/internal/common/registry/registrytest"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTime     = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	testArtifact = registry.Artifact{
+		ArtifactType: "application/vnd.cyclonedx+json",
+		MediaType:    "application/vnd.cyclonedx+json",
+		Content:      []byte(`{"bomFormat":"CycloneDX"}`),
+	}
+)
+
+func Test_PushReferrer_GivenRegistry_ShouldPushArtifactReferringToImage(t *testing.T) {
+	tests := map[string]registrytest.Options{
+		"anonymous":  {},
+		"basic auth": {Username: "user", Password: "secret"},
+		"token auth": {Username: "user", Password: "secret", TokenAuth: true},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := registrytest.New(t, opts)
+			subject := r.PutManifest("library/app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+
+			desc, err := newClient(r).PushReferrer(context.Background(), r.Host()+"/library/app:1.0", "",
+				registry.Credentials{Username: opts.Username, Password: opts.Password}, testArtifact)
+			require.NoError(t, err)
+
+			require.Equal(t, image.MediaTypeOCIManifest, desc.MediaType)
+			require.Equal(t, testArtifact.ArtifactType, desc.ArtifactType)
+
+			var manifest image.Manifest
+			require.NoError(t, json.Unmarshal(r.Manifest("library/app", desc.Digest.String()), &manifest))
+			require.Equal(t, image.Manifest{
+				SchemaVersion: 2,
+				MediaType:     image.MediaTypeOCIManifest,
+				ArtifactType:  testArtifact.ArtifactType,
+				Config: image.Descriptor{
+					MediaType: registry.MediaTypeEmptyJSON,
+					Digest:    digest.FromString("{}"),
+					Size:      2,
+				},
+				Layers: []image.Descriptor{{
+					MediaType: testArtifact.MediaType,
+					Digest:    digest.FromBytes(testArtifact.Content),
+					Size:      int64(len(testArtifact.Content)),
+				}},
+				Subject: &image.Descriptor{
+					MediaType: image.MediaTypeOCIManifest,
+					Digest:    subject,
+					Size:      int64(len(`{"schemaVersion":2}`)),
+				},
+				Annotations: map[string]string{registry.AnnotationCreated: "2026-01-02T03:04:05Z"},
+			}, manifest)
+			require.Equal(t, testArtifact.Content, r.Blob(digest.FromBytes(testArtifact.Content)))
+			require.Equal(t, []byte("{}"), r.Blob(digest.FromString("{}")))
+		})
+	}
+}
+
+func Test_PushReferrer_GivenIndexAndPlatform_ShouldReferToPlatformImage(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{})
+	amd64 := r.PutManifest("app", "", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2,"amd64":true}`))
+	arm64 := r.PutManifest("app", "", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2,"arm64":true}`))
+	index, err := json.Marshal(image.Index{SchemaVersion: 2, Manifests: []image.Descriptor{
+		{MediaType: image.MediaTypeOCIManifest, Digest: amd64, Size: 1, Platform: linuxPlatform("amd64")},
+		{MediaType: image.MediaTypeOCIManifest, Digest: arm64, Size: 1, Platform: linuxPlatform("arm64")},
+	}})
+	require.NoError(t, err)
+	indexDigest := r.PutManifest("app", "latest", image.MediaTypeOCIIndex, index)
+
+	_, err = newClient(r).PushReferrer(context.Background(), r.Host()+"/app", "linux/arm64",
+		registry.Credentials{}, testArtifact)
+	require.NoError(t, err)
+	require.Len(t, r.Referrers("app", arm64), 1)
+
+	_, err = newClient(r).PushReferrer(context.Background(), r.Host()+"/app@"+indexDigest.String(), "",
+		registry.Credentials{}, testArtifact)
+	require.NoError(t, err)
+	require.Len(t, r.Referrers("app", indexDigest), 1)
+}
+
+func Test_Resolve_GivenTag_ShouldResolveImageDigest(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{Username: "user", Password: "secret", TokenAuth: true})
+	manifest := []byte(`{"schemaVersion":2}`)
+	d := r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, manifest)
+	creds := registry.Credentials{Username: "user", Password: "secret"}
+
+	resolved, err := newClient(r).Resolve(context.Background(), r.Host()+"/app:1.0", "", creds)
+	require.NoError(t, err)
+
+	desc := image.Descriptor{MediaType: image.MediaTypeOCIManifest, Digest: d, Size: int64(len(manifest))}
+	require.Equal(t, &registry.ResolvedImage{Descriptor: desc, Image: desc}, resolved)
+}
+
+func Test_Resolve_GivenIndex_ShouldResolvePlatformImage(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{})
+	amd64 := r.PutManifest("app", "", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2,"amd64":true}`))
+	arm64 := r.PutManifest("app", "", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2,"arm64":true}`))
+	index, err := json.Marshal(image.Index{SchemaVersion: 2, Manifests: []image.Descriptor{
+		{MediaType: image.MediaTypeOCIManifest, Digest: arm64, Size: 2, Platform: linuxPlatform("arm64")},
+		{MediaType: image.MediaTypeOCIManifest, Digest: amd64, Size: 1, Platform: linuxPlatform("amd64")},
+	}})
+	require.NoError(t, err)
+	indexDigest := r.PutManifest("app", "1.0", image.MediaTypeOCIIndex, index)
+
+	tests := map[string]struct {
+		platform         string
+		expectedImage    image.Descriptor
+		expectedPlatform string
+	}{
+		"default platform": {
+			expectedImage:    image.Descriptor{MediaType: image.MediaTypeOCIManifest, Digest: amd64, Size: 1},
+			expectedPlatform: "linux/amd64",
+		},
+		"given platform": {
+			platform:         "linux/arm64",
+			expectedImage:    image.Descriptor{MediaType: image.MediaTypeOCIManifest, Digest: arm64, Size: 2},
+			expectedPlatform: "linux/arm64",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			resolved, err := newClient(r).Resolve(context.Background(), r.Host()+"/app:1.0", tc.platform,
+				registry.Credentials{})
+			require.NoError(t, err)
+
+			require.Equal(t, indexDigest, resolved.Descriptor.Digest)
+			require.Equal(t, image.MediaTypeOCIIndex, resolved.Descriptor.MediaType)
+			require.Equal(t, tc.expectedImage, resolved.Image)
+			require.Equal(t, tc.expectedPlatform, resolved.Platform)
+		})
+	}
+
+	_, err = newClient(r).Resolve(context.Background(), r.Host()+"/app:1.0", "linux/s390x", registry.Credentials{})
+	require.EqualError(t, err, "no image found for platform linux/s390x")
+}
+
+func Test_Pull_GivenImage_ShouldWriteOCILayout(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{Username: "user", Password: "secret", TokenAuth: true})
+	layout, manifestDigest := imagetest.OCILayout(t, "", imagetest.Layer{"etc/os-release": "ID=alpine\n"})
+	r.PutImage("app", "1.0", layout, manifestDigest)
+	dir := t.TempDir()
+
+	resolved, err := newClient(r).Pull(context.Background(), r.Host()+"/app:1.0", "",
+		registry.Credentials{Username: "user", Password: "secret"}, dir)
+	require.NoError(t, err)
+	require.Equal(t, manifestDigest, resolved.Image.Digest)
+
+	img, err := image.Open(image.Reference{Transport: image.TransportOCILayout, Path: dir}, "")
+	require.NoError(t, err)
+	defer img.Close()
+	require.Equal(t, manifestDigest, img.ManifestDigest)
+	require.Equal(t, "linux/amd64", img.Platform)
+}
+
+func Test_Pull_GivenMissingImage_ShouldReturnErrNotFound(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{})
+
+	_, err := newClient(r).Pull(context.Background(), r.Host()+"/app:1.0", "", registry.Credentials{}, t.TempDir())
+	require.ErrorIs(t, err, registry.ErrNotFound)
+}
+
+func Test_PushReferrer_GivenRegistryWithoutReferrersAPI_ShouldUpdateReferrersTag(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{NoReferrersAPI: true})
+	subject := r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+	client := newClient(r)
+
+	first, err := client.PushReferrer(context.Background(), r.Host()+"/app:1.0", "", registry.Credentials{}, testArtifact)
+	require.NoError(t, err)
+	spdx := registry.Artifact{ArtifactType: "application/spdx+json", MediaType: "application/spdx+json",
+		Content: []byte(`{"spdxVersion":"SPDX-2.3"}`)}
+	second, err := client.PushReferrer(context.Background(), r.Host()+"/app:1.0", "", registry.Credentials{}, spdx)
+	require.NoError(t, err)
+
+	var index image.Index
+	require.NoError(t, json.Unmarshal(r.Manifest("app", "sha256-"+subject.Encoded()), &index))
+	require.Equal(t, []image.Descriptor{first, second}, index.Manifests)
+}
+
+func Test_PushReferrer_GivenInvalidCredentials_ShouldReturnErrUnauthorized(t *testing.T) {
+	for name, opts := range map[string]registrytest.Options{
+		"basic auth": {Username: "user", Password: "secret"},
+		"token auth": {Username: "user", Password: "secret", TokenAuth: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := registrytest.New(t, opts)
+			r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+
+			_, err := newClient(r).PushReferrer(context.Background(), r.Host()+"/app:1.0", "",
+				registry.Credentials{Username: "user", Password: "wrong"}, testArtifact)
+			require.ErrorIs(t, err, registry.ErrUnauthorized)
+		})
+	}
+}
+
+func Test_PushReferrer_GivenMissingImage_ShouldReturnError(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{})
+
+	_, err := newClient(r).PushReferrer(context.Background(), r.Host()+"/app:1.0", "",
+		registry.Credentials{}, testArtifact)
+	require.ErrorContains(t, err, "could not find image app:1.0 (status: 404 Not Found)")
+}
+
+func newClient(r *registrytest.Registry) *registry.Client {
+	return registry.NewClient(registry.ClientConfig{
+		Client: r.Client(),
+		Logger: &zlog.Logger,
+		Clock:  func() time.Time { return testTime },
+	})
+}
+
+func linuxPlatform(arch string) *image.Platform {
+	return &image.Platform{OS: "linux", Architecture: arch}
+}
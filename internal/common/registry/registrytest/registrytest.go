@@ -0,0 +1,290 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registrytest runs an in-memory container registry for tests.
+package registrytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/snyk/container-cli/internal/common/image"
+)
+
+// Options configure the behaviour of the registry.
+type Options struct {
+	// Username and Password are required if Username is set.
+	Username string
+	Password string
+	// TokenAuth makes the registry authenticate with bearer tokens issued by its own token endpoint,
+	// as Docker Hub does, instead of basic auth.
+	TokenAuth bool
+	// NoReferrersAPI makes the registry behave like registries predating OCI 1.1, which don't
+	// acknowledge the subject of manifests.
+	NoReferrersAPI bool
+}
+
+type manifest struct {
+	mediaType string
+	content   []byte
+}
+
+// Registry is an in-memory registry implementing the parts of the OCI distribution API needed to push
+// and pull manifests and blobs.
+type Registry struct {
+	*httptest.Server
+	opts Options
+
+	mu        sync.Mutex
+	manifests map[string]manifest
+	blobs     map[digest.Digest][]byte
+	uploads   int
+}
+
+const testToken = "registrytest-token"
+
+// New starts a TLS registry which is stopped when the test finishes.
+func New(t *testing.T, opts Options) *Registry {
+	t.Helper()
+
+	r := &Registry{
+		opts:      opts,
+		manifests: make(map[string]manifest),
+		blobs:     make(map[digest.Digest][]byte),
+	}
+	r.Server = httptest.NewTLSServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// Host returns the "host:port" of the registry, to be used in image references.
+func (r *Registry) Host() string {
+	u, _ := url.Parse(r.URL)
+	return u.Host
+}
+
+// PutManifest stores a manifest under its digest and, if not empty, the tag and returns its digest.
+func (r *Registry) PutManifest(repository, tag, mediaType string, content []byte) digest.Digest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := digest.FromBytes(content)
+	r.manifests[repository+"@"+d.String()] = manifest{mediaType: mediaType, content: content}
+	if tag != "" {
+		r.manifests[repository+":"+tag] = manifest{mediaType: mediaType, content: content}
+	}
+	return d
+}
+
+// PutBlob stores a blob and returns its digest.
+func (r *Registry) PutBlob(content []byte) digest.Digest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := digest.FromBytes(content)
+	r.blobs[d] = content
+	return d
+}
+
+// PutImage stores the image of an OCI image layout, as returned by imagetest.OCILayout, and tags its manifest.
+func (r *Registry) PutImage(repository, tag string, layout map[string][]byte, manifestDigest digest.Digest) {
+	for name, content := range layout {
+		if strings.HasPrefix(name, "blobs/") {
+			r.PutBlob(content)
+		}
+	}
+	r.PutManifest(repository, tag, image.MediaTypeOCIManifest, layout["blobs/sha256/"+manifestDigest.Encoded()])
+}
+
+// Manifest returns the content of the manifest with the given tag or digest, or nil if it doesn't exist.
+func (r *Registry) Manifest(repository, ref string) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.manifests[manifestKey(repository, ref)].content
+}
+
+// Blob returns the content of the blob, or nil if it doesn't exist.
+func (r *Registry) Blob(d digest.Digest) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.blobs[d]
+}
+
+// Referrers returns the manifests in the repository whose subject is the given digest.
+func (r *Registry) Referrers(repository string, subject digest.Digest) []image.Manifest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var referrers []image.Manifest
+	for key, m := range r.manifests {
+		if !strings.HasPrefix(key, repository+"@") {
+			continue
+		}
+		var mf image.Manifest
+		if json.Unmarshal(m.content, &mf) == nil && mf.Subject != nil && mf.Subject.Digest == subject {
+			referrers = append(referrers, mf)
+		}
+	}
+	return referrers
+}
+
+func manifestKey(repository, ref string) string {
+	if _, err := digest.Parse(ref); err == nil {
+		return repository + "@" + ref
+	}
+	return repository + ":" + ref
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+	if !r.authorized(req) {
+		if r.opts.TokenAuth {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="registrytest"`, r.URL))
+		} else {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registrytest"`)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		i := strings.LastIndex(path, "/manifests/")
+		r.serveManifest(w, req, path[:i], path[i+len("/manifests/"):])
+	case strings.Contains(path, "/blobs/uploads/"):
+		r.serveUpload(w, req, path[:strings.LastIndex(path, "/blobs/uploads/")])
+	case strings.Contains(path, "/blobs/"):
+		r.serveBlob(w, req, digest.Digest(path[strings.LastIndex(path, "/blobs/")+len("/blobs/"):]))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *Registry) authorized(req *http.Request) bool {
+	if r.opts.Username == "" {
+		return true
+	}
+	if r.opts.TokenAuth {
+		return req.Header.Get("Authorization") == "Bearer "+testToken
+	}
+	username, password, ok := req.BasicAuth()
+	return ok && username == r.opts.Username && password == r.opts.Password
+}
+
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	if !ok || username != r.opts.Username || password != r.opts.Password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": testToken})
+}
+
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, repository, ref string) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		r.mu.Lock()
+		m, ok := r.manifests[manifestKey(repository, ref)]
+		r.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(m.content).String())
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(m.content)
+		}
+	case http.MethodPut:
+		content, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if d, err := digest.Parse(ref); err == nil && d != digest.FromBytes(content) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		tag := ref
+		if manifestKey(repository, ref) != repository+":"+ref {
+			tag = ""
+		}
+		d := r.PutManifest(repository, tag, req.Header.Get("Content-Type"), content)
+
+		var mf image.Manifest
+		if !r.opts.NoReferrersAPI && json.Unmarshal(content, &mf) == nil && mf.Subject != nil {
+			w.Header().Set("OCI-Subject", mf.Subject.Digest.String())
+		}
+		w.Header().Set("Docker-Content-Digest", d.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request, repository string) {
+	switch req.Method {
+	case http.MethodPost:
+		r.mu.Lock()
+		r.uploads++
+		id := r.uploads
+		r.mu.Unlock()
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", repository, id))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		content, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		d, err := digest.Parse(req.URL.Query().Get("digest"))
+		if err != nil || d != digest.FromBytes(content) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.mu.Lock()
+		r.blobs[d] = content
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) serveBlob(w http.ResponseWriter, req *http.Request, d digest.Digest) {
+	content := r.Blob(d)
+	if content == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if req.Method == http.MethodGet {
+		_, _ = w.Write(content)
+	}
+}
@@ -0,0 +1,46 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflows
+
+import (
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+)
+
+// TargetArgs returns the non-empty positional arguments of the command, which the host passes either as a
+// single string or as a list.
+func TargetArgs(config configuration.Configuration) []string {
+	var args []string
+	switch v := config.Get(constants.ContainerTargetArgName).(type) {
+	case string:
+		args = append(args, v)
+	case []string:
+		args = append(args, v...)
+	case []any:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				args = append(args, s)
+			}
+		}
+	}
+
+	result := args[:0]
+	for _, arg := range args {
+		if arg != "" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
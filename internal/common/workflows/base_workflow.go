@@ -15,6 +15,7 @@
 package workflows
 
 import (
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
 	"github.com/snyk/container-cli/internal/common/flags"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 	"github.com/spf13/pflag"
@@ -37,3 +38,14 @@ func (w *BaseWorkflow) GetConfigurationOptionsFromFlagSet() workflow.Configurati
 
 	return workflow.ConfigurationOptionsFromFlagset(fs)
 }
+
+// JSONErrors wraps the entrypoint of a workflow to render its errors as JSON if the --json flag is set.
+func JSONErrors(entrypoint workflow.Callback) workflow.Callback {
+	return func(ictx workflow.InvocationContext, input []workflow.Data) ([]workflow.Data, error) {
+		output, err := entrypoint(ictx, input)
+		if err != nil && flags.FlagJSON.GetFlagValue(ictx.GetConfiguration()) {
+			return output, containererrors.NewJSONError(err)
+		}
+		return output, err
+	}
+}
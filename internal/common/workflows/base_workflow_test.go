@@ -0,0 +1,54 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflows
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	zlog "github.com/rs/zerolog/log"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/mocks"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JSONErrors_GivenJSONFlag_ShouldRenderErrorAsJSON(t *testing.T) {
+	cause := containererrors.NewErrorFactory(&zlog.Logger).
+		NewError(containererrors.CodePrune, errors.New("permission denied"), "The cache could not be pruned.")
+	entrypoint := JSONErrors(func(workflow.InvocationContext, []workflow.Data) ([]workflow.Data, error) {
+		return nil, cause
+	})
+
+	for _, jsonFlag := range []bool{false, true} {
+		config := configuration.NewInMemory()
+		config.Set(flags.FlagJSON.Name, jsonFlag)
+		ictx := mocks.NewMockInvocationContext(gomock.NewController(t))
+		ictx.EXPECT().GetConfiguration().Return(config)
+
+		_, err := entrypoint(ictx, nil)
+
+		require.ErrorIs(t, err, cause)
+		if jsonFlag {
+			require.JSONEq(t, `{"code":"SNYK-CONTAINER-0036","category":"internal",`+
+				`"message":"The cache could not be pruned.","detail":"permission denied"}`, err.Error())
+		} else {
+			require.EqualError(t, err, "The cache could not be pruned.")
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cacheprune
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/snyk/container-cli/internal/common/cache"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/workflows"
+	cachepruneerrors "github.com/snyk/container-cli/internal/workflows/cacheprune/errors"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+const dataTypeCachePrune = "cache-prune"
+
+// Workflow represents the workflow pruning the depgraph cache
+type Workflow struct {
+	workflows.BaseWorkflow
+	errFactory *cachepruneerrors.CachePruneErrorFactory
+}
+
+// NewWorkflow creates a new cache prune workflow value
+func NewWorkflow(errFactory *cachepruneerrors.CachePruneErrorFactory) *Workflow {
+	return &Workflow{
+		BaseWorkflow: workflows.BaseWorkflow{
+			Name:  "container cache prune",
+			Flags: slices.Concat([]flags.Flag{flags.FlagAll, flags.FlagJSON}, flags.CacheFlags),
+		},
+		errFactory: errFactory,
+	}
+}
+
+// Init registers the workflow for the provided engine
+func (w *Workflow) Init(e workflow.Engine) error {
+	_, err := e.Register(
+		w.Identifier(),
+		w.GetConfigurationOptionsFromFlagSet(),
+		workflows.JSONErrors(w.entrypoint),
+	)
+	return err
+}
+
+func (w *Workflow) entrypoint(ictx workflow.InvocationContext, _ []workflow.Data) ([]workflow.Data, error) {
+	logger := ictx.GetEnhancedLogger()
+	logger.Info().Msg("starting the cache prune workflow")
+
+	config := ictx.GetConfiguration()
+	if _, err := flags.Resolve(config, w.Flags); err != nil {
+		return nil, w.errFactory.NewConfigFileError(err)
+	}
+
+	c, err := cache.NewFromConfiguration(config)
+	if err != nil {
+		return nil, w.errFactory.NewCacheConfigError(err)
+	}
+
+	all := flags.FlagAll.GetFlagValue(config)
+	logger.Debug().Msgf("pruning the depgraph cache %s (all: %t)", c.Dir(), all)
+	result, err := c.Prune(all)
+	if err != nil {
+		return nil, w.errFactory.NewPruneError(c.Dir(), err)
+	}
+
+	summary := fmt.Sprintf("Removed %d cached depgraphs (%s) from %s, %d remaining\n",
+		result.Removed, formatSize(result.Freed), c.Dir(), result.Remaining)
+	return []workflow.Data{workflow.NewData(w.typeIdentifier(), "text/plain", []byte(summary))}, nil
+}
+
+// formatSize renders a size in bytes with a binary unit.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func (w *Workflow) typeIdentifier() workflow.Identifier {
+	return workflow.NewTypeIdentifier(w.Identifier(), dataTypeCachePrune)
+}
@@ -0,0 +1,98 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cacheprune
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/cache"
+	"github.com/snyk/container-cli/internal/common/flags"
+	cachepruneerrors "github.com/snyk/container-cli/internal/workflows/cacheprune/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/mocks"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var errFactory = cachepruneerrors.NewCachePruneErrorFactory(&zlog.Logger)
+
+func Test_Entrypoint_GivenAll_ShouldRemoveAllEntries(t *testing.T) {
+	dir := t.TempDir()
+	c := cache.New(cache.Config{Dir: dir, TTL: cache.DefaultTTL, MaxSize: cache.DefaultMaxSize})
+	require.NoError(t, c.Put("alpine@sha256:abc", []byte("depgraph")))
+	require.NoError(t, c.Put("nginx@sha256:def", []byte("depgraph")))
+
+	config, ictx := setup(t)
+	config.Set(flags.FlagCacheDir.Name, dir)
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Equal(t, "Removed 0 cached depgraphs (0 B) from "+dir+", 2 remaining\n",
+		string(result[0].GetPayload().([]byte)))
+
+	config, ictx = setup(t)
+	config.Set(flags.FlagCacheDir.Name, dir)
+	config.Set(flags.FlagAll.Name, true)
+
+	result, err = NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+	require.Regexp(t, `^Removed 2 cached depgraphs \(\d+ B\) from .*, 0 remaining\n$`,
+		string(result[0].GetPayload().([]byte)))
+
+	_, ok := c.Get("alpine@sha256:abc")
+	require.False(t, ok)
+}
+
+func Test_Entrypoint_GivenInvalidConfiguration_ShouldReturnError(t *testing.T) {
+	config, ictx := setup(t)
+	config.Set(flags.FlagCacheDir.Name, t.TempDir())
+	config.Set(flags.FlagCacheTTL.Name, "-1h")
+
+	_, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.ErrorContains(t, err, `The depgraph cache could not be configured: invalid cache TTL -1h0m0s`)
+}
+
+func Test_FormatSize_GivenSize_ShouldUseBinaryUnits(t *testing.T) {
+	require.Equal(t, "512 B", formatSize(512))
+	require.Equal(t, "1.5 KiB", formatSize(1536))
+	require.Equal(t, "64.0 MiB", formatSize(64<<20))
+}
+
+func Test_Init_GivenWorkflowFlags_ShouldRegisterFlags(t *testing.T) {
+	config := configuration.New()
+	engine := workflow.NewWorkFlowEngine(config)
+
+	err := NewWorkflow(nil).Init(engine)
+	require.NoError(t, err)
+
+	require.NotNil(t, config.Get(flags.FlagAll.Name))
+	require.NotNil(t, config.Get(flags.FlagCacheDir.Name))
+}
+
+func setup(t *testing.T) (configuration.Configuration, *mocks.MockInvocationContext) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	config := configuration.NewInMemory()
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetConfiguration().Return(config)
+
+	return config, ictx
+}
@@ -0,0 +1,48 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+)
+
+type CachePruneErrorFactory struct {
+	*containererrors.ErrorFactory
+}
+
+func NewCachePruneErrorFactory(logger *zerolog.Logger) *CachePruneErrorFactory {
+	return &CachePruneErrorFactory{
+		ErrorFactory: containererrors.NewErrorFactory(logger),
+	}
+}
+
+func (ef *CachePruneErrorFactory) NewCacheConfigError(err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeCacheConfig,
+		fmt.Errorf("invalid cache configuration: %w", err),
+		fmt.Sprintf("The depgraph cache could not be configured: %s.", err),
+	)
+}
+
+func (ef *CachePruneErrorFactory) NewPruneError(dir string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodePrune,
+		fmt.Errorf("could not prune cache %s: %w", dir, err),
+		fmt.Sprintf("The depgraph cache (%s) could not be pruned: %s.", dir, err),
+	)
+}
@@ -0,0 +1,135 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/redact"
+	"github.com/snyk/container-cli/internal/common/workflows"
+	configerrors "github.com/snyk/container-cli/internal/workflows/config/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+const dataTypeConfig = "config"
+
+// Workflow represents the workflow printing the effective configuration of the container workflows
+type Workflow struct {
+	workflows.BaseWorkflow
+	errFactory *configerrors.ConfigErrorFactory
+}
+
+// NewWorkflow creates a new config workflow value
+func NewWorkflow(errFactory *configerrors.ConfigErrorFactory) *Workflow {
+	return &Workflow{
+		BaseWorkflow: workflows.BaseWorkflow{
+			Name:  "container config",
+			Flags: flags.AllFlags,
+		},
+		errFactory: errFactory,
+	}
+}
+
+// Init registers the workflow for the provided engine
+func (w *Workflow) Init(e workflow.Engine) error {
+	_, err := e.Register(
+		w.Identifier(),
+		w.GetConfigurationOptionsFromFlagSet(),
+		workflows.JSONErrors(w.entrypoint),
+	)
+	return err
+}
+
+// setting is the effective value of a flag as printed by the workflow.
+type setting struct {
+	Name   string       `json:"name"`
+	Value  string       `json:"value"`
+	Source flags.Source `json:"source"`
+	EnvVar string       `json:"envVar,omitempty"`
+}
+
+func (w *Workflow) entrypoint(ictx workflow.InvocationContext, _ []workflow.Data) ([]workflow.Data, error) {
+	logger := ictx.GetEnhancedLogger()
+	logger.Info().Msg("starting the config workflow")
+
+	config := ictx.GetConfiguration()
+	resolved, err := flags.Resolve(config, w.Flags)
+	if err != nil {
+		return nil, w.errFactory.NewConfigFileError(err)
+	}
+
+	settings := make([]setting, 0, len(resolved))
+	for _, r := range resolved {
+		settings = append(settings, setting{
+			Name:   r.Flag.GetName(),
+			Value:  formatValue(config, r.Flag),
+			Source: r.Source,
+			EnvVar: r.Flag.GetEnvVar(),
+		})
+	}
+
+	if flags.FlagJSON.GetFlagValue(config) {
+		out, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return nil, w.errFactory.NewInternalError(fmt.Errorf("failed to marshal configuration: %w", err))
+		}
+		return []workflow.Data{workflow.NewData(w.typeIdentifier(), constants.ContentTypeJSON, out)}, nil
+	}
+	return []workflow.Data{workflow.NewData(w.typeIdentifier(), "text/plain", renderText(settings))}, nil
+}
+
+// formatValue renders the effective value of a flag like it is passed on the command line, masking secrets.
+func formatValue(config configuration.Configuration, f flags.Flag) string {
+	var value string
+	switch v := config.Get(f.GetName()).(type) {
+	case nil:
+	case string:
+		value = v
+	case []string:
+		value = strings.Join(v, ",")
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	if value != "" && f.IsSensitive() {
+		return redact.Mask
+	}
+	return value
+}
+
+func renderText(settings []setting) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Flags are taken from the command line, the environment, %s and their defaults, "+
+		"in this order.\n\n", flags.ConfigFileName)
+
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLAG\tVALUE\tSOURCE\tENVIRONMENT VARIABLE")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Value, s.Source, s.EnvVar)
+	}
+	_ = tw.Flush()
+	return buf.Bytes()
+}
+
+func (w *Workflow) typeIdentifier() workflow.Identifier {
+	return workflow.NewTypeIdentifier(w.Identifier(), dataTypeConfig)
+}
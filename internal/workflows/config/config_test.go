@@ -0,0 +1,106 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/redact"
+	configerrors "github.com/snyk/container-cli/internal/workflows/config/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/mocks"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var errFactory = configerrors.NewConfigErrorFactory(&zlog.Logger)
+
+func Test_Entrypoint_GivenJSON_ShouldPrintSettingsWithMaskedSecrets(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(flags.ConfigFileName, []byte("platform: linux/arm64\n"), 0o600))
+	t.Setenv(flags.FlagPassword.GetEnvVar(), "hunter2")
+
+	config, ictx := setup(t)
+	config.Set(flags.FlagJSON.Name, true)
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	var settings []setting
+	require.NoError(t, json.Unmarshal(result[0].GetPayload().([]byte), &settings))
+	require.Len(t, settings, len(flags.AllFlags))
+	require.Contains(t, settings, setting{
+		Name: "platform", Value: "linux/arm64", Source: flags.SourceFile, EnvVar: "SNYK_CONTAINER_PLATFORM",
+	})
+	require.Contains(t, settings, setting{
+		Name: "password", Value: redact.Mask, Source: flags.SourceEnv, EnvVar: "SNYK_CONTAINER_PASSWORD",
+	})
+	require.Contains(t, settings, setting{Name: "json", Value: "true", Source: flags.SourceDefault})
+	require.NotContains(t, string(result[0].GetPayload().([]byte)), "hunter2")
+}
+
+func Test_Entrypoint_GivenNoJSON_ShouldPrintTable(t *testing.T) {
+	t.Chdir(t.TempDir())
+	config, ictx := setup(t)
+	config.Set(flags.FlagCacheDir.Name, "/tmp/depgraphs")
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	out := string(result[0].GetPayload().([]byte))
+	require.Contains(t, out, "FLAG ")
+	require.Regexp(t, `\ncache-dir +/tmp/depgraphs +default +SNYK_CONTAINER_CACHE_DIR\n`, out)
+}
+
+func Test_Entrypoint_GivenInvalidConfigFile_ShouldReturnError(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(flags.ConfigFileName, []byte("fromat: spdx2.3+json\n"), 0o600))
+	_, ictx := setup(t)
+
+	_, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.EqualError(t, err,
+		`The configuration could not be read: .snyk-container.yaml: unknown option "fromat".`)
+}
+
+func Test_Init_GivenWorkflowFlags_ShouldRegisterFlags(t *testing.T) {
+	config := configuration.New()
+	engine := workflow.NewWorkFlowEngine(config)
+
+	err := NewWorkflow(nil).Init(engine)
+	require.NoError(t, err)
+
+	for _, f := range flags.AllFlags {
+		require.NotNil(t, config.Get(f.GetName()), f.GetName())
+	}
+}
+
+func setup(t *testing.T) (configuration.Configuration, *mocks.MockInvocationContext) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	config := configuration.NewInMemory()
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetConfiguration().Return(config)
+
+	return config, ictx
+}
@@ -0,0 +1,39 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"github.com/rs/zerolog"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+)
+
+type ConfigErrorFactory struct {
+	*containererrors.ErrorFactory
+}
+
+func NewConfigErrorFactory(logger *zerolog.Logger) *ConfigErrorFactory {
+	return &ConfigErrorFactory{
+		ErrorFactory: containererrors.NewErrorFactory(logger),
+	}
+}
+
+func (ef *ConfigErrorFactory) NewInternalError(err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInternal,
+		err,
+		"An error occurred while printing the configuration. "+
+			"Should this issue persist, please reach out to customer support.",
+	)
+}
@@ -0,0 +1,229 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analyzer produces dep-graphs for the operating system packages of container images without
+// shelling out to the legacy CLI. It reads apk and dpkg databases; images with an rpm database are left to
+// the legacy CLI, see ErrUnsupportedPackageDatabase, and so are application dependencies, which are only
+// reported as Result.AppManifests.
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/snyk/container-cli/internal/common/image"
+)
+
+const (
+	apkInstalledFile      = "lib/apk/db/installed"
+	dpkgStatusFile        = "var/lib/dpkg/status"
+	dpkgStatusDir         = "var/lib/dpkg/status.d/"
+	osReleaseFile         = "etc/os-release"
+	osReleaseFallbackFile = "usr/lib/os-release"
+
+	// RootPkgPrefix prefixes the name of the root package of container dep-graphs.
+	RootPkgPrefix = "docker-image|"
+)
+
+// rpmDatabaseFiles are the locations of the rpm databases we know about, but can't read.
+var rpmDatabaseFiles = []string{
+	"var/lib/rpm/Packages",
+	"var/lib/rpm/Packages.db",
+	"var/lib/rpm/rpmdb.sqlite",
+	"usr/lib/sysimage/rpm/Packages.db",
+	"usr/lib/sysimage/rpm/rpmdb.sqlite",
+}
+
+// appManifestFiles are the files the legacy CLI finds application dependencies in, which the analyzer doesn't
+// read. appArchiveExtensions are the extensions of the java archives it looks into.
+var (
+	appManifestFiles = []string{
+		"package.json",
+		"package-lock.json",
+		"yarn.lock",
+		"pnpm-lock.yaml",
+		"composer.lock",
+		"Pipfile.lock",
+		"poetry.lock",
+		"requirements.txt",
+	}
+	appArchiveExtensions = []string{".jar", ".war", ".ear"}
+)
+
+// ErrNoPackageDatabase is returned if the image does not contain a supported package database.
+var ErrNoPackageDatabase = errors.New("no supported package database (apk, dpkg) found in the image")
+
+// ErrUnsupportedPackageDatabase is returned if the image contains a package database the analyzer can't read.
+var ErrUnsupportedPackageDatabase = errors.New("rpm based images are not supported by the native analyzer")
+
+// Result is the dep-graph of an analysed image.
+type Result struct {
+	// Target identifies the analysed image in the same way the legacy CLI does, e.g. "docker-image|alpine:3.17".
+	Target   string
+	DepGraph *depgraph.DepGraph
+	// AppManifests are the paths of the files in the image the dependencies of applications are declared in,
+	// which are not part of the dep-graph. Files deleted by upper layers may be listed too.
+	AppManifests []string
+}
+
+// Analyze builds the dep-graph of the operating system packages installed in img. The name and
+// version identify the image and become the root package of the dep-graph. Reading the layers is aborted once
+// the context is done.
+func Analyze(ctx context.Context, img *image.Image, name, version string) (*Result, error) {
+	var appManifests []string
+	files, err := collectFiles(ctx, img, func(name string) bool {
+		if isAppManifest(name) {
+			appManifests = append(appManifests, name)
+		}
+		return isAnalyzedFile(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(appManifests)
+	appManifests = slices.Compact(appManifests)
+
+	osRelease := files[osReleaseFile]
+	if osRelease == nil {
+		osRelease = files[osReleaseFallbackFile]
+	}
+	distro := parseOSRelease(osRelease)
+
+	var (
+		pkgManager string
+		pkgs       []osPackage
+	)
+	switch {
+	case files[apkInstalledFile] != nil:
+		pkgManager = "apk"
+		pkgs = parseApkInstalled(files[apkInstalledFile])
+	case hasDpkgDatabase(files):
+		pkgManager = "deb"
+		pkgs = dpkgPackages(files)
+	case hasAny(files, rpmDatabaseFiles):
+		return nil, ErrUnsupportedPackageDatabase
+	default:
+		return nil, ErrNoPackageDatabase
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Name < pkgs[j].Name || (pkgs[i].Name == pkgs[j].Name && pkgs[i].Version < pkgs[j].Version)
+	})
+
+	pm := depgraph.PkgManager{Name: pkgManager}
+	if distro["ID"] != "" {
+		pm.Repositories = []depgraph.Repository{{Alias: fmt.Sprintf("%s:%s", distro["ID"], distro["VERSION_ID"])}}
+	}
+
+	b := depgraph.NewBuilder(pm, depgraph.PkgInfo{Name: RootPkgPrefix + name, Version: version})
+	for _, p := range pkgs {
+		b.AddDependency(depgraph.PkgInfo{
+			Name:    pkgName(pkgManager, p),
+			Version: p.Version,
+			PURL:    purl(pkgManager, distro, p),
+		})
+	}
+
+	target := RootPkgPrefix + name
+	if version != "" {
+		target += ":" + version
+	}
+	return &Result{Target: target, DepGraph: b.Build(), AppManifests: appManifests}, nil
+}
+
+func isAppManifest(name string) bool {
+	base := path.Base(name)
+	return slices.Contains(appManifestFiles, base) || slices.Contains(appArchiveExtensions, path.Ext(base))
+}
+
+func isAnalyzedFile(name string) bool {
+	switch name {
+	case apkInstalledFile, dpkgStatusFile, osReleaseFile, osReleaseFallbackFile:
+		return true
+	}
+	for _, f := range rpmDatabaseFiles {
+		if name == f {
+			return true
+		}
+	}
+	return strings.HasPrefix(name, dpkgStatusDir)
+}
+
+func hasDpkgDatabase(files map[string][]byte) bool {
+	for name := range files {
+		if name == dpkgStatusFile || strings.HasPrefix(name, dpkgStatusDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func dpkgPackages(files map[string][]byte) []osPackage {
+	pkgs := parseDpkgStatus(files[dpkgStatusFile])
+	for name, content := range files {
+		if strings.HasPrefix(name, dpkgStatusDir) {
+			pkgs = append(pkgs, parseDpkgStatus(content)...)
+		}
+	}
+	return pkgs
+}
+
+func hasAny(files map[string][]byte, names []string) bool {
+	for _, n := range names {
+		if _, ok := files[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pkgName returns the package name the way Snyk identifies packages: debian packages are
+// qualified with their source package, so that vulnerabilities reported against the source match.
+func pkgName(pkgManager string, p osPackage) string {
+	if pkgManager == "deb" && p.Source != "" {
+		return p.Source + "/" + p.Name
+	}
+	return p.Name
+}
+
+func purl(pkgManager string, distro map[string]string, p osPackage) string {
+	namespace := distro["ID"]
+	if namespace == "" {
+		namespace = map[string]string{"apk": "alpine", "deb": "debian"}[pkgManager]
+	}
+
+	q := url.Values{}
+	if p.Arch != "" {
+		q.Set("arch", p.Arch)
+	}
+	if distro["ID"] != "" && distro["VERSION_ID"] != "" {
+		q.Set("distro", distro["ID"]+"-"+distro["VERSION_ID"])
+	}
+	if p.Source != "" {
+		q.Set("upstream", p.Source)
+	}
+
+	s := fmt.Sprintf("pkg:%s/%s/%s@%s", pkgManager, namespace, url.PathEscape(p.Name), url.PathEscape(p.Version))
+	if len(q) > 0 {
+		s += "?" + q.Encode()
+	}
+	return s
+}
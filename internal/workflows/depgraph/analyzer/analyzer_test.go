@@ -0,0 +1,206 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/stretchr/testify/require"
+)
+
+const alpineOSRelease = `NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.17.0
+`
+
+const apkInstalled = `C:Q1abc=
+P:musl
+V:1.2.3-r4
+A:x86_64
+o:musl
+
+P:busybox
+V:1.35.0-r29
+A:x86_64
+o:busybox
+
+P:libcrypto3
+V:3.0.8-r0
+A:x86_64
+o:openssl
+`
+
+const dpkgStatus = `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Source: glibc
+Version: 2.36-9
+Description: GNU C Library
+ multi-line description
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+
+Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2
+`
+
+func Test_Analyze_GivenAlpineImage_ShouldReturnApkDepGraph(t *testing.T) {
+	img := openDockerArchive(t,
+		imagetest.Layer{"etc/os-release": alpineOSRelease, "lib/apk/db/installed": apkInstalled},
+	)
+
+	result, err := Analyze(context.Background(), img, "alpine", "3.17.0")
+	require.NoError(t, err)
+
+	require.Equal(t, "docker-image|alpine:3.17.0", result.Target)
+	require.Equal(t, depgraph.PkgManager{
+		Name:         "apk",
+		Repositories: []depgraph.Repository{{Alias: "alpine:3.17.0"}},
+	}, result.DepGraph.PkgManager)
+	require.Equal(t, []depgraph.Pkg{
+		{ID: "docker-image|alpine@3.17.0", Info: depgraph.PkgInfo{Name: "docker-image|alpine", Version: "3.17.0"}},
+		{ID: "busybox@1.35.0-r29", Info: depgraph.PkgInfo{
+			Name:    "busybox",
+			Version: "1.35.0-r29",
+			PURL:    "pkg:apk/alpine/busybox@1.35.0-r29?arch=x86_64&distro=alpine-3.17.0",
+		}},
+		{ID: "libcrypto3@3.0.8-r0", Info: depgraph.PkgInfo{
+			Name:    "libcrypto3",
+			Version: "3.0.8-r0",
+			PURL:    "pkg:apk/alpine/libcrypto3@3.0.8-r0?arch=x86_64&distro=alpine-3.17.0&upstream=openssl",
+		}},
+		{ID: "musl@1.2.3-r4", Info: depgraph.PkgInfo{
+			Name:    "musl",
+			Version: "1.2.3-r4",
+			PURL:    "pkg:apk/alpine/musl@1.2.3-r4?arch=x86_64&distro=alpine-3.17.0",
+		}},
+	}, result.DepGraph.Pkgs)
+	require.Equal(t, depgraph.RootNodeID, result.DepGraph.Graph.RootNodeID)
+	require.Equal(t,
+		[]depgraph.Dep{{NodeID: "busybox@1.35.0-r29"}, {NodeID: "libcrypto3@3.0.8-r0"}, {NodeID: "musl@1.2.3-r4"}},
+		result.DepGraph.Graph.Nodes[0].Deps)
+	require.Empty(t, result.AppManifests)
+}
+
+func Test_Analyze_GivenDebianImage_ShouldReturnInstalledPackagesQualifiedBySource(t *testing.T) {
+	img := openDockerArchive(t,
+		imagetest.Layer{"usr/lib/os-release": "ID=debian\nVERSION_ID=\"12\"\n", "var/lib/dpkg/status": dpkgStatus},
+		imagetest.Layer{"var/lib/dpkg/status.d/curl": "Package: curl\nVersion: 7.88.1-10\n"},
+	)
+
+	result, err := Analyze(context.Background(), img, "debian", "12")
+	require.NoError(t, err)
+
+	require.Equal(t, "deb", result.DepGraph.PkgManager.Name)
+	require.Equal(t, []string{"docker-image|debian@12", "bash@5.2.15-2", "curl@7.88.1-10", "glibc/libc6@2.36-9"},
+		pkgIDs(result.DepGraph))
+}
+
+func Test_Analyze_GivenPackageDatabaseRemovedInUpperLayer_ShouldReturnNoPackageDatabaseError(t *testing.T) {
+	type test struct {
+		upper imagetest.Layer
+	}
+
+	tests := map[string]test{
+		"file whiteout":   {imagetest.Layer{"lib/apk/db/.wh.installed": ""}},
+		"dir whiteout":    {imagetest.Layer{"lib/apk/.wh.db": ""}},
+		"opaque whiteout": {imagetest.Layer{"lib/apk/db/.wh..wh..opq": ""}},
+		"parent whiteout": {imagetest.Layer{"lib/.wh.apk": ""}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			img := openDockerArchive(t, imagetest.Layer{"lib/apk/db/installed": apkInstalled}, tc.upper)
+
+			_, err := Analyze(context.Background(), img, "alpine", "")
+			require.ErrorIs(t, err, ErrNoPackageDatabase)
+		})
+	}
+}
+
+func Test_Analyze_GivenApplications_ShouldReturnTheirManifests(t *testing.T) {
+	img := openDockerArchive(t,
+		imagetest.Layer{"etc/os-release": alpineOSRelease, "lib/apk/db/installed": apkInstalled},
+		imagetest.Layer{"app/package.json": "{}", "app/package-lock.json": "{}", "opt/app.jar": "jar"},
+		imagetest.Layer{"app/package.json": "{}", "usr/share/doc/README": "readme"},
+	)
+
+	result, err := Analyze(context.Background(), img, "alpine", "3.17.0")
+	require.NoError(t, err)
+	require.Equal(t, []string{"app/package-lock.json", "app/package.json", "opt/app.jar"}, result.AppManifests)
+}
+
+func Test_Analyze_GivenRpmImage_ShouldReturnUnsupportedPackageDatabaseError(t *testing.T) {
+	img := openDockerArchive(t, imagetest.Layer{"var/lib/rpm/rpmdb.sqlite": "sqlite"})
+
+	_, err := Analyze(context.Background(), img, "ubi", "9")
+	require.ErrorIs(t, err, ErrUnsupportedPackageDatabase)
+}
+
+func Test_Analyze_GivenContextDone_ShouldStopReadingLayers(t *testing.T) {
+	img := openDockerArchive(t,
+		imagetest.Layer{"etc/os-release": alpineOSRelease, "lib/apk/db/installed": apkInstalled})
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := Analyze(ctx, img, "alpine", "3.17.0")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_Analyze_GivenOCILayout_ShouldReturnDepGraph(t *testing.T) {
+	dir := t.TempDir()
+	imagetest.WriteOCILayout(t, dir, "3.17.0",
+		imagetest.Layer{"etc/os-release": alpineOSRelease, "lib/apk/db/installed": apkInstalled})
+
+	img, err := image.Open(image.Reference{Transport: image.TransportOCILayout, Path: dir}, "")
+	require.NoError(t, err)
+	defer img.Close()
+
+	result, err := Analyze(context.Background(), img, "alpine", img.Tag)
+	require.NoError(t, err)
+	require.Equal(t, "docker-image|alpine:3.17.0", result.Target)
+	require.Len(t, result.DepGraph.Pkgs, 4)
+}
+
+func openDockerArchive(t *testing.T, layers ...imagetest.Layer) *image.Image {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "image.tar")
+	imagetest.WriteDockerArchive(t, path, nil, layers...)
+
+	img, err := image.Open(image.Reference{Transport: image.TransportDockerArchive, Path: path}, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = img.Close() })
+	return img
+}
+
+func pkgIDs(g *depgraph.DepGraph) []string {
+	ids := make([]string, 0, len(g.Pkgs))
+	for _, p := range g.Pkgs {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
@@ -0,0 +1,104 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/image"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+
+	// maxFileSize protects against unreasonably large package databases.
+	maxFileSize = 256 << 20
+)
+
+type layerFile struct {
+	layer   int
+	content []byte
+}
+
+// collectFiles returns the content of all files selected by wanted, as they are visible in the final
+// image filesystem. Files deleted by whiteouts in upper layers are not returned. The walk stops with the error
+// of the context once it is done.
+func collectFiles(
+	ctx context.Context,
+	img *image.Image,
+	wanted func(name string) bool,
+) (map[string][]byte, error) {
+	files := make(map[string]layerFile)
+
+	err := img.WalkLayers(func(layer int, hdr *tar.Header, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := normalizePath(hdr.Name)
+		dir, base := path.Split(name)
+
+		switch {
+		case base == whiteoutOpaque:
+			for existing, f := range files {
+				if f.layer < layer && strings.HasPrefix(existing, dir) {
+					delete(files, existing)
+				}
+			}
+			return nil
+		case strings.HasPrefix(base, whiteoutPrefix):
+			removed := dir + strings.TrimPrefix(base, whiteoutPrefix)
+			for existing := range files {
+				if existing == removed || strings.HasPrefix(existing, removed+"/") {
+					delete(files, existing)
+				}
+			}
+			return nil
+		}
+
+		if !wanted(name) {
+			return nil
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			// a non-regular file replaces whatever lower layers had at this path.
+			delete(files, name)
+			return nil
+		}
+
+		content, err := io.ReadAll(io.LimitReader(r, maxFileSize))
+		if err != nil {
+			return err
+		}
+		files[name] = layerFile{layer: layer, content: content}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte, len(files))
+	for name, f := range files {
+		result[name] = f.content
+	}
+	return result, nil
+}
+
+func normalizePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,150 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// osPackage is a package installed by the operating system package manager.
+type osPackage struct {
+	Name    string
+	Version string
+	// Source is the source package the binary package was built from, if it differs from Name.
+	Source string
+	Arch   string
+}
+
+// parseApkInstalled parses the apk database found at lib/apk/db/installed.
+func parseApkInstalled(content []byte) []osPackage {
+	var pkgs []osPackage
+	for _, record := range splitRecords(content) {
+		var p osPackage
+		for _, line := range record {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "P":
+				p.Name = value
+			case "V":
+				p.Version = value
+			case "A":
+				p.Arch = value
+			case "o":
+				p.Source = value
+			}
+		}
+		if p.Source == p.Name {
+			p.Source = ""
+		}
+		if p.Name != "" {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs
+}
+
+// parseDpkgStatus parses a dpkg status file, either var/lib/dpkg/status or a single
+// file of the var/lib/dpkg/status.d directory used by distroless images.
+func parseDpkgStatus(content []byte) []osPackage {
+	var pkgs []osPackage
+	for _, record := range splitRecords(content) {
+		fields := parseControlFields(record)
+		if fields["Package"] == "" {
+			continue
+		}
+		// status.d files don't carry a status, their packages are always installed.
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+
+		source, _, _ := strings.Cut(fields["Source"], " ")
+		if source == fields["Package"] {
+			source = ""
+		}
+		pkgs = append(pkgs, osPackage{
+			Name:    fields["Package"],
+			Version: fields["Version"],
+			Source:  source,
+			Arch:    fields["Architecture"],
+		})
+	}
+	return pkgs
+}
+
+// parseControlFields parses the lines of a Debian control record. Continuation lines are dropped,
+// as none of the fields we're interested in span multiple lines.
+func parseControlFields(lines []string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range lines {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// splitRecords splits blank-line separated records into their lines.
+func splitRecords(content []byte) [][]string {
+	var (
+		records [][]string
+		current []string
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				records = append(records, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		records = append(records, current)
+	}
+	return records
+}
+
+// parseOSRelease parses an os-release file into its key/value pairs.
+func parseOSRelease(content []byte) map[string]string {
+	values := make(map[string]string)
+	for _, record := range splitRecords(content) {
+		for _, line := range record {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return values
+}
@@ -0,0 +1,87 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph
+
+import (
+	"os"
+	"sync"
+)
+
+// the environment variables the legacy CLI reads registry credentials from.
+const (
+	registryUsernameEnvVar = "SNYK_REGISTRY_USERNAME"
+	registryPasswordEnvVar = "SNYK_REGISTRY_PASSWORD"
+)
+
+// credentialsEnv passes registry credentials to the legacy CLI through the environment it inherits from
+// this process, which unlike its arguments doesn't show up in process listings or logs. Concurrent analyses
+// share the environment, which is restored once the last of them is done.
+type credentialsEnv struct {
+	mu      sync.Mutex
+	users   int
+	restore func()
+}
+
+var registryCredentials = &credentialsEnv{}
+
+// set exports the credentials until release is called. Without credentials the environment is left untouched,
+// so credentials the user exported for the legacy CLI still apply.
+func (e *credentialsEnv) set(username, password string) (release func()) {
+	if username == "" && password == "" {
+		return func() {}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.users == 0 {
+		e.restore = setEnv(map[string]string{
+			registryUsernameEnvVar: username,
+			registryPasswordEnvVar: password,
+		})
+	}
+	e.users++
+
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.users--
+		if e.users == 0 {
+			e.restore()
+		}
+	}
+}
+
+// setEnv sets the environment variables and returns a function restoring their previous values.
+func setEnv(env map[string]string) (restore func()) {
+	previous := make(map[string]*string, len(env))
+	for k, v := range env {
+		if old, ok := os.LookupEnv(k); ok {
+			previous[k] = &old
+		} else {
+			previous[k] = nil
+		}
+		_ = os.Setenv(k, v)
+	}
+
+	return func() {
+		for k, v := range previous {
+			if v == nil {
+				_ = os.Unsetenv(k)
+			} else {
+				_ = os.Setenv(k, *v)
+			}
+		}
+	}
+}
@@ -15,23 +15,32 @@
 package depgraph
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/docker/distribution/reference"
 	"github.com/rs/zerolog"
 	"github.com/snyk/container-cli/internal/common/constants"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
 	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/redact"
+	"github.com/snyk/container-cli/internal/common/registry"
 	"github.com/snyk/container-cli/internal/common/workflows"
+	"github.com/snyk/container-cli/internal/workflows/depgraph/analyzer"
+	depgrapherrors "github.com/snyk/container-cli/internal/workflows/depgraph/errors"
 	"github.com/snyk/go-application-framework/pkg/configuration"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 )
 
-const internalErrorMessage = "an error occurred while running the underlying analysis needed to generate the depgraph"
-
 type DepGraphWorkflow struct {
 	workflows.BaseWorkflow
 }
@@ -39,7 +48,7 @@ type DepGraphWorkflow struct {
 var Workflow = &DepGraphWorkflow{
 	BaseWorkflow: workflows.BaseWorkflow{
 		Name:  "container depgraph",
-		Flags: flags.CommonFlags,
+		Flags: slices.Concat(flags.CommonFlags, []flags.Flag{flags.FlagJSON}),
 	},
 }
 
@@ -47,7 +56,7 @@ func (d *DepGraphWorkflow) InitWorkflow(e workflow.Engine) error {
 	_, err := e.Register(
 		d.Identifier(),
 		d.GetConfigurationOptionsFromFlagSet(),
-		d.entrypoint,
+		workflows.JSONErrors(d.entrypoint),
 	)
 	return err
 }
@@ -58,40 +67,62 @@ func (d *DepGraphWorkflow) TypeIdentifier() workflow.Identifier {
 
 var legacyCLIID = workflow.NewWorkflowIdentifier(constants.WorkflowIdentifierLegacyCli)
 
+// errAppDependencies is returned by the native analysis of images whose application dependencies are to be
+// analysed, which only the legacy CLI does.
+var errAppDependencies = errors.New("the native analyzer does not analyse application dependencies")
+
 func (d *DepGraphWorkflow) entrypoint(ictx workflow.InvocationContext, _ []workflow.Data) ([]workflow.Data, error) {
 	logger := ictx.GetEnhancedLogger()
+	errFactory := depgrapherrors.NewDepGraphErrorFactory(logger)
 	config := ictx.GetConfiguration()
+	if _, err := flags.Resolve(config, d.Flags); err != nil {
+		return nil, errFactory.NewConfigFileError(err)
+	}
 
 	logger.Info().Msg("starting the depgraph workflow")
 
-	baseCmdArgs := []string{"container", "test", "--print-graph", "--json"}
-	cmdArgs := buildCliCommand(baseCmdArgs, d.Flags, config)
+	target := config.GetString(constants.ContainerTargetArgName)
+	ctx, cancel := deadlineContext(config)
+	defer cancel()
+	if flags.FlagNativeAnalyzer.GetFlagValue(config) {
+		data, err := d.analyzeNatively(ctx, ictx, logger, errFactory, config, target)
+		if !fallsBackToLegacyCLI(err) {
+			return data, err
+		}
+		logger.Warn().Msgf("%s, falling back to the legacy CLI for %s", err, target)
+	}
+
+	// the legacy CLI can't be bounded by the deadline, but isn't started once it has passed.
+	if err := ctx.Err(); err != nil {
+		return nil, errFactory.NewTimeoutError(target, err)
+	}
+
+	baseCmdArgs := []string{"container", "test", "--print-graph", structuredOutputArg, "--json"}
+	cmdArgs := buildCliCommand(baseCmdArgs, flags.LegacyCLIFlags, config, legacyCLITarget(target))
 
-	logger.Info().Msgf("cli invocation args: %v", cmdArgs)
+	logger.Info().Msgf("cli invocation args: %s", redact.String(fmt.Sprint(cmdArgs)))
 	config.Set(configuration.RAW_CMD_ARGS, cmdArgs)
+	release := registryCredentials.set(flags.FlagUsername.GetFlagValue(config), flags.FlagPassword.GetFlagValue(config))
 	data, err := ictx.GetEngine().InvokeWithConfig(legacyCLIID, config)
+	release()
 	if err != nil {
-		// TODO: maybe log the cli error instead of general error
-		logger.Error().Err(err).Msg("failed to execute depgraph legacy workflow")
-		return nil, extractLegacyCLIError(data, err)
+		logger.Error().Err(redact.Error(err)).Msg("failed to execute depgraph legacy workflow")
+		return nil, classifyLegacyCLIError(errFactory, config, target, data, err)
 	}
 
 	if len(data) == 0 || data[0] == nil {
-		return nil, mapInternalToUserError(
-			logger, fmt.Errorf("empty depgraph legacy workflow response payload (payload: %s)", data),
-			internalErrorMessage)
+		return nil, errFactory.NewDepGraphOutputError(target, errors.New("the legacy CLI returned no output"))
 	}
 
 	p, ok := data[0].GetPayload().([]byte)
 	if !ok {
-		return nil, mapInternalToUserError(logger, fmt.Errorf("could not convert payload, expected []byte, get %T",
-			data[0].GetPayload()), internalErrorMessage)
+		return nil, errFactory.NewDepGraphOutputError(target,
+			fmt.Errorf("invalid payload type, want []byte, got %T", data[0].GetPayload()))
 	}
 
 	depGraphList, err := extractDepGraphsFromCLIOutput(p, d.TypeIdentifier())
 	if err != nil {
-		return nil, mapInternalToUserError(logger, fmt.Errorf("could not extract depGraphs from CLI output: %w", err),
-			internalErrorMessage)
+		return nil, errFactory.NewDepGraphOutputError(target, err)
 	}
 
 	logger.Info().Msgf("finished the depgraph workflow, number of depgraphs=%d", len(depGraphList))
@@ -99,7 +130,178 @@ func (d *DepGraphWorkflow) entrypoint(ictx workflow.InvocationContext, _ []workf
 	return depGraphList, nil
 }
 
-func buildCliCommand(baseCmdArgs []string, flags []flags.Flag, config configuration.Configuration) []string {
+// deadlineContext returns the context bounding the analysis by the deadline the invoking workflow set in the
+// configuration, if any.
+func deadlineContext(config configuration.Configuration) (context.Context, context.CancelFunc) {
+	if deadline, ok := config.Get(constants.DepGraphDeadlineArgName).(time.Time); ok && !deadline.IsZero() {
+		return context.WithDeadline(context.Background(), deadline)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// analyzeNatively produces the depgraph of the image in-process, pulling images from their registry first.
+// The result has the same shape as the output of the legacy CLI, so consumers of this workflow can't tell the
+// difference. Images the analyzer can't read return analyzer.ErrUnsupportedPackageDatabase, and are left to
+// the legacy CLI, as are images without a package database the analyzer knows and images with application
+// dependencies, unless --exclude-app-vulns is set. The pull and the analysis are aborted once the context is done.
+func (d *DepGraphWorkflow) analyzeNatively(
+	ctx context.Context,
+	ictx workflow.InvocationContext,
+	logger *zerolog.Logger,
+	errFactory *depgrapherrors.DepGraphErrorFactory,
+	config configuration.Configuration,
+	target string,
+) ([]workflow.Data, error) {
+	analyzeApps := !flags.FlagExcludeAppVulns.GetFlagValue(config)
+	if analyzeApps {
+		for _, f := range []flags.Flag{flags.FlagExcludeNodeModules, flags.FlagNestedJarsDepth} {
+			if arg := f.GetAsCLIArgument(config); arg != "" {
+				return nil, fmt.Errorf("%w, but %s is set", errAppDependencies, arg)
+			}
+		}
+	}
+
+	platform := flags.FlagPlatform.GetFlagValue(config)
+
+	ref, local := image.ParseReference(target)
+	var name, version string
+	if !local {
+		dir, err := os.MkdirTemp("", "snyk-container-image-*")
+		if err != nil {
+			return nil, errFactory.NewInternalError(fmt.Errorf("could not create image directory: %w", err))
+		}
+		defer os.RemoveAll(dir)
+
+		if name, version, err = d.pull(ctx, ictx, logger, config, target, platform, dir); err != nil {
+			if ctx.Err() != nil {
+				return nil, errFactory.NewTimeoutError(target, ctx.Err())
+			}
+			return nil, pullError(errFactory, target, platform, err)
+		}
+		ref = image.Reference{Transport: image.TransportOCILayout, Path: dir}
+	}
+	logger.Info().Msgf("analyzing %s image %s with the native analyzer", ref.Transport, ref.Path)
+
+	img, err := image.Open(ref, platform)
+	if errors.Is(err, image.ErrPlatformNotFound) {
+		return nil, errFactory.NewUnsupportedPlatformError(target, platform, err)
+	}
+	if err != nil {
+		return nil, errFactory.NewArchiveUnreadableError(target, err)
+	}
+	defer img.Close()
+
+	if local {
+		name, version = img.Name, img.Tag
+		if name == "" {
+			name = filepath.Base(ref.Path)
+		}
+	}
+
+	result, err := analyzer.Analyze(ctx, img, name, version)
+	if fallsBackToLegacyCLI(err) {
+		return nil, err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, errFactory.NewTimeoutError(target, err)
+	}
+	if err != nil {
+		return nil, errFactory.NewNativeAnalyzerError(target, err)
+	}
+
+	if analyzeApps && len(result.AppManifests) > 0 {
+		return nil, fmt.Errorf("%w, but the image contains %s", errAppDependencies, result.AppManifests[0])
+	}
+
+	depGraph, err := json.Marshal(result.DepGraph)
+	if err != nil {
+		return nil, errFactory.NewInternalError(fmt.Errorf("could not marshal depgraph: %w", err))
+	}
+
+	data := workflow.NewData(d.TypeIdentifier(), constants.ContentTypeJSON, depGraph)
+	data.SetMetaData(constants.HeaderContentLocation, result.Target)
+
+	logger.Info().Msgf("finished the depgraph workflow, number of depgraphs=%d", 1)
+
+	return []workflow.Data{data}, nil
+}
+
+// fallsBackToLegacyCLI returns true if the native analysis failed because the image needs the legacy CLI.
+func fallsBackToLegacyCLI(err error) bool {
+	return errors.Is(err, analyzer.ErrUnsupportedPackageDatabase) ||
+		errors.Is(err, analyzer.ErrNoPackageDatabase) ||
+		errors.Is(err, errAppDependencies)
+}
+
+// pull downloads the image from its registry into dir as an OCI layout. It returns the name and version
+// identifying the image, which are its repository and tag or digest.
+func (d *DepGraphWorkflow) pull(
+	ctx context.Context,
+	ictx workflow.InvocationContext,
+	logger *zerolog.Logger,
+	config configuration.Configuration,
+	target, platform, dir string,
+) (name, version string, err error) {
+	named, err := reference.ParseNormalizedNamed(target)
+	if err != nil {
+		return "", "", fmt.Errorf("could not parse image reference: %w", err)
+	}
+	named = reference.TagNameOnly(named)
+	name = reference.FamiliarName(named)
+	if canonical, ok := named.(reference.Canonical); ok {
+		version = canonical.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		version = tagged.Tag()
+	}
+
+	client := registry.NewClient(registry.ClientConfig{
+		Client: ictx.GetNetworkAccess().GetUnauthorizedHttpClient(),
+		Logger: logger,
+	})
+	creds := registry.Credentials{
+		Username: flags.FlagUsername.GetFlagValue(config),
+		Password: flags.FlagPassword.GetFlagValue(config),
+	}
+	logger.Info().Msgf("pulling %s for the native analyzer", target)
+	if _, err := client.Pull(ctx, target, platform, creds, dir); err != nil {
+		return "", "", err
+	}
+	return name, version, nil
+}
+
+// pullError classifies an error pulling the image from its registry.
+func pullError(
+	errFactory *depgrapherrors.DepGraphErrorFactory,
+	target, platform string,
+	err error,
+) *containererrors.ContainerExtensionError {
+	switch {
+	case errors.Is(err, registry.ErrUnauthorized):
+		return errFactory.NewRegistryAuthError(target, err)
+	case errors.Is(err, registry.ErrNotFound):
+		return errFactory.NewImageNotFoundError(target, err)
+	case errors.Is(err, image.ErrPlatformNotFound):
+		return errFactory.NewUnsupportedPlatformError(target, platform, err)
+	default:
+		return errFactory.NewNativeAnalyzerError(target, err)
+	}
+}
+
+// legacyCLITarget returns the target as understood by the legacy CLI, which doesn't know the docker-daemon
+// transport but looks images up in the docker daemon before pulling them anyway.
+func legacyCLITarget(target string) string {
+	if ref, ok := image.ParseReference(target); ok && ref.Transport == image.TransportDockerDaemon {
+		return ref.Path
+	}
+	return target
+}
+
+func buildCliCommand(
+	baseCmdArgs []string,
+	flags []flags.Flag,
+	config configuration.Configuration,
+	target string,
+) []string {
 	var cmdArgs []string
 	cmdArgs = append(cmdArgs, baseCmdArgs...)
 
@@ -110,7 +312,7 @@ func buildCliCommand(baseCmdArgs []string, flags []flags.Flag, config configurat
 		}
 	}
 
-	cmdArgs = append(cmdArgs, config.GetString(constants.ContainerTargetArgName))
+	cmdArgs = append(cmdArgs, target)
 	return cmdArgs
 }
 
@@ -120,17 +322,26 @@ func buildCliCommand(baseCmdArgs []string, flags []flags.Flag, config configurat
 // The `(?s)` at the beginning enables multiline-matching.
 var depGraphSeparator = regexp.MustCompile(`(?s)DepGraph data:(.*?)DepGraph target:(.*?)DepGraph end`)
 
+// extractDepGraphsFromCLIOutput reads the depgraphs from the structured output requested by structuredOutputArg.
+// Output which doesn't follow the contract is searched for the text markers of older CLIs, and the contract
+// violation is returned if it has none.
 func extractDepGraphsFromCLIOutput(output []byte, typeID workflow.Identifier) ([]workflow.Data, error) {
 	if len(output) == 0 {
 		return nil, errors.New("empty output")
 	}
 
+	depGraphs, err := extractDepGraphsFromStructuredOutput(output, typeID)
+	if err == nil {
+		return depGraphs, nil
+	}
+
+	// older CLIs don't support the structured output and print the depgraphs between text markers.
 	matches := depGraphSeparator.FindAllSubmatch(output, -1)
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("malformed CLI output, got 0 matches")
+		return nil, err
 	}
 
-	depGraphs := make([]workflow.Data, 0, len(matches))
+	depGraphs = make([]workflow.Data, 0, len(matches))
 	for _, match := range matches {
 		data := workflow.NewData(typeID, constants.ContentTypeJSON, match[1])
 		data.SetMetaData(constants.HeaderContentLocation, strings.TrimSpace(string(match[2])))
@@ -139,43 +350,3 @@ func extractDepGraphsFromCLIOutput(output []byte, typeID workflow.Identifier) ([
 
 	return depGraphs, nil
 }
-
-// legacyCLIJSONError is the error type returned by the legacy cli.
-type legacyCLIJSONError struct {
-	Ok       bool   `json:"ok"`
-	ErrorMsg string `json:"error"`
-	Path     string `json:"path"`
-}
-
-// Error returns the LegacyCliJsonError error message.
-func (e *legacyCLIJSONError) Error() string {
-	return e.ErrorMsg
-}
-
-// extractLegacyCLIError extracts the error message from the legacy cli if possible.
-func extractLegacyCLIError(data []workflow.Data, err error) error {
-	// if there's no data, we can't extract anything.
-	if len(data) == 0 {
-		return err
-	}
-
-	// extract error from legacy cli if possible and wrap it in an error instance
-	var exitErr *exec.ExitError
-	if errors.As(err, &exitErr) {
-		bytes, ok := data[0].GetPayload().([]byte)
-		if !ok {
-			return fmt.Errorf("invalid payload type, want []byte, got %T", data[0].GetPayload())
-		}
-
-		var decodedError legacyCLIJSONError
-		if json.Unmarshal(bytes, &decodedError) == nil {
-			return &decodedError
-		}
-	}
-	return err
-}
-
-func mapInternalToUserError(logger *zerolog.Logger, err error, userMessage string) error {
-	logger.Err(err).Msg("failed to execute depgraph legacy workflow")
-	return errors.New(userMessage)
-}
@@ -15,25 +15,37 @@
 package depgraph
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/rs/zerolog"
 	zlog "github.com/rs/zerolog/log"
 	"github.com/snyk/container-cli/internal/common/constants"
+	containerdepgraph "github.com/snyk/container-cli/internal/common/depgraph"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
 	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/snyk/container-cli/internal/common/redact"
+	"github.com/snyk/container-cli/internal/common/registry/registrytest"
 	"github.com/snyk/go-application-framework/pkg/configuration"
 	"github.com/snyk/go-application-framework/pkg/mocks"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 	"github.com/stretchr/testify/require"
 )
 
-const testContainerTargetArg = "test_image:test_tag"
+const (
+	testContainerTargetArg     = "test_image:test_tag"
+	depGraphOutputErrorMessage = "The analysis of the image test_image:test_tag did not produce a depgraph"
+)
 
 var (
 	mockCtrl              *gomock.Controller
@@ -51,6 +63,7 @@ func beforeEach(t *testing.T) {
 	mockCtrl = gomock.NewController(t)
 
 	mockConfig = mocks.NewMockConfiguration(mockCtrl)
+	mockConfig.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(nil).AnyTimes()
 	mockInvocationContext = mocks.NewMockInvocationContext(mockCtrl)
 	mockEngine = mocks.NewMockEngine(mockCtrl)
 	mockInvocationContext.EXPECT().GetEngine().Return(mockEngine)
@@ -65,7 +78,7 @@ func afterEach() {
 	mockCtrl.Finish()
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsEmpty_ShouldReturnTheOriginalLegacyCliWorkflowError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsEmpty_ShouldReturnAnalyzerCrashErrorWithTheOriginalLegacyCliWorkflowError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
@@ -78,10 +91,12 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsEmpty_Sh
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, expectedErrorMessage)
+	require.ErrorIs(t, err, legacyCliError)
+	requireErrorCode(t, containererrors.CodeAnalyzerCrash, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmptyAndErrorIsNotOfTypeExitError_ShouldReturnTheOriginalLegacyCliWorkflowError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmptyAndErrorIsNotOfTypeExitError_ShouldReturnAnalyzerCrashErrorWithTheOriginalLegacyCliWorkflowError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
@@ -96,10 +111,12 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmpty
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, expectedErrorMessage)
+	require.ErrorIs(t, err, legacyCliNonExitError)
+	requireErrorCode(t, containererrors.CodeAnalyzerCrash, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmptyAndErrorIsOfTypeExitErrorAndPayloadCouldNotConvertFromByteArray_ShouldReturnNilError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmptyAndErrorIsOfTypeExitErrorAndPayloadCouldNotConvertFromByteArray_ShouldReturnAnalyzerCrashError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
@@ -118,10 +135,11 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmpty
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, expectedErrorMessage)
+	requireErrorCode(t, containererrors.CodeAnalyzerCrash, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmptyAndErrorIsOfTypeExitErrorAndPayloadIsLegacyCliJsonError_ShouldReturnLegacyCliJsonError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmptyAndErrorIsOfTypeExitErrorAndPayloadIsLegacyCliJsonError_ShouldReturnAnalyzerCrashErrorWithLegacyCliJsonError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
@@ -142,46 +160,48 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnsAnError_AndDataArrayIsNotEmpty
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.Equal(t, err, &expectedError)
+	var legacyErr *legacyCLIJSONError
+	require.True(t, errors.As(err, &legacyErr))
+	require.Equal(t, &expectedError, legacyErr)
+	requireErrorCode(t, containererrors.CodeAnalyzerCrash, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsEmpty_ShouldReturnInternalError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsEmpty_ShouldReturnDepGraphOutputError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
 	legacyCliData := []workflow.Data{}
-	expectedErrorMessage := internalErrorMessage
 
 	initMocks()
 	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return(legacyCliData, nil)
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, depGraphOutputErrorMessage)
+	requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNil_ShouldReturnInternalError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNil_ShouldReturnDepGraphOutputError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
 	legacyCliData := []workflow.Data{nil}
-	expectedErrorMessage := internalErrorMessage
 
 	initMocks()
 	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return(legacyCliData, nil)
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, depGraphOutputErrorMessage)
+	requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadFailedToConvertToByteArray_ShouldReturnInternalError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadFailedToConvertToByteArray_ShouldReturnDepGraphOutputError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
 	legacyCliData := []workflow.Data{mockData}
 	var payload interface{} = nil
-	expectedErrorMessage := internalErrorMessage
 
 	initMocks()
 	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return(legacyCliData, nil)
@@ -189,16 +209,16 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNi
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, depGraphOutputErrorMessage)
+	requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadConvertToByteArrayAndTheByteArrayIsEmpty_ShouldReturnInternalError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadConvertToByteArrayAndTheByteArrayIsEmpty_ShouldReturnDepGraphOutputError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
 	legacyCliData := []workflow.Data{mockData}
 	payload := []byte("")
-	expectedErrorMessage := internalErrorMessage
 
 	initMocks()
 	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return(legacyCliData, nil)
@@ -206,16 +226,16 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNi
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, depGraphOutputErrorMessage)
+	requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
 }
 
-func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadConvertToByteArrayAndTheByteArrayIsNotEmptyAndUnableToMatchDepGraphData_ShouldReturnInternalError(t *testing.T) {
+func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadConvertToByteArrayAndTheByteArrayIsNotEmptyAndUnableToMatchDepGraphData_ShouldReturnDepGraphOutputError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
 	legacyCliData := []workflow.Data{mockData}
 	payload := []byte("no depgraph data")
-	expectedErrorMessage := internalErrorMessage
 
 	initMocks()
 	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return(legacyCliData, nil)
@@ -223,7 +243,8 @@ func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNi
 
 	_, err := unit.entrypoint(mockInvocationContext, nil)
 
-	require.EqualError(t, err, expectedErrorMessage)
+	require.ErrorContains(t, err, depGraphOutputErrorMessage)
+	requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
 }
 
 func Test_Entrypoint_GivenLegacyCliWorkflowReturnDataArray_AndDataIsNotEmptyOrNilAndPayloadConvertToByteArrayAndTheByteArrayIsNotEmptyAndMatchMultipleDepGraphData_ShouldReturnWorkflowDataWithMultipleDepGraphsAndDepGraphsMetadata(t *testing.T) {
@@ -270,7 +291,10 @@ func Test_InitWorkflow_GivenFlags_ShouldRegisterFlagsToWorkflowAndReturnThemInCo
 	err := Workflow.InitWorkflow(engine)
 	require.Nil(t, err)
 
-	require.Len(t, Workflow.Flags, 6)
+	require.Len(t, Workflow.Flags, 8)
+
+	flagNativeAnalyzer := config.Get(flags.FlagNativeAnalyzer.Name)
+	require.NotNil(t, flagNativeAnalyzer)
 
 	flagExcludeAppVulns := config.Get(flags.FlagExcludeAppVulns.Name)
 	require.NotNil(t, flagExcludeAppVulns)
@@ -289,26 +313,49 @@ func Test_InitWorkflow_GivenFlags_ShouldRegisterFlagsToWorkflowAndReturnThemInCo
 
 	flagPlatform := config.Get(flags.FlagPlatform.Name)
 	require.NotNil(t, flagPlatform)
+
+	flagJSON := config.Get(flags.FlagJSON.Name)
+	require.NotNil(t, flagJSON)
+}
+
+func Test_InitWorkflow_GivenJSONFlag_ShouldRenderErrorsAsJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(flags.FlagPlatform.GetEnvVar(), "bogus")
+	config := configuration.New()
+	engine := workflow.NewWorkFlowEngine(config)
+	require.NoError(t, Workflow.InitWorkflow(engine))
+	entry, ok := engine.GetWorkflow(Workflow.Identifier())
+	require.True(t, ok)
+
+	config.Set(flags.FlagJSON.Name, true)
+	ictx := mocks.NewMockInvocationContext(gomock.NewController(t))
+	ictx.EXPECT().GetConfiguration().Return(config).AnyTimes()
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger).AnyTimes()
+
+	_, err := entry.GetEntryPoint()(ictx, nil)
+
+	var jsonErr *containererrors.JSONError
+	require.ErrorAs(t, err, &jsonErr)
+	require.Contains(t, err.Error(), `"code":"SNYK-CONTAINER-0005"`)
 }
 
-func Test_Entrypoint_GivenFlagsAreSet_ShouldPassFlagsToLegacyCli(t *testing.T) {
+func Test_Entrypoint_GivenFlagsAreSet_ShouldPassFlagsAndCredentialsToLegacyCli(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
 
+	mockConfig.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(false)
 	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("linux/amd64")
 	mockConfig.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(true)
 	mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("myuser")
 	mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("mypass")
 	mockConfig.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(true)
-	mockConfig.EXPECT().GetString(flags.FlagNestedJarsDepth.Name).Return("3")
+	mockConfig.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(3)
 	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return(testContainerTargetArg)
 
 	expectedArgs := []string{
-		"container", "test", "--print-graph", "--json",
+		"container", "test", "--print-graph", structuredOutputArg, "--json",
 		"--exclude-app-vulns",
 		"--platform=linux/amd64",
-		"--username=myuser",
-		"--password=mypass",
 		"--exclude-node-modules",
 		"--nested-jars-depth=3",
 		testContainerTargetArg,
@@ -319,20 +366,370 @@ func Test_Entrypoint_GivenFlagsAreSet_ShouldPassFlagsToLegacyCli(t *testing.T) {
 	mockInvocationContext.EXPECT().GetConfiguration().Return(mockConfig)
 	mockInvocationContext.EXPECT().GetEnhancedLogger().Return(logger)
 
-	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return([]workflow.Data{}, nil)
+	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).DoAndReturn(
+		func(workflow.Identifier, configuration.Configuration) ([]workflow.Data, error) {
+			require.Equal(t, "myuser", os.Getenv(registryUsernameEnvVar))
+			require.Equal(t, "mypass", os.Getenv(registryPasswordEnvVar))
+			return []workflow.Data{}, nil
+		})
 
 	// We ignore the return values because this test focuses exclusively on verifying the CLI command
 	// construction via the mock expectations above.
 	_, _ = unit.entrypoint(mockInvocationContext, nil)
+
+	_, ok := os.LookupEnv(registryPasswordEnvVar)
+	require.False(t, ok, "the credentials must be removed from the environment")
+}
+
+func Test_Entrypoint_GivenLegacyCliErrorContainingPassword_ShouldRedactIt(t *testing.T) {
+	beforeEach(t)
+	defer afterEach()
+
+	mockConfig.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(false)
+	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	mockConfig.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("myuser")
+	mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("s3cr3t-pass")
+	mockConfig.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	mockConfig.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return(testContainerTargetArg)
+	mockConfig.EXPECT().Set(configuration.RAW_CMD_ARGS, gomock.AssignableToTypeOf([]string{}))
+
+	mockInvocationContext.EXPECT().GetConfiguration().Return(mockConfig)
+	mockInvocationContext.EXPECT().GetEnhancedLogger().Return(logger)
+
+	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).
+		Return(nil, errors.New("authentication with myuser:s3cr3t-pass failed: unauthorized"))
+
+	_, err := unit.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "authentication with myuser:"+redact.Mask+" failed")
+	require.NotContains(t, err.Error(), "s3cr3t-pass")
+	requireErrorCode(t, containererrors.CodeRegistryAuth, err)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndLocalImage_ShouldReturnDepGraphWithoutInvokingLegacyCli(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	path := filepath.Join(t.TempDir(), "nginx.tar")
+	imagetest.WriteDockerArchive(t, path, []string{"nginx:1.25"}, imagetest.Layer{
+		"etc/os-release":       "ID=alpine\nVERSION_ID=3.18.4\n",
+		"lib/apk/db/installed": "P:musl\nV:1.2.4-r2\n",
+	})
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(nil)
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return("docker-archive:" + path)
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	config.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	config.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	config.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	config.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+
+	result, err := Workflow.entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, constants.ContentTypeJSON, result[0].GetContentType())
+	require.Equal(t, "docker-image|nginx:1.25", result[0].GetContentLocation())
+
+	var depGraph containerdepgraph.DepGraph
+	require.NoError(t, json.Unmarshal(result[0].GetPayload().([]byte), &depGraph))
+	require.Equal(t, "apk", depGraph.PkgManager.Name)
+	require.Equal(t, "docker-image|nginx@1.25", depGraph.Pkgs[0].ID)
+	require.Equal(t, "musl@1.2.4-r2", depGraph.Pkgs[1].ID)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndUnreadableArchive_ShouldReturnArchiveUnreadableError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(nil)
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return("oci-archive:/does/not/exist.tar")
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	config.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	config.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	config.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	config.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+
+	_, err := Workflow.entrypoint(ictx, nil)
+	require.ErrorContains(t, err, "The image archive oci-archive:/does/not/exist.tar could not be read")
+	requireErrorCode(t, containererrors.CodeArchiveUnreadable, err)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndRegistryImage_ShouldPullAndAnalyzeImage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := registrytest.New(t, registrytest.Options{Username: "user", Password: "secret"})
+	layout, manifestDigest := imagetest.OCILayout(t, "", imagetest.Layer{
+		"etc/os-release":      "ID=debian\nVERSION_ID=12\n",
+		"var/lib/dpkg/status": "Package: bash\nStatus: install ok installed\nVersion: 5.2.15-2\n",
+	})
+	r.PutImage("library/app", "1.0", layout, manifestDigest)
+	target := r.Host() + "/library/app:1.0"
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(nil)
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return(target)
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	config.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	config.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	config.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	config.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	config.EXPECT().GetString(flags.FlagUsername.Name).Return("user")
+	config.EXPECT().GetString(flags.FlagPassword.Name).Return("secret")
+
+	networkAccess := mocks.NewMockNetworkAccess(ctrl)
+	networkAccess.EXPECT().GetUnauthorizedHttpClient().Return(r.Client())
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetNetworkAccess().Return(networkAccess)
+
+	result, err := Workflow.entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, "docker-image|"+r.Host()+"/library/app:1.0", result[0].GetContentLocation())
+
+	var depGraph containerdepgraph.DepGraph
+	require.NoError(t, json.Unmarshal(result[0].GetPayload().([]byte), &depGraph))
+	require.Equal(t, "deb", depGraph.PkgManager.Name)
+	require.Equal(t, "bash@5.2.15-2", depGraph.Pkgs[1].ID)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndMissingRegistryImage_ShouldReturnImageNotFoundError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := registrytest.New(t, registrytest.Options{})
+	target := r.Host() + "/library/app:1.0"
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(nil)
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return(target)
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	config.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	config.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	config.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	config.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	config.EXPECT().GetString(flags.FlagUsername.Name).Return("")
+	config.EXPECT().GetString(flags.FlagPassword.Name).Return("")
+
+	networkAccess := mocks.NewMockNetworkAccess(ctrl)
+	networkAccess.EXPECT().GetUnauthorizedHttpClient().Return(r.Client())
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetNetworkAccess().Return(networkAccess)
+
+	_, err := Workflow.entrypoint(ictx, nil)
+	require.ErrorContains(t, err, "The image "+target+" could not be found")
+	requireErrorCode(t, containererrors.CodeImageNotFound, err)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndDeadlinePassed_ShouldReturnTimeoutError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := registrytest.New(t, registrytest.Options{})
+	layout, manifestDigest := imagetest.OCILayout(t, "", imagetest.Layer{"etc/os-release": "ID=debian\n"})
+	r.PutImage("library/app", "1.0", layout, manifestDigest)
+	target := r.Host() + "/library/app:1.0"
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(time.Now().Add(-time.Second))
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return(target)
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	config.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	config.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	config.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	config.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	config.EXPECT().GetString(flags.FlagUsername.Name).Return("")
+	config.EXPECT().GetString(flags.FlagPassword.Name).Return("")
+
+	networkAccess := mocks.NewMockNetworkAccess(ctrl)
+	networkAccess.EXPECT().GetUnauthorizedHttpClient().Return(r.Client())
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetNetworkAccess().Return(networkAccess)
+
+	_, err := Workflow.entrypoint(ictx, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	requireErrorCode(t, containererrors.CodeAnalysisTimeout, err)
+}
+
+func Test_Entrypoint_GivenDeadlinePassed_ShouldNotInvokeLegacyCli(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(time.Now().Add(-time.Second))
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return(testContainerTargetArg)
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(false)
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+
+	_, err := Workflow.entrypoint(ictx, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	requireErrorCode(t, containererrors.CodeAnalysisTimeout, err)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndRpmImage_ShouldFallBackToLegacyCli(t *testing.T) {
+	beforeEach(t)
+	defer afterEach()
+
+	path := filepath.Join(t.TempDir(), "ubi.tar")
+	imagetest.WriteDockerArchive(t, path, []string{"ubi:9"}, imagetest.Layer{"var/lib/rpm/rpmdb.sqlite": "sqlite"})
+	target := "docker-archive:" + path
+
+	mockConfig.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("").Times(2)
+	mockConfig.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false).Times(2)
+	mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("")
+	mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("")
+	mockConfig.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false).Times(2)
+	mockConfig.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil).Times(2)
+	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return(target)
+	mockConfig.EXPECT().Set(configuration.RAW_CMD_ARGS,
+		[]string{"container", "test", "--print-graph", structuredOutputArg, "--json", target})
+
+	mockInvocationContext.EXPECT().GetConfiguration().Return(mockConfig)
+	mockInvocationContext.EXPECT().GetEnhancedLogger().Return(logger)
+
+	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return([]workflow.Data{}, nil)
+
+	_, err := unit.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "did not produce a depgraph")
+	requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndImageNeedingLegacyCli_ShouldFallBackToLegacyCli(t *testing.T) {
+	apk := imagetest.Layer{"etc/os-release": "ID=alpine\n", "lib/apk/db/installed": "P:musl\nV:1.2.4-r2\n"}
+	withApp := imagetest.Layer{"app/package-lock.json": "{}"}
+	for name, p := range apk {
+		withApp[name] = p
+	}
+
+	type test struct {
+		layer           imagetest.Layer
+		nestedJarsDepth any
+		expectedArgs    []string
+	}
+
+	tests := map[string]test{
+		"no package database": {layer: imagetest.Layer{"etc/os-release": "ID=scratch\n"}},
+		"app manifest":        {layer: withApp},
+		"nested jars depth":   {layer: apk, nestedJarsDepth: 2, expectedArgs: []string{"--nested-jars-depth=2"}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			beforeEach(t)
+			defer afterEach()
+
+			path := filepath.Join(t.TempDir(), "image.tar")
+			imagetest.WriteDockerArchive(t, path, []string{"image:1"}, tc.layer)
+			target := "docker-archive:" + path
+
+			mockConfig.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+			mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("").MinTimes(1)
+			mockConfig.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false).Times(2)
+			mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("")
+			mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("")
+			mockConfig.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false).Times(2)
+			mockConfig.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(tc.nestedJarsDepth).Times(2)
+			mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return(target)
+			args := slices.Concat(
+				[]string{"container", "test", "--print-graph", structuredOutputArg, "--json"}, tc.expectedArgs)
+			mockConfig.EXPECT().Set(configuration.RAW_CMD_ARGS, append(args, target))
+
+			mockInvocationContext.EXPECT().GetConfiguration().Return(mockConfig)
+			mockInvocationContext.EXPECT().GetEnhancedLogger().Return(logger)
+
+			mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return([]workflow.Data{}, nil)
+
+			_, err := unit.entrypoint(mockInvocationContext, nil)
+			requireErrorCode(t, containererrors.CodeDepGraphOutput, err)
+		})
+	}
+}
+
+func Test_Entrypoint_GivenNativeAnalyzerAndAppVulnsExcluded_ShouldIgnoreAppManifests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	path := filepath.Join(t.TempDir(), "app.tar")
+	imagetest.WriteDockerArchive(t, path, []string{"app:1"}, imagetest.Layer{
+		"etc/os-release":        "ID=alpine\n",
+		"lib/apk/db/installed":  "P:musl\nV:1.2.4-r2\n",
+		"app/package-lock.json": "{}",
+	})
+
+	config := mocks.NewMockConfiguration(ctrl)
+	config.EXPECT().Get(constants.DepGraphDeadlineArgName).Return(nil)
+	config.EXPECT().GetString(constants.ContainerTargetArgName).Return("docker-archive:" + path)
+	config.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(true)
+	config.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(true)
+	config.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+
+	result, err := Workflow.entrypoint(ictx, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Equal(t, "docker-image|app:1", result[0].GetContentLocation())
+}
+
+func Test_Entrypoint_GivenDockerDaemonImage_ShouldPassImageNameToLegacyCli(t *testing.T) {
+	beforeEach(t)
+	defer afterEach()
+
+	mockConfig.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(false)
+	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	mockConfig.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
+	mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("")
+	mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("")
+	mockConfig.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
+	mockConfig.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
+	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return("docker-daemon:" + testContainerTargetArg)
+	mockConfig.EXPECT().Set(configuration.RAW_CMD_ARGS,
+		[]string{"container", "test", "--print-graph", structuredOutputArg, "--json", testContainerTargetArg})
+
+	mockInvocationContext.EXPECT().GetConfiguration().Return(mockConfig)
+	mockInvocationContext.EXPECT().GetEnhancedLogger().Return(logger)
+
+	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), mockConfig).Return([]workflow.Data{}, nil)
+
+	_, _ = unit.entrypoint(mockInvocationContext, nil)
 }
 
 func initMocks() {
+	mockConfig.EXPECT().GetBool(flags.FlagNativeAnalyzer.Name).Return(false)
 	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
 	mockConfig.EXPECT().GetBool(flags.FlagExcludeAppVulns.Name).Return(false)
 	mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("")
 	mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("")
 	mockConfig.EXPECT().GetBool(flags.FlagExcludeNodeModules.Name).Return(false)
-	mockConfig.EXPECT().GetString(flags.FlagNestedJarsDepth.Name).Return("")
+	mockConfig.EXPECT().Get(flags.FlagNestedJarsDepth.Name).Return(nil)
 	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return(testContainerTargetArg)
 	mockConfig.EXPECT().Set(configuration.RAW_CMD_ARGS, gomock.AssignableToTypeOf([]string{}))
 
@@ -340,6 +737,14 @@ func initMocks() {
 	mockInvocationContext.EXPECT().GetEnhancedLogger().Return(logger)
 }
 
+func requireErrorCode(t *testing.T, expected containererrors.Code, err error) {
+	t.Helper()
+
+	var xerr *containererrors.ContainerExtensionError
+	require.True(t, errors.As(err, &xerr), "expected a container extension error, got %T", err)
+	require.Equal(t, expected, xerr.Code())
+}
+
 func buildData(identifier workflow.Identifier, payload any, target string) workflow.Data {
 	d := workflow.NewData(identifier, constants.ContentTypeJSON, payload)
 	d.SetMetaData(constants.HeaderContentLocation, target)
@@ -0,0 +1,155 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+)
+
+type DepGraphErrorFactory struct {
+	*containererrors.ErrorFactory
+}
+
+func NewDepGraphErrorFactory(logger *zerolog.Logger) *DepGraphErrorFactory {
+	return &DepGraphErrorFactory{
+		ErrorFactory: containererrors.NewErrorFactory(logger),
+	}
+}
+
+func (ef *DepGraphErrorFactory) NewImageNotFoundError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeImageNotFound,
+		fmt.Errorf("image %s not found: %w", target, err),
+		fmt.Sprintf(
+			"The image %s could not be found: %s. "+
+				"Please check the image name and tag, and that you are logged in if the registry is private.",
+			target, err,
+		),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewRegistryAuthError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeRegistryAuth,
+		fmt.Errorf("registry denied access to %s: %w", target, err),
+		fmt.Sprintf(
+			"The registry denied access to the image %s: %s. "+
+				"Please check the credentials passed with --username and --password.",
+			target, err,
+		),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewUnsupportedPlatformError(
+	target, platform string, err error,
+) *containererrors.ContainerExtensionError {
+	userMsg := fmt.Sprintf(
+		"The image %s is not available for the default platform: %s. "+
+			"Please select one of the platforms of the image with --platform.",
+		target, err,
+	)
+	if platform != "" {
+		userMsg = fmt.Sprintf(
+			"The image %s is not available for the platform %s: %s. "+
+				"Please select one of the platforms of the image with --platform.",
+			target, platform, err,
+		)
+	}
+	return ef.NewError(
+		containererrors.CodeUnsupportedPlatform,
+		fmt.Errorf("image %s does not support platform %q: %w", target, platform, err),
+		userMsg,
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewArchiveUnreadableError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeArchiveUnreadable,
+		fmt.Errorf("could not read image archive %s: %w", target, err),
+		fmt.Sprintf(
+			"The image archive %s could not be read: %s. "+
+				"Please make sure the file exists and is a docker or OCI archive.",
+			target, err,
+		),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewTimeoutError(target string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeAnalysisTimeout,
+		fmt.Errorf("analysis of %s timed out: %w", target, err),
+		fmt.Sprintf(
+			"The analysis of the image %s timed out: %s. "+
+				"Please check the connection to the registry and try again.",
+			target, err,
+		),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewAnalyzerCrashError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeAnalyzerCrash,
+		fmt.Errorf("analysis of %s failed: %w", target, err),
+		fmt.Sprintf("The analysis of the image %s failed: %s.", target, err),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewNativeAnalyzerError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeNativeAnalyzer,
+		fmt.Errorf("native analysis of %s failed: %w", target, err),
+		fmt.Sprintf(
+			"The native analyzer could not analyze the image %s: %s. "+
+				"Please try again without --native-analyzer.",
+			target, err,
+		),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewDepGraphOutputError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeDepGraphOutput,
+		fmt.Errorf("could not extract depgraphs of %s from CLI output: %w", target, err),
+		fmt.Sprintf(
+			"The analysis of the image %s did not produce a depgraph: %s. "+
+				"Please make sure you are using the latest version of the Snyk CLI.",
+			target, err,
+		),
+	)
+}
+
+func (ef *DepGraphErrorFactory) NewInternalError(err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInternal,
+		err,
+		"An error occurred while generating the depgraph. "+
+			"Should this issue persist, please reach out to customer support.",
+	)
+}
@@ -0,0 +1,123 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image"
+	depgrapherrors "github.com/snyk/container-cli/internal/workflows/depgraph/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+// legacyCLIJSONError is the error type returned by the legacy cli.
+type legacyCLIJSONError struct {
+	Ok       bool   `json:"ok"`
+	ErrorMsg string `json:"error"`
+	Path     string `json:"path"`
+}
+
+// Error returns the LegacyCliJsonError error message.
+func (e *legacyCLIJSONError) Error() string {
+	return e.ErrorMsg
+}
+
+// extractLegacyCLIError extracts the error message from the legacy cli if possible.
+func extractLegacyCLIError(data []workflow.Data, err error) error {
+	// if there's no data, we can't extract anything.
+	if len(data) == 0 {
+		return err
+	}
+
+	// extract error from legacy cli if possible and wrap it in an error instance
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		bytes, ok := data[0].GetPayload().([]byte)
+		if !ok {
+			return fmt.Errorf("invalid payload type, want []byte, got %T", data[0].GetPayload())
+		}
+
+		var decodedError legacyCLIJSONError
+		if json.Unmarshal(bytes, &decodedError) == nil {
+			return &decodedError
+		}
+	}
+	return err
+}
+
+// The legacy CLI reports its errors as free text only, so the kind of error is derived from the phrases
+// the CLI and the registries use. The patterns match whole words of the lowercased message, which mentions
+// flags like --platform and targets like docker-archive:app.tar, so single words are not telling.
+var (
+	timeoutPattern  = regexp.MustCompile(`\b(timed out|timeout|etimedout|deadline exceeded)\b`)
+	platformPattern = regexp.MustCompile(
+		`\bno matching manifest\b|\b(does not|doesn't) (match|exist for) the (specified|requested|current) platform\b`,
+	)
+	archivePattern = regexp.MustCompile(
+		`\bno such file\b|\benoent\b|\b(invalid|corrupt|truncated) (tar|archive)\b|` +
+			`\bnot an? (valid )?(tar|archive)\b|\bunrecognized archive format\b|\bunexpected (end of file|eof)\b`,
+	)
+	notFoundPattern = regexp.MustCompile(
+		`\b(image|manifest|repository|tag) (was )?not found\b|\bdoes not exist\b|\bmanifest unknown\b|` +
+			`\bname unknown\b|\bno such image\b`,
+	)
+	authPattern = regexp.MustCompile(
+		`\bunauthorized\b|\bauthentication (required|failed)\b|\bincorrect username or password\b|` +
+			`\baccess denied\b|\bdenied:|\bstatus code 40[13]\b`,
+	)
+)
+
+// classifyLegacyCLIError turns the failure of the legacy CLI into an error telling the user what went wrong
+// with the analysis of target.
+func classifyLegacyCLIError(
+	errFactory *depgrapherrors.DepGraphErrorFactory,
+	config configuration.Configuration,
+	target string,
+	data []workflow.Data,
+	err error,
+) error {
+	legacyErr := extractLegacyCLIError(data, err)
+	msg := strings.ToLower(legacyErr.Error())
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || timeoutPattern.MatchString(msg):
+		return errFactory.NewTimeoutError(target, legacyErr)
+	case platformPattern.MatchString(msg):
+		return errFactory.NewUnsupportedPlatformError(target, flags.FlagPlatform.GetFlagValue(config), legacyErr)
+	case isArchive(target) && archivePattern.MatchString(msg):
+		return errFactory.NewArchiveUnreadableError(target, legacyErr)
+	// registries answer "pull access denied" for images which don't exist, so this is checked before auth.
+	case notFoundPattern.MatchString(msg):
+		return errFactory.NewImageNotFoundError(target, legacyErr)
+	case authPattern.MatchString(msg):
+		return errFactory.NewRegistryAuthError(target, legacyErr)
+	default:
+		return errFactory.NewAnalyzerCrashError(target, legacyErr)
+	}
+}
+
+// isArchive returns true if the target is an image archive or layout on disk.
+func isArchive(target string) bool {
+	ref, ok := image.ParseReference(target)
+	return ok && ref.Transport != image.TransportDockerDaemon
+}
@@ -0,0 +1,139 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	zlog "github.com/rs/zerolog/log"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+	"github.com/snyk/container-cli/internal/common/flags"
+	depgrapherrors "github.com/snyk/container-cli/internal/workflows/depgraph/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClassifyLegacyCLIError_GivenLegacyCliError_ShouldReturnMatchingError(t *testing.T) {
+	tests := map[string]struct {
+		target          string
+		err             string
+		expectedCode    containererrors.Code
+		expectedMessage string
+	}{
+		"manifest unknown": {
+			target:          "alpine:nope",
+			err:             "manifest unknown: manifest unknown",
+			expectedCode:    containererrors.CodeImageNotFound,
+			expectedMessage: "The image alpine:nope could not be found",
+		},
+		"pull access denied": {
+			target:          "acme/private:1",
+			err:             "pull access denied for acme/private, repository does not exist or may require 'docker login'",
+			expectedCode:    containererrors.CodeImageNotFound,
+			expectedMessage: "The image acme/private:1 could not be found",
+		},
+		"registry auth": {
+			target:          "registry.acme.com/app:1",
+			err:             "unauthorized: authentication required",
+			expectedCode:    containererrors.CodeRegistryAuth,
+			expectedMessage: "The registry denied access to the image registry.acme.com/app:1",
+		},
+		"unsupported platform": {
+			target:          "alpine:3.17",
+			err:             "no matching manifest for linux/s390x in the manifest list entries",
+			expectedCode:    containererrors.CodeUnsupportedPlatform,
+			expectedMessage: "The image alpine:3.17 is not available for the platform linux/s390x",
+		},
+		"unreadable archive": {
+			target:          "docker-archive:/tmp/missing.tar",
+			err:             "ENOENT: no such file or directory, open '/tmp/missing.tar'",
+			expectedCode:    containererrors.CodeArchiveUnreadable,
+			expectedMessage: "The image archive docker-archive:/tmp/missing.tar could not be read",
+		},
+		"corrupt archive": {
+			target:          "oci-archive:/tmp/app.tar",
+			err:             "archive/tar: invalid tar header",
+			expectedCode:    containererrors.CodeArchiveUnreadable,
+			expectedMessage: "The image archive oci-archive:/tmp/app.tar could not be read",
+		},
+		"archive target in message": {
+			target:          "docker-archive:/tmp/app.tar",
+			err:             "failed to start the analysis of docker-archive:/tmp/app.tar",
+			expectedCode:    containererrors.CodeAnalyzerCrash,
+			expectedMessage: "The analysis of the image docker-archive:/tmp/app.tar failed",
+		},
+		"image not found in archive": {
+			target:          "docker-archive:/tmp/app.tar",
+			err:             "image not found in the archive",
+			expectedCode:    containererrors.CodeImageNotFound,
+			expectedMessage: "The image docker-archive:/tmp/app.tar could not be found",
+		},
+		"platform flag in message": {
+			target:          "alpine:3.17",
+			err:             "the --platform option could not be applied to the target started",
+			expectedCode:    containererrors.CodeAnalyzerCrash,
+			expectedMessage: "The analysis of the image alpine:3.17 failed",
+		},
+		"timeout": {
+			target:          "alpine:3.17",
+			err:             "connect ETIMEDOUT 104.18.121.25:443",
+			expectedCode:    containererrors.CodeAnalysisTimeout,
+			expectedMessage: "The analysis of the image alpine:3.17 timed out",
+		},
+		"analyzer crash": {
+			target:          "alpine:3.17",
+			err:             "Cannot read properties of undefined (reading 'layers')",
+			expectedCode:    containererrors.CodeAnalyzerCrash,
+			expectedMessage: "The analysis of the image alpine:3.17 failed: Cannot read properties of undefined",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.NewInMemory()
+			config.Set(flags.FlagPlatform.Name, "linux/s390x")
+			data := []workflow.Data{
+				buildData(Workflow.TypeIdentifier(), []byte(fmt.Sprintf(`{"ok":false,"error":%q}`, tc.err)), ""),
+			}
+			exitErr := &exec.ExitError{ProcessState: &os.ProcessState{}}
+
+			err := classifyLegacyCLIError(
+				depgrapherrors.NewDepGraphErrorFactory(&zlog.Logger), config, tc.target, data, exitErr,
+			)
+
+			require.ErrorContains(t, err, tc.expectedMessage)
+			requireErrorCode(t, tc.expectedCode, err)
+		})
+	}
+}
+
+func Test_ClassifyLegacyCLIError_GivenDeadlineExceeded_ShouldReturnTimeoutError(t *testing.T) {
+	err := classifyLegacyCLIError(
+		depgrapherrors.NewDepGraphErrorFactory(&zlog.Logger),
+		configuration.NewInMemory(),
+		"alpine:3.17",
+		nil,
+		fmt.Errorf("legacy cli: %w", context.DeadlineExceeded),
+	)
+
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	requireErrorCode(t, containererrors.CodeAnalysisTimeout, err)
+}
@@ -0,0 +1,136 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+	containerdepgraph "github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+const (
+	// structuredOutputVersion is the version of the structured depgraph output contract this workflow understands.
+	structuredOutputVersion = 1
+	// structuredOutputArg asks the legacy CLI for the structured depgraph output. Older CLIs ignore options they
+	// don't know and keep printing the depgraphs between text markers.
+	structuredOutputArg = "--print-graph-format=json-lines"
+)
+
+// structuredOutputRecord is a single record of the structured depgraph output. The output is a stream of
+// JSON objects (typically one per line), each holding one depgraph:
+//
+//	{"version":1,"target":"docker-image|alpine:3.17","depGraph":{"schemaVersion":"1.2.0",...}}
+type structuredOutputRecord struct {
+	Version  int             `json:"version"`
+	Target   string          `json:"target"`
+	DepGraph json.RawMessage `json:"depGraph"`
+}
+
+// outputContractError reports a violation of the structured output contract.
+type outputContractError struct {
+	// Offset is the byte offset in the CLI output at which the violation was detected.
+	Offset int64
+	Err    error
+}
+
+func (e *outputContractError) Error() string {
+	return fmt.Sprintf("invalid depgraph output at byte offset %d: %s", e.Offset, e.Err)
+}
+
+func (e *outputContractError) Unwrap() error {
+	return e.Err
+}
+
+func extractDepGraphsFromStructuredOutput(output []byte, typeID workflow.Identifier) ([]workflow.Data, error) {
+	dec := json.NewDecoder(bytes.NewReader(output))
+	dec.DisallowUnknownFields()
+
+	var depGraphs []workflow.Data
+	for {
+		offset := dec.InputOffset()
+
+		var record structuredOutputRecord
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &outputContractError{Offset: decodeErrorOffset(err, offset), Err: err}
+		}
+
+		if err := validateRecord(&record); err != nil {
+			return nil, &outputContractError{Offset: skipWhitespace(output, offset), Err: err}
+		}
+
+		data := workflow.NewData(typeID, constants.ContentTypeJSON, []byte(record.DepGraph))
+		data.SetMetaData(constants.HeaderContentLocation, record.Target)
+		depGraphs = append(depGraphs, data)
+	}
+
+	if len(depGraphs) == 0 {
+		return nil, &outputContractError{Offset: 0, Err: errors.New("no depgraph records found")}
+	}
+	return depGraphs, nil
+}
+
+func validateRecord(record *structuredOutputRecord) error {
+	if record.Version != structuredOutputVersion {
+		return fmt.Errorf("unsupported output version %d, expected %d", record.Version, structuredOutputVersion)
+	}
+	if record.Target == "" {
+		return errors.New(`"target" must not be empty`)
+	}
+	if len(record.DepGraph) == 0 {
+		return errors.New(`"depGraph" is required`)
+	}
+
+	var g containerdepgraph.DepGraph
+	if err := json.Unmarshal(record.DepGraph, &g); err != nil {
+		return fmt.Errorf(`"depGraph" is not a valid depgraph: %w`, err)
+	}
+	if err := g.Validate(); err != nil {
+		return fmt.Errorf(`"depGraph" is not a valid depgraph: %w`, err)
+	}
+	return nil
+}
+
+// decodeErrorOffset returns the absolute offset of a decoding error if the decoder reports one.
+func decodeErrorOffset(err error, recordOffset int64) int64 {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// the decoder reports the offset after the offending byte.
+		return max(syntaxErr.Offset-1, 0)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Offset
+	}
+	return recordOffset
+}
+
+// skipWhitespace returns the offset of the first non-whitespace byte at or after offset, which is where
+// the record that failed validation starts.
+func skipWhitespace(output []byte, offset int64) int64 {
+	for offset < int64(len(output)) && bytes.ContainsRune([]byte(" \t\r\n"), rune(output[offset])) {
+		offset++
+	}
+	return offset
+}
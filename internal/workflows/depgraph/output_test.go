@@ -0,0 +1,119 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depgraph
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/stretchr/testify/require"
+)
+
+const testDepGraph = `{"schemaVersion":"1.2.0","pkgManager":{"name":"apk"},` +
+	`"pkgs":[{"id":"docker-image|alpine@3.17","info":{"name":"docker-image|alpine","version":"3.17"}}],` +
+	`"graph":{"rootNodeId":"root-node","nodes":[{"nodeId":"root-node","pkgId":"docker-image|alpine@3.17","deps":[]}]}}`
+
+func Test_ExtractDepGraphsFromCLIOutput_GivenStructuredOutput_ShouldReturnDepGraphs(t *testing.T) {
+	output := []byte(
+		`{"version":1,"target":"docker-image|alpine:3.17","depGraph":` + testDepGraph + "}\n" +
+			`{"version":1,"target":"docker-image|alpine:3.17:/app/package.json","depGraph":` + testDepGraph + "}\n",
+	)
+
+	result, err := extractDepGraphsFromCLIOutput(output, Workflow.TypeIdentifier())
+	require.NoError(t, err)
+
+	require.Len(t, result, 2)
+	require.Equal(t, []byte(testDepGraph), result[0].GetPayload())
+	require.Equal(t, constants.ContentTypeJSON, result[0].GetContentType())
+	require.Equal(t, "docker-image|alpine:3.17", result[0].GetContentLocation())
+	require.Equal(t, "docker-image|alpine:3.17:/app/package.json", result[1].GetContentLocation())
+}
+
+func Test_ExtractDepGraphsFromCLIOutput_GivenInvalidStructuredOutput_ShouldReturnErrorWithOffset(t *testing.T) {
+	validRecord := `{"version":1,"target":"docker-image|alpine:3.17","depGraph":` + testDepGraph + "}\n"
+
+	type test struct {
+		output         string
+		expectedOffset int64
+		expectedError  string
+	}
+
+	tests := map[string]test{
+		"syntax error in second record": {
+			output:         validRecord + `{"version":1,,}`,
+			expectedOffset: int64(len(validRecord)) + 13,
+			expectedError:  "invalid character ','",
+		},
+		"stray log output after record": {
+			output:         validRecord + "some log line\n",
+			expectedOffset: int64(len(validRecord)),
+			expectedError:  "invalid character 's'",
+		},
+		"stray log output before record": {
+			output:         "some log line\n" + validRecord,
+			expectedOffset: 0,
+			expectedError:  "invalid character 's'",
+		},
+		"unsupported version": {
+			output:         validRecord + `  {"version":2,"target":"x","depGraph":{}}`,
+			expectedOffset: int64(len(validRecord)) + 2,
+			expectedError:  "unsupported output version 2, expected 1",
+		},
+		"unknown field": {
+			output:         `{"version":1,"target":"x","depGraph":{},"extra":true}`,
+			expectedOffset: 0,
+			expectedError:  `unknown field "extra"`,
+		},
+		"empty target": {
+			output:         `{"version":1,"target":"","depGraph":` + testDepGraph + `}`,
+			expectedOffset: 0,
+			expectedError:  `"target" must not be empty`,
+		},
+		"wrong field type": {
+			output:         `{"version":"1"}`,
+			expectedOffset: 14,
+			expectedError:  "cannot unmarshal string",
+		},
+		"invalid depgraph": {
+			output:         `{"version":1,"target":"x","depGraph":{"schemaVersion":"1.2.0","pkgManager":{"name":"apk"}}}`,
+			expectedOffset: 0,
+			expectedError:  "graph.rootNodeId must not be empty",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := extractDepGraphsFromCLIOutput([]byte(tc.output), Workflow.TypeIdentifier())
+
+			var contractErr *outputContractError
+			require.True(t, errors.As(err, &contractErr), "expected an output contract error, got %v", err)
+			require.Equal(t, tc.expectedOffset, contractErr.Offset)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_ExtractDepGraphsFromCLIOutput_GivenLegacyOutputWithLeadingLogs_ShouldUseMarkers(t *testing.T) {
+	output := []byte("some log line {not json}\nDepGraph data:" + testDepGraph +
+		"DepGraph target:docker-image|alpine:3.17DepGraph end")
+
+	result, err := extractDepGraphsFromCLIOutput(output, Workflow.TypeIdentifier())
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, []byte(testDepGraph), result[0].GetPayload())
+	require.Equal(t, "docker-image|alpine:3.17", result[0].GetContentLocation())
+}
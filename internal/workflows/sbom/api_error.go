@@ -0,0 +1,89 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+)
+
+// maxErrorBodySize limits how much of the body of an unsuccessful response is read.
+const maxErrorBodySize = 1 << 20
+
+// jsonAPIErrorDocument is the body the Snyk REST API returns for failed requests,
+// see https://jsonapi.org/format/#errors.
+type jsonAPIErrorDocument struct {
+	Errors []jsonAPIError `json:"errors"`
+}
+
+type jsonAPIError struct {
+	ID     string         `json:"id"`
+	Status string         `json:"status"`
+	Code   string         `json:"code"`
+	Title  string         `json:"title"`
+	Detail string         `json:"detail"`
+	Meta   map[string]any `json:"meta"`
+}
+
+// apiError holds what the Snyk API reported about a failed request.
+type apiError struct {
+	code      string
+	requestID string
+	detail    string
+}
+
+// parseAPIError extracts the error code, request ID and detail of a failed request. Bodies that are
+// not JSON:API error documents are ignored, the request ID is then only taken from the headers.
+func parseAPIError(res *http.Response, body []byte) apiError {
+	result := apiError{requestID: res.Header.Get(constants.HeaderSnykRequestID)}
+
+	var doc jsonAPIErrorDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return result
+	}
+
+	var details []string
+	for _, e := range doc.Errors {
+		if result.code == "" {
+			result.code = e.Code
+		}
+		if result.requestID == "" {
+			result.requestID = requestIDFromMeta(e.Meta)
+		}
+
+		detail := e.Detail
+		if detail == "" {
+			detail = e.Title
+		}
+		if detail != "" && !slices.Contains(details, detail) {
+			details = append(details, detail)
+		}
+	}
+	result.detail = strings.Join(details, "; ")
+	return result
+}
+
+func requestIDFromMeta(meta map[string]any) string {
+	for _, key := range []string{"request_id", "requestId"} {
+		if id, ok := meta[key].(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -16,43 +16,25 @@ package sbom
 
 import (
 	"path/filepath"
-	"strings"
-)
 
-// archivePrefixes defines the supported archive URI prefixes.
-// These must stay in sync with snyk-docker-plugin/lib/image-type.ts (getImageType).
-var archivePrefixes = []string{
-	"docker-archive:",
-	"oci-archive:",
-	"kaniko-archive:",
-}
+	"github.com/snyk/container-cli/internal/common/image"
+)
 
-// isArchiveInput returns true if the input is an archive reference,
-// either prefixed (docker-archive:, oci-archive:, kaniko-archive:) or ending in .tar.
+// isArchiveInput returns true if the input refers to an image on disk, i.e. an archive (docker-archive:,
+// oci-archive:, kaniko-archive: or a file ending in .tar, .tar.gz, .tgz or .tar.zst) or an OCI layout
+// directory (oci:). Images of the docker daemon are named like registry images and are no archives.
 func isArchiveInput(input string) bool {
-	for _, prefix := range archivePrefixes {
-		if strings.HasPrefix(input, prefix) {
-			return true
-		}
-	}
-	return strings.HasSuffix(input, ".tar")
+	ref, ok := image.ParseReference(input)
+	return ok && ref.Transport != image.TransportDockerDaemon
 }
 
 // archiveMetadata extracts a meaningful name and version from an archive input.
 // For archive inputs, the name is derived from the file basename (including the
 // .tar extension), and the version is left empty. This is consistent with how
 // snyk-docker-plugin derives image identifiers and existing v1 behavior.
+// OCI layout directories are named after the directory, and versioned by the tag
+// selecting the image if there is one.
 func archiveMetadata(input string) (name, version string) {
-	path := input
-
-	// Strip known archive prefixes to get the file path.
-	for _, prefix := range archivePrefixes {
-		if strings.HasPrefix(input, prefix) {
-			path = input[len(prefix):]
-			break
-		}
-	}
-
-	name = filepath.Base(path)
-	return name, ""
+	ref, _ := image.ParseReference(input)
+	return filepath.Base(ref.Path), ref.Tag
 }
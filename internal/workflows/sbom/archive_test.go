@@ -41,11 +41,23 @@ func TestIsArchiveInput(t *testing.T) {
 		"filename .tar":      {"image.tar", true},
 		"relative path .tar": {"./relative/image.tar", true},
 
+		// Compressed tarballs
+		"absolute path .tar.gz":  {"/path/to/image.tar.gz", true},
+		"filename .tgz":          {"image.tgz", true},
+		"relative path .tar.zst": {"./relative/image.tar.zst", true},
+		"prefixed .tar.zst":      {"oci-archive:/path/to/image.tar.zst", true},
+
+		// OCI layout directories
+		"oci layout":          {"oci:/path/to/layout", true},
+		"oci layout with tag": {"oci:/path/to/layout:1.25", true},
+
 		// Non-archive inputs
 		"image with tag":          {"nginx:latest", false},
 		"image with version":      {"alpine:3.17.0", false},
 		"registry image with tag": {"registry.example.com/repo:tag", false},
 		"bare image name":         {"ubuntu", false},
+		"docker daemon image":     {"docker-daemon:nginx:1.25", false},
+		"other extension":         {"image.zip", false},
 		"empty string":            {"", false},
 	}
 
@@ -85,6 +97,17 @@ func TestArchiveMetadata(t *testing.T) {
 
 		// Non-.tar extension with prefix (e.g. .tar.gz)
 		"docker-archive tar.gz": {"docker-archive:/path/to/image.tar.gz", "image.tar.gz", ""},
+
+		// Bare compressed tarballs keep their extension just like .tar files
+		"bare tar.gz":  {"/path/to/image.tar.gz", "image.tar.gz", ""},
+		"bare tgz":     {"image.tgz", "image.tgz", ""},
+		"bare tar.zst": {"./relative/image.tar.zst", "image.tar.zst", ""},
+
+		// OCI layout directories are named after the directory and versioned by the selected tag
+		"oci layout":                   {"oci:/path/to/layout", "layout", ""},
+		"oci layout with tag":          {"oci:/path/to/layout:1.25", "layout", "1.25"},
+		"oci layout with trailing /":   {"oci:/path/to/layout/", "layout", ""},
+		"oci layout relative with tag": {"oci:build/app:v2", "app", "v2"},
 	}
 
 	for name, tc := range tests {
@@ -0,0 +1,76 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"mime"
+
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/registry"
+)
+
+// attach pushes the SBOM document to the registry of the image as an OCI referrer of the image which was
+// analysed, which is the manifest the subject was resolved to rather than the one the tag points to by now.
+func (w *Workflow) attach(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	sbomResult *GetSbomForDepGraphResult,
+	target string,
+	subject Subject,
+	opts generateOptions,
+) error {
+	if _, ok := image.ParseReference(target); ok {
+		return w.errFactory.NewAttachLocalImageError(target)
+	}
+
+	desc, err := w.registryClient.PushReferrer(
+		ctx,
+		attachReference(target, subject),
+		subject.Platform,
+		opts.credentials,
+		registry.Artifact{
+			ArtifactType: artifactTypeForMIMEType(sbomResult.MIMEType),
+			MediaType:    sbomResult.MIMEType,
+			Content:      sbomResult.Doc,
+		},
+	)
+	if err != nil {
+		return w.errFactory.NewAttachError(target, err)
+	}
+
+	logger.Info().Msgf("attached the SBOM to %s as %s", target, desc.Digest)
+	return nil
+}
+
+// attachReference returns the reference of the manifest the subject was resolved to. The target is
+// resolved again if the digest of the subject is unknown.
+func attachReference(target string, subject Subject) string {
+	if subject.Registry == "" || subject.Repository == "" || subject.Digest == "" {
+		return target
+	}
+	return subject.Registry + "/" + subject.Repository + "@" + subject.Digest
+}
+
+// artifactTypeForMIMEType returns the artifact type of an SBOM document, which is its media type
+// without parameters, e.g. "application/vnd.cyclonedx+json".
+func artifactTypeForMIMEType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return mimeType
+	}
+	return mediaType
+}
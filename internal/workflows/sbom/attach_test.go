@@ -0,0 +1,130 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opencontainers/go-digest"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/registry"
+	"github.com/snyk/container-cli/internal/common/registry/registrytest"
+	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Entrypoint_GivenAttach_ShouldPushSbomAsReferrerOfImage(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{Username: "user", Password: "secret"})
+	subject := r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+
+	config := beforeEachInMemory(t, r.Host()+"/app:1.0")
+	config.Set(flags.FlagAttach.Name, true)
+	config.Set(flags.FlagUsername.Name, "user")
+	config.Set(flags.FlagPassword.Name, "secret")
+	sbomWorkflow = NewWorkflow(mockSbomClient, nil, nil, newTestRegistryClient(r), errFactory)
+
+	sbomDoc := getSbom(t, "testdata/sbom_result_doc.json")
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: sbomDoc, MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, sbomDoc, result[0].GetPayload())
+
+	referrers := r.Referrers("app", subject)
+	require.Len(t, referrers, 1)
+	require.Equal(t, "application/vnd.cyclonedx+json", referrers[0].ArtifactType)
+	require.Equal(t, sbomDoc, r.Blob(referrers[0].Layers[0].Digest))
+}
+
+func Test_Entrypoint_GivenTagMovedAfterAnalysis_ShouldAttachSbomToAnalysedImage(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{Username: "user", Password: "secret"})
+	analysed := r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+
+	config := beforeEachInMemory(t, r.Host()+"/app:1.0")
+	config.Set(flags.FlagAttach.Name, true)
+	config.Set(flags.FlagUsername.Name, "user")
+	config.Set(flags.FlagPassword.Name, "secret")
+	sbomWorkflow = NewWorkflow(mockSbomClient, nil, nil, newTestRegistryClient(r), errFactory)
+
+	var moved digest.Digest
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		DoAndReturn(func(workflow.Identifier, configuration.Configuration) ([]workflow.Data, error) {
+			moved = r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2,"moved":true}`))
+			return []workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil
+		})
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: []byte(`{}`), MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, r.Referrers("app", analysed), 1)
+	require.Empty(t, r.Referrers("app", moved))
+}
+
+func Test_AttachReference_GivenSubject_ShouldReferenceResolvedDigest(t *testing.T) {
+	require.Equal(t, "docker.io/library/alpine@"+testImageDigest.String(),
+		attachReference("alpine:3.17.0", alpineSubject("")))
+	require.Equal(t, "alpine:3.17.0", attachReference("alpine:3.17.0", Subject{Name: "alpine", Version: "3.17.0"}))
+}
+
+func Test_Entrypoint_GivenAttachWithoutCredentials_ShouldReturnAttachError(t *testing.T) {
+	r := registrytest.New(t, registrytest.Options{Username: "user", Password: "secret"})
+	r.PutManifest("app", "1.0", image.MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+
+	config := beforeEachInMemory(t, r.Host()+"/app:1.0")
+	config.Set(flags.FlagAttach.Name, true)
+	sbomWorkflow = NewWorkflow(mockSbomClient, nil, nil, newTestRegistryClient(r), errFactory)
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: []byte(`{}`), MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "The registry denied access to the image "+r.Host()+"/app:1.0")
+}
+
+func Test_Entrypoint_GivenAttachAndArchive_ShouldReturnAttachLocalImageError(t *testing.T) {
+	config := beforeEachInMemory(t, "docker-archive:image.tar")
+	config.Set(flags.FlagAttach.Name, true)
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: []byte(`{}`), MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewAttachLocalImageError("docker-archive:image.tar").Error())
+}
+
+func Test_ArtifactTypeForMIMEType_GivenParameters_ShouldStripThem(t *testing.T) {
+	require.Equal(t, "application/vnd.cyclonedx+json", artifactTypeForMIMEType("application/vnd.cyclonedx+json"))
+	require.Equal(t, "application/spdx+json", artifactTypeForMIMEType("application/spdx+json; version=2.3"))
+}
+
+func newTestRegistryClient(r *registrytest.Registry) *registry.Client {
+	return registry.NewClient(registry.ClientConfig{Client: r.Client(), Logger: &zlog.Logger})
+}
@@ -0,0 +1,98 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/snyk/container-cli/internal/common/attestation"
+)
+
+// loadSigner loads the key SBOMs are signed with and makes sure the format can be attested.
+func (w *Workflow) loadSigner(path, format string) (attestation.Signer, error) {
+	// the SBOM becomes the predicate of an in-toto statement, which has to be JSON.
+	if !strings.HasSuffix(format, "+json") {
+		return nil, w.errFactory.NewAttestationFormatError(format)
+	}
+
+	signer, err := attestation.LoadSigner(path)
+	if err != nil {
+		return nil, w.errFactory.NewSigningKeyError(path, err)
+	}
+	return signer, nil
+}
+
+// attest wraps the SBOM into an in-toto statement about the image and returns the signed DSSE envelope.
+func (w *Workflow) attest(
+	sbomResult *GetSbomForDepGraphResult,
+	target string,
+	subject Subject,
+	opts generateOptions,
+) (*GetSbomForDepGraphResult, error) {
+	name, d, err := subjectDigest(subject)
+	if err != nil {
+		return nil, w.errFactory.NewSubjectDigestError(target, err)
+	}
+
+	statement := attestation.NewStatement(name, d, predicateTypeForMIMEType(sbomResult.MIMEType), sbomResult.Doc)
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, w.errFactory.NewInternalError(fmt.Errorf("could not marshal in-toto statement: %w", err))
+	}
+
+	envelope, err := attestation.Sign(attestation.PayloadTypeInToto, payload, opts.signer)
+	if err != nil {
+		return nil, w.errFactory.NewInternalError(err)
+	}
+
+	doc, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, w.errFactory.NewInternalError(fmt.Errorf("could not marshal dsse envelope: %w", err))
+	}
+
+	return &GetSbomForDepGraphResult{Doc: doc, MIMEType: attestation.MediaTypeEnvelope}, nil
+}
+
+// subjectDigest returns the name and digest the attestation of the image is about. Registries and verifiers
+// match attestations by the digest of the image manifest, which docker archives don't store. Their image id
+// is the digest of the image configuration, which would never match, so they can't be attested.
+func subjectDigest(subject Subject) (string, digest.Digest, error) {
+	name := subject.Name
+	if subject.Registry != "" {
+		name = subject.Registry + "/" + subject.Repository
+	}
+
+	switch {
+	case subject.Digest != "":
+		d, err := digest.Parse(subject.Digest)
+		return name, d, err
+	case subject.ImageID != "":
+		return "", "", errors.New("the archive does not record the digest of the image manifest")
+	default:
+		return "", "", errors.New("the digest of the image could not be resolved")
+	}
+}
+
+// predicateTypeForMIMEType returns the in-toto predicate type of an SBOM document.
+func predicateTypeForMIMEType(mimeType string) string {
+	if strings.Contains(mimeType, "spdx") {
+		return attestation.PredicateTypeSPDX
+	}
+	return attestation.PredicateTypeCycloneDX
+}
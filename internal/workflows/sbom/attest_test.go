@@ -0,0 +1,148 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opencontainers/go-digest"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/attestation"
+	"github.com/snyk/container-cli/internal/common/attestation/attestationtest"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/snyk/container-cli/internal/common/registry"
+	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var testImageDigest = digest.FromString("alpine manifest")
+
+func Test_Entrypoint_GivenSignKey_ShouldReturnSignedAttestationOfImageDigest(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.ECDSAP256)
+	config := beforeEachInMemory(t, "alpine@"+testImageDigest.String())
+	config.Set(flags.FlagSignKey.Name, keys.PrivateKeyPath)
+
+	sbomDoc := getSbom(t, "testdata/sbom_result_doc.json")
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: sbomDoc, MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, attestation.MediaTypeEnvelope, result[0].GetContentType())
+
+	var envelope attestation.Envelope
+	require.NoError(t, json.Unmarshal(result[0].GetPayload().([]byte), &envelope))
+
+	verifier, err := attestation.LoadVerifier(keys.PublicKeyPath)
+	require.NoError(t, err)
+	payload, err := envelope.Verify(verifier)
+	require.NoError(t, err)
+
+	var statement attestation.Statement
+	require.NoError(t, json.Unmarshal(payload, &statement))
+	require.Equal(t, attestation.PredicateTypeCycloneDX, statement.PredicateType)
+	require.Equal(t, []attestation.Subject{
+		{Name: "docker.io/library/alpine", Digest: map[string]string{"sha256": testImageDigest.Encoded()}},
+	}, statement.Subject)
+	require.JSONEq(t, string(sbomDoc), string(statement.Predicate))
+}
+
+func Test_Entrypoint_GivenSignKeyAndUnresolvableImage_ShouldReturnSubjectDigestError(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.Ed25519)
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagSignKey.Name, keys.PrivateKeyPath)
+
+	mockRegistryClient = NewMockRegistryClient(mockCtrl)
+	mockRegistryClient.EXPECT().Resolve(gomock.Any(), "alpine:3.17.0", "", registry.Credentials{}).
+		Return(nil, errors.New("registry unreachable"))
+	sbomWorkflow = NewWorkflow(mockSbomClient, nil, nil, mockRegistryClient, errFactory)
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: []byte(`{}`), MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "the digest of the image could not be resolved")
+}
+
+func Test_Entrypoint_GivenSignKeyAndXMLFormat_ShouldReturnAttestationFormatError(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.Ed25519)
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagSbomFormat.Name, "cyclonedx1.5+xml")
+	config.Set(flags.FlagSignKey.Name, keys.PrivateKeyPath)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewAttestationFormatError("cyclonedx1.5+xml").Error())
+}
+
+func Test_Entrypoint_GivenMissingSignKey_ShouldReturnSigningKeyError(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagSignKey.Name, "testdata/missing.pem")
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "The signing key (testdata/missing.pem) could not be loaded")
+}
+
+func Test_SubjectDigest_GivenDockerArchive_ShouldReturnError(t *testing.T) {
+	dockerArchive := filepath.Join(t.TempDir(), "alpine.tar")
+	imagetest.WriteDockerArchive(t, dockerArchive, nil, imagetest.Layer{"etc/os-release": "alpine"})
+	w := NewWorkflow(nil, nil, nil, nil, errFactory)
+	subject := w.resolveSubject(
+		context.Background(), &zlog.Logger, "docker-archive:"+dockerArchive, Subject{Name: "alpine.tar"},
+		generateOptions{},
+	)
+
+	// docker archives have no manifest, the image id is the digest of the config instead.
+	require.Equal(t, digest.FromBytes(imagetest.Config).String(), subject.ImageID)
+	_, _, err := subjectDigest(subject)
+	require.EqualError(t, err, "the archive does not record the digest of the image manifest")
+}
+
+func Test_SubjectDigest_GivenOCIArchive_ShouldReturnManifestDigest(t *testing.T) {
+	ociArchive := filepath.Join(t.TempDir(), "alpine.tar")
+	manifestDigest := imagetest.WriteOCIArchive(t, ociArchive, "", imagetest.Layer{"etc/os-release": "alpine"})
+	w := NewWorkflow(nil, nil, nil, nil, errFactory)
+	subject := w.resolveSubject(
+		context.Background(), &zlog.Logger, "oci-archive:"+ociArchive, Subject{Name: "alpine.tar"},
+		generateOptions{},
+	)
+
+	name, d, err := subjectDigest(subject)
+	require.NoError(t, err)
+	require.Equal(t, "alpine.tar", name)
+	require.Equal(t, manifestDigest, d)
+}
+
+func Test_SubjectDigest_GivenUnresolvedSubject_ShouldReturnError(t *testing.T) {
+	_, _, err := subjectDigest(Subject{Name: "alpine", Tag: "3.17.0"})
+	require.EqualError(t, err, "the digest of the image could not be resolved")
+}
+
+func Test_PredicateTypeForMIMEType_GivenSbomFormats_ShouldReturnMatchingPredicateType(t *testing.T) {
+	require.Equal(t, attestation.PredicateTypeCycloneDX, predicateTypeForMIMEType("application/vnd.cyclonedx+json"))
+	require.Equal(t, attestation.PredicateTypeSPDX, predicateTypeForMIMEType("application/spdx+json"))
+}
@@ -14,6 +14,8 @@
 
 package constants
 
+import "time"
+
 var SbomValidFormats = []string{
 	"cyclonedx1.4+json",
 	"cyclonedx1.4+xml",
@@ -24,6 +26,20 @@ var SbomValidFormats = []string{
 	"spdx2.3+json",
 }
 
+// VulnerabilitiesModes are the ways vulnerabilities can be added to the SBOM: embedded into CycloneDX
+// documents or as an accompanying OpenVEX document.
+var VulnerabilitiesModes = []string{
+	"cyclonedx",
+	"openvex",
+}
+
+// SbomAPIModes are the ways the Snyk SBOM API generates SBOMs: in the response to the request, or in a job
+// which is polled until it finishes.
+var SbomAPIModes = []string{
+	"sync",
+	"async",
+}
+
 var ValidPlatforms = []string{
 	"linux/amd64",
 	"linux/arm64",
@@ -34,3 +50,9 @@ var ValidPlatforms = []string{
 	"linux/arm/v7",
 	"linux/arm/v6",
 }
+
+// The limits of the retries of requests to the SBOM API, unless other ones are configured.
+const (
+	DefaultRetryMaxAttempts = 4
+	DefaultRetryMaxBackoff  = 30 * time.Second
+)
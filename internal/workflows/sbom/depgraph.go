@@ -19,43 +19,53 @@ import (
 	"fmt"
 
 	"github.com/docker/distribution/reference"
-	"github.com/opencontainers/go-digest"
+	"github.com/snyk/container-cli/internal/common/image"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 )
 
-func depGraphMetadata(imgName string) (name, version string, err error) {
-	// Archive inputs (docker-archive:, oci-archive:, kaniko-archive:, *.tar) cannot be
+// parseSubject describes the image passed on the command line. The registry, repository, tag and digest
+// are only known for registry references, archive inputs are identified by their basename until the
+// archive is read by resolveSubject.
+func parseSubject(imgName string) (Subject, error) {
+	// Archive inputs (docker-archive:, oci-archive:, kaniko-archive:, oci:, *.tar[.gz|.zst]) cannot be
 	// parsed by the Docker distribution reference library. Handle them separately.
 	if isArchiveInput(imgName) {
-		name, version = archiveMetadata(imgName)
-		return name, version, nil
+		name, version := archiveMetadata(imgName)
+		return Subject{Name: name, Version: version, Tag: version}, nil
+	}
+
+	// images of the docker daemon are named just like registry images.
+	if ref, ok := image.ParseReference(imgName); ok && ref.Transport == image.TransportDockerDaemon {
+		return parseSubject(ref.Path)
 	}
 
 	// we currently don't have a way of extracting the clean image name & potentially a digest from
 	// the DepGraph output, so we use what's been passed on the command line.
 	ref, err := reference.Parse(imgName)
 	if err != nil {
-		return "", "", fmt.Errorf("could not parse container name: %w", err)
+		return Subject{}, fmt.Errorf("could not parse container name: %w", err)
 	}
 
-	// sadly the reference library doesn't export these interfaces...
-	type named interface{ Name() string }
-	type digested interface{ Digest() digest.Digest }
-	type tagged interface{ Tag() string }
 	// shouldn't happen :-)
-	n, ok := ref.(named)
+	n, ok := ref.(reference.Named)
 	if !ok {
-		return "", "", fmt.Errorf("image %q does not contain a name", imgName)
+		return Subject{}, fmt.Errorf("image %q does not contain a name", imgName)
 	}
 
-	switch s := ref.(type) {
-	case digested:
-		return n.Name(), s.Digest().String(), nil
-	case tagged:
-		return n.Name(), s.Tag(), nil
-	default:
-		return n.Name(), "", nil
+	subject := Subject{Name: n.Name()}
+	if normalized, err := reference.ParseNormalizedNamed(imgName); err == nil {
+		subject.Registry = reference.Domain(normalized)
+		subject.Repository = reference.Path(normalized)
+	}
+	if tagged, ok := ref.(reference.Tagged); ok {
+		subject.Tag = tagged.Tag()
+		subject.Version = subject.Tag
+	}
+	if digested, ok := ref.(reference.Digested); ok {
+		subject.Digest = digested.Digest().String()
+		subject.Version = subject.Digest
 	}
+	return subject, nil
 }
 
 func parseDepGraph(depGraphs []workflow.Data) ([]json.RawMessage, error) {
@@ -0,0 +1,151 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+// cachedDepGraph is a depgraph returned by the depgraph workflow, as stored in the cache. The depgraph is
+// kept byte for byte, so cached and fresh analyses produce the same SBOMs.
+type cachedDepGraph struct {
+	ContentLocation string `json:"contentLocation"`
+	DepGraph        []byte `json:"depGraph"`
+}
+
+// depGraphCacheKey identifies the depgraphs of the image by its reference and digest, along with the values of
+// the flags affecting the analysis. The depgraphs name their root package and content location after the
+// reference the image was passed as, so images pulled by another tag of the same digest are analysed again.
+// Images without a known digest can't be cached.
+func depGraphCacheKey(config configuration.Configuration, subject Subject) (string, bool) {
+	_, d, err := subjectDigest(subject)
+	if err != nil {
+		return "", false
+	}
+
+	ref := config.GetString(constants.ContainerTargetArgName)
+	if !strings.Contains(ref, "@") {
+		ref += "@" + d.String()
+	}
+	parts := []string{ref}
+	for _, f := range flags.CommonFlags {
+		// credentials don't affect the analysis and must not end up in the cache.
+		if slices.Contains(flags.RegistryCredentialsFlags, f) {
+			continue
+		}
+		if arg := f.GetAsCLIArgument(config); arg != "" {
+			parts = append(parts, arg)
+		}
+	}
+	return strings.Join(parts, " "), true
+}
+
+// invokeDepGraph returns the depgraphs of the image, which are taken from the cache if the image was
+// analysed before. Failing to use the cache is not fatal, the image is analysed instead.
+func (w *Workflow) invokeDepGraph(
+	ctx context.Context,
+	engine workflow.Engine,
+	logger *zerolog.Logger,
+	config configuration.Configuration,
+	subject Subject,
+	opts generateOptions,
+) ([]workflow.Data, error) {
+	var key string
+	cacheable := false
+	if opts.cache != nil {
+		key, cacheable = depGraphCacheKey(config, subject)
+	}
+
+	if cacheable {
+		if value, ok := opts.cache.Get(key); ok {
+			depGraphs, err := w.decodeCachedDepGraphs(value)
+			if err == nil {
+				logger.Info().Msgf("using the cached depgraphs of %s", key)
+				return depGraphs, nil
+			}
+			logger.Warn().Err(err).Msgf("ignoring the invalid cached depgraphs of %s", key)
+		}
+	}
+
+	logger.Debug().Msg("invoking depgraph workflow")
+	depGraphs, err := invokeWithContext(ctx, func() ([]workflow.Data, error) {
+		// the engine can't pass the context on, the depgraph workflow bounds its native analysis by the deadline
+		// instead. The legacy CLI can't be bounded, interrupts terminate it along with this process meanwhile.
+		depGraphConfig := config.Clone()
+		if deadline, ok := ctx.Deadline(); ok {
+			depGraphConfig.Set(constants.DepGraphDeadlineArgName, deadline)
+		}
+		defer opts.interrupts.pause()()
+		return engine.InvokeWithConfig(w.depGraph.Identifier(), depGraphConfig)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		if err := w.cacheDepGraphs(opts, key, depGraphs); err != nil {
+			logger.Warn().Err(err).Msgf("could not cache the depgraphs of %s", key)
+		}
+	}
+	return depGraphs, nil
+}
+
+func (w *Workflow) cacheDepGraphs(opts generateOptions, key string, depGraphs []workflow.Data) error {
+	cached := make([]cachedDepGraph, 0, len(depGraphs))
+	for _, d := range depGraphs {
+		if d.GetPayload() == nil {
+			continue
+		}
+		payload, ok := d.GetPayload().([]byte)
+		if !ok {
+			return fmt.Errorf("invalid payload type, want []byte, got %T", d.GetPayload())
+		}
+		// the content location is optional, an error only tells it is absent.
+		location, _ := d.GetMetaData(constants.HeaderContentLocation)
+		cached = append(cached, cachedDepGraph{ContentLocation: location, DepGraph: payload})
+	}
+
+	value, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	return opts.cache.Put(key, value)
+}
+
+func (w *Workflow) decodeCachedDepGraphs(value []byte) ([]workflow.Data, error) {
+	var cached []cachedDepGraph
+	if err := json.Unmarshal(value, &cached); err != nil {
+		return nil, err
+	}
+
+	depGraphs := make([]workflow.Data, len(cached))
+	for i, c := range cached {
+		depGraphs[i] = workflow.NewData(w.depGraph.TypeIdentifier(), constants.ContentTypeJSON, c.DepGraph)
+		if c.ContentLocation != "" {
+			depGraphs[i].SetMetaData(constants.HeaderContentLocation, c.ContentLocation)
+		}
+	}
+	return depGraphs, nil
+}
@@ -0,0 +1,83 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/container-cli/internal/common/flags"
+	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Entrypoint_GivenCachedDepGraph_ShouldNotAnalyseImageAgain(t *testing.T) {
+	cacheDir := t.TempDir()
+	depGraph := getValidDepGraph(t, "testdata/sbom_request_depgraph.json")
+
+	run := func(analyse bool) {
+		config := beforeEachInMemory(t, "alpine:3.17.0")
+		config.Set(flags.FlagNoCache.Name, false)
+		config.Set(flags.FlagCacheDir.Name, cacheDir)
+
+		// the engine of a run fails the test if the depgraph workflow is invoked without being expected.
+		if analyse {
+			mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+				Return([]workflow.Data{depGraph}, nil)
+		}
+		mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			&GetSbomForDepGraphRequest{
+				DepGraphs: getDepGraphBytes([]workflow.Data{depGraph}),
+				Subject:   alpineSubject(""),
+			}).
+			Return(&GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+		_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+		require.NoError(t, err)
+	}
+
+	run(true)
+	run(false)
+}
+
+func Test_DepGraphCacheKey_GivenSubject_ShouldIdentifyAnalysis(t *testing.T) {
+	config := configuration.NewInMemory()
+	config.Set(flags.FlagPlatform.Name, "linux/arm64")
+	config.Set(flags.FlagUsername.Name, "user")
+	config.Set(flags.FlagPassword.Name, "secret")
+
+	config.Set(constants.ContainerTargetArgName, "alpine:3.17.0")
+
+	key, ok := depGraphCacheKey(config, alpineSubject(""))
+	require.True(t, ok)
+	require.Equal(t, "alpine:3.17.0@"+testImageDigest.String()+" --platform=linux/arm64", key)
+
+	// the depgraphs are named after the tag, which must not be taken from the analysis of another tag.
+	config.Set(constants.ContainerTargetArgName, "alpine:latest")
+	latestKey, ok := depGraphCacheKey(config, alpineSubject(""))
+	require.True(t, ok)
+	require.Equal(t, "alpine:latest@"+testImageDigest.String()+" --platform=linux/arm64", latestKey)
+
+	config.Set(constants.ContainerTargetArgName, "alpine@"+testImageDigest.String())
+	digestKey, ok := depGraphCacheKey(config, alpineSubject(""))
+	require.True(t, ok)
+	require.Equal(t, "alpine@"+testImageDigest.String()+" --platform=linux/arm64", digestKey)
+
+	_, ok = depGraphCacheKey(config, Subject{Name: "alpine", Version: "3.17.0"})
+	require.False(t, ok)
+}
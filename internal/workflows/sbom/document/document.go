@@ -0,0 +1,239 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package document reads the packages listed in SBOM documents of the formats generated by the sbom workflow.
+package document
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Format is the format of an SBOM document.
+type Format string
+
+const (
+	FormatCycloneDXJSON Format = "cyclonedx+json"
+	FormatCycloneDXXML  Format = "cyclonedx+xml"
+	FormatSPDXJSON      Format = "spdx+json"
+)
+
+// ErrUnsupportedFormat is returned for documents which are neither CycloneDX nor SPDX.
+var ErrUnsupportedFormat = errors.New("not a CycloneDX (JSON or XML) or SPDX (JSON) document")
+
+// Component is a package listed in an SBOM document.
+type Component struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+	// Licenses are SPDX license ids, license names or SPDX license expressions.
+	Licenses []string `json:"licenses,omitempty"`
+}
+
+// Document is the format independent content of an SBOM document.
+type Document struct {
+	Format Format
+	// Subject is the component the document describes, typically the container image.
+	Subject Component
+	// Components are all other components listed in the document.
+	Components []Component
+}
+
+// Parse detects the format of an SBOM document and reads its components.
+func Parse(data []byte) (*Document, error) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(trimmed) == 0 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	switch trimmed[0] {
+	case '<':
+		return parseCycloneDXXML(trimmed)
+	case '{':
+		var probe struct {
+			BOMFormat   string `json:"bomFormat"`
+			SPDXVersion string `json:"spdxVersion"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return nil, fmt.Errorf("invalid JSON document: %w", err)
+		}
+		switch {
+		case probe.BOMFormat == "CycloneDX":
+			return parseCycloneDXJSON(trimmed)
+		case strings.HasPrefix(probe.SPDXVersion, "SPDX-2."):
+			return parseSPDXJSON(trimmed)
+		}
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+type cdxComponent struct {
+	Name       string         `json:"name" xml:"name"`
+	Version    string         `json:"version" xml:"version"`
+	PURL       string         `json:"purl" xml:"purl"`
+	Components []cdxComponent `json:"components" xml:"components>component"`
+	// licenses are an array of choices in JSON, but a mix of license and expression elements in XML.
+	Licenses    []cdxLicenseChoice `json:"licenses" xml:"-"`
+	XMLLicenses struct {
+		Licenses    []cdxLicense `xml:"license"`
+		Expressions []string     `xml:"expression"`
+	} `json:"-" xml:"licenses"`
+}
+
+type cdxLicense struct {
+	ID   string `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+type cdxLicenseChoice struct {
+	License    cdxLicense `json:"license"`
+	Expression string     `json:"expression"`
+}
+
+func (c *cdxComponent) component() Component {
+	component := Component{Name: c.Name, Version: c.Version, PURL: c.PURL}
+	add := func(l string) {
+		if l != "" {
+			component.Licenses = append(component.Licenses, l)
+		}
+	}
+	for _, l := range c.Licenses {
+		add(cmp.Or(l.License.ID, l.License.Name, l.Expression))
+	}
+	for _, l := range c.XMLLicenses.Licenses {
+		add(cmp.Or(l.ID, l.Name))
+	}
+	for _, e := range c.XMLLicenses.Expressions {
+		add(e)
+	}
+	return component
+}
+
+type cdxBOM struct {
+	XMLName  xml.Name `json:"-"`
+	Metadata struct {
+		Component cdxComponent `json:"component" xml:"component"`
+	} `json:"metadata" xml:"metadata"`
+	Components []cdxComponent `json:"components" xml:"components>component"`
+}
+
+func parseCycloneDXJSON(data []byte) (*Document, error) {
+	var bom cdxBOM
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+	}
+	return newCycloneDXDocument(FormatCycloneDXJSON, &bom), nil
+}
+
+func parseCycloneDXXML(data []byte) (*Document, error) {
+	var bom cdxBOM
+	if err := xml.Unmarshal(data, &bom); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+	}
+	if bom.XMLName.Local != "bom" || !strings.HasPrefix(bom.XMLName.Space, "http://cyclonedx.org/schema/bom/") {
+		return nil, ErrUnsupportedFormat
+	}
+	return newCycloneDXDocument(FormatCycloneDXXML, &bom), nil
+}
+
+func newCycloneDXDocument(format Format, bom *cdxBOM) *Document {
+	doc := &Document{
+		Format:  format,
+		Subject: bom.Metadata.Component.component(),
+	}
+
+	// components can be nested, e.g. the packages of an application within the image.
+	var walk func(components []cdxComponent)
+	walk = func(components []cdxComponent) {
+		for _, c := range components {
+			doc.Components = append(doc.Components, c.component())
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+	return doc
+}
+
+type spdxDocument struct {
+	DocumentDescribes []string `json:"documentDescribes"`
+	Packages          []struct {
+		SPDXID           string `json:"SPDXID"`
+		Name             string `json:"name"`
+		VersionInfo      string `json:"versionInfo"`
+		LicenseConcluded string `json:"licenseConcluded"`
+		LicenseDeclared  string `json:"licenseDeclared"`
+		ExternalRefs     []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+	Relationships []struct {
+		SPDXElementID      string `json:"spdxElementId"`
+		RelationshipType   string `json:"relationshipType"`
+		RelatedSPDXElement string `json:"relatedSpdxElement"`
+	} `json:"relationships"`
+}
+
+func parseSPDXJSON(data []byte) (*Document, error) {
+	var spdx spdxDocument
+	if err := json.Unmarshal(data, &spdx); err != nil {
+		return nil, fmt.Errorf("invalid SPDX document: %w", err)
+	}
+
+	described := make(map[string]bool)
+	for _, id := range spdx.DocumentDescribes {
+		described[id] = true
+	}
+	for _, r := range spdx.Relationships {
+		if r.SPDXElementID == "SPDXRef-DOCUMENT" && r.RelationshipType == "DESCRIBES" {
+			described[r.RelatedSPDXElement] = true
+		}
+	}
+
+	doc := &Document{Format: FormatSPDXJSON}
+	for _, p := range spdx.Packages {
+		c := Component{Name: p.Name, Version: p.VersionInfo}
+		if l := spdxLicense(p.LicenseConcluded, p.LicenseDeclared); l != "" {
+			c.Licenses = []string{l}
+		}
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				c.PURL = ref.ReferenceLocator
+				break
+			}
+		}
+
+		if described[p.SPDXID] && doc.Subject.Name == "" {
+			doc.Subject = c
+			continue
+		}
+		doc.Components = append(doc.Components, c)
+	}
+	return doc, nil
+}
+
+// spdxLicense returns the first of the licenses which is actually known.
+func spdxLicense(licenses ...string) string {
+	for _, l := range licenses {
+		if l != "" && l != "NOASSERTION" && l != "NONE" {
+			return l
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse_GivenSupportedFormat_ShouldReturnComponents(t *testing.T) {
+	type test struct {
+		file           string
+		expectedFormat document.Format
+	}
+
+	tests := map[string]test{
+		"CycloneDX 1.4 JSON": {file: "local_sbom_cyclonedx1.4+json.golden", expectedFormat: document.FormatCycloneDXJSON},
+		"CycloneDX 1.6 JSON": {file: "local_sbom_cyclonedx1.6+json.golden", expectedFormat: document.FormatCycloneDXJSON},
+		"CycloneDX 1.4 XML":  {file: "local_sbom_cyclonedx1.4+xml.golden", expectedFormat: document.FormatCycloneDXXML},
+		"CycloneDX 1.6 XML":  {file: "local_sbom_cyclonedx1.6+xml.golden", expectedFormat: document.FormatCycloneDXXML},
+		"SPDX 2.3 JSON":      {file: "local_sbom_spdx2.3+json.golden", expectedFormat: document.FormatSPDXJSON},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile("../testdata/" + tc.file)
+			require.NoError(t, err)
+
+			doc, err := document.Parse(data)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedFormat, doc.Format)
+			require.Equal(t, document.Component{
+				Name: "alpine", Version: "3.17.0", PURL: "pkg:docker/alpine@3.17.0",
+			}, doc.Subject)
+			require.Equal(t, []document.Component{
+				{Name: "testpkg", Version: "10.10"},
+				{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"},
+			}, doc.Components)
+		})
+	}
+}
+
+func Test_Parse_GivenNestedCycloneDXComponents_ShouldFlattenThem(t *testing.T) {
+	doc, err := document.Parse([]byte(`{"bomFormat":"CycloneDX","components":[` +
+		`{"name":"app","version":"1","components":[{"name":"lib","version":"2"}]}]}`))
+	require.NoError(t, err)
+
+	require.Equal(t, []document.Component{{Name: "app", Version: "1"}, {Name: "lib", Version: "2"}}, doc.Components)
+}
+
+func Test_Parse_GivenLicenses_ShouldReadThem(t *testing.T) {
+	tests := map[string]string{
+		"CycloneDX JSON": `{"bomFormat":"CycloneDX","components":[{"name":"lib","licenses":[` +
+			`{"license":{"id":"MIT"}},{"license":{"name":"Custom"}},{"expression":"GPL-2.0-only OR BSD-3-Clause"}]}]}`,
+		"CycloneDX XML": `<bom xmlns="http://cyclonedx.org/schema/bom/1.5"><components><component><name>lib</name>` +
+			`<licenses><license><id>MIT</id></license><license><name>Custom</name></license>` +
+			`<expression>GPL-2.0-only OR BSD-3-Clause</expression></licenses></component></components></bom>`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := document.Parse([]byte(data))
+			require.NoError(t, err)
+
+			require.Equal(t, []document.Component{{
+				Name:     "lib",
+				Licenses: []string{"MIT", "Custom", "GPL-2.0-only OR BSD-3-Clause"},
+			}}, doc.Components)
+		})
+	}
+
+	doc, err := document.Parse([]byte(`{"spdxVersion":"SPDX-2.3","packages":[` +
+		`{"name":"concluded","licenseConcluded":"MIT","licenseDeclared":"Apache-2.0"},` +
+		`{"name":"declared","licenseConcluded":"NOASSERTION","licenseDeclared":"Apache-2.0"},` +
+		`{"name":"unknown","licenseConcluded":"NOASSERTION","licenseDeclared":"NONE"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, []document.Component{
+		{Name: "concluded", Licenses: []string{"MIT"}},
+		{Name: "declared", Licenses: []string{"Apache-2.0"}},
+		{Name: "unknown"},
+	}, doc.Components)
+}
+
+func Test_Parse_GivenUnsupportedDocument_ShouldReturnError(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"other json":     `{"name":"not an sbom"}`,
+		"other xml":      `<project xmlns="http://maven.apache.org/POM/4.0.0"></project>`,
+		"not a document": "alpine:3.17.0",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := document.Parse([]byte(data))
+			require.ErrorIs(t, err, document.ErrUnsupportedFormat)
+		})
+	}
+}
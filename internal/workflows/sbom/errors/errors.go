@@ -15,11 +15,15 @@
 package errors
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	containererrors "github.com/snyk/container-cli/internal/common/errors"
+	"github.com/snyk/container-cli/internal/common/registry"
 )
 
 type SbomErrorFactory struct {
@@ -36,6 +40,7 @@ func (ef *SbomErrorFactory) NewEmptySbomFormatError(
 	validSbomFormats []string,
 ) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeEmptySbomFormat,
 		fmt.Errorf("no format provided"),
 		fmt.Sprintf(
 			"Must set `--format` flag to specify an SBOM format. "+
@@ -49,6 +54,7 @@ func (ef *SbomErrorFactory) NewInvalidSbomFormatError(
 	invalid string, validSbomFormats []string,
 ) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeInvalidSbomFormat,
 		fmt.Errorf("invalid format provided (%s)", invalid),
 		fmt.Sprintf(
 			"The format provided (%s) is not one of the available formats. "+
@@ -63,6 +69,7 @@ func (ef *SbomErrorFactory) NewInvalidPlatformError(
 	invalid string, validPlatforms []string,
 ) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeInvalidPlatform,
 		fmt.Errorf("invalid platform provided (%s)", invalid),
 		fmt.Sprintf(
 			"The platform provided (%s) is not one of the available platforms. "+
@@ -73,8 +80,323 @@ func (ef *SbomErrorFactory) NewInvalidPlatformError(
 	)
 }
 
+func (ef *SbomErrorFactory) NewTargetsFileError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeTargetsFile,
+		fmt.Errorf("could not read targets file %s: %w", path, err),
+		fmt.Sprintf(
+			"The targets file (%s) could not be read. "+
+				"Please make sure it exists and lists one container image per line.",
+			path,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewInvalidConcurrencyError(invalid int) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidConcurrency,
+		fmt.Errorf("invalid concurrency provided (%d)", invalid),
+		fmt.Sprintf("The concurrency provided (%d) must be a positive number.", invalid),
+	)
+}
+
+func (ef *SbomErrorFactory) NewInvalidTimeoutError(invalid time.Duration) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidTimeout,
+		fmt.Errorf("invalid timeout provided (%s)", invalid),
+		fmt.Sprintf("The timeout provided (%s) must not be negative, use 0 to disable the timeout.", invalid),
+	)
+}
+
+func (ef *SbomErrorFactory) NewInvalidRetryMaxAttemptsError(invalid int) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidRetryMaxAttempts,
+		fmt.Errorf("invalid maximum number of attempts provided (%d)", invalid),
+		fmt.Sprintf("The maximum number of attempts provided (%d) must be a positive number, "+
+			"use 1 to disable retries.", invalid),
+	)
+}
+
+func (ef *SbomErrorFactory) NewInvalidRetryMaxBackoffError(
+	invalid time.Duration,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidRetryMaxBackoff,
+		fmt.Errorf("invalid maximum backoff provided (%s)", invalid),
+		fmt.Sprintf("The maximum backoff provided (%s) must not be negative.", invalid),
+	)
+}
+
+// NewTimeoutError reports that the SBOM of the target could not be generated within the timeout, naming the
+// phase of the generation which was still running.
+func (ef *SbomErrorFactory) NewTimeoutError(
+	target, phase string, timeout time.Duration,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeTimeout,
+		fmt.Errorf("timed out after %s while %s for %s: %w", timeout, phase, target, context.DeadlineExceeded),
+		fmt.Sprintf(
+			"The SBOM of %s could not be generated within %s, the timeout was reached while %s. "+
+				"Please increase the timeout with --timeout.",
+			target, timeout, phase,
+		),
+	)
+}
+
+// NewCanceledError reports that the generation of the SBOM of the target was interrupted, naming the phase
+// of the generation which was still running.
+func (ef *SbomErrorFactory) NewCanceledError(target, phase string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeCanceled,
+		fmt.Errorf("canceled while %s for %s: %w", phase, target, context.Canceled),
+		fmt.Sprintf("The generation of the SBOM of %s was canceled while %s.", target, phase),
+	)
+}
+
+func (ef *SbomErrorFactory) NewInvalidSbomAPIPathError(path string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidSbomAPIPath,
+		fmt.Errorf("invalid sbom api path provided (%s)", path),
+		fmt.Sprintf(
+			"The SBOM API path provided (%s) must start with / and contain the placeholder {org_id}.",
+			path,
+		),
+	)
+}
+
+// NewMissingSbomAPIPathError reports that the mode of the SBOM API has no default endpoint.
+func (ef *SbomErrorFactory) NewMissingSbomAPIPathError(mode string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeMissingSbomAPIPath,
+		fmt.Errorf("no sbom api path provided for the %s mode", mode),
+		fmt.Sprintf(
+			"The %s mode of the SBOM API has no default endpoint, please provide its path with --sbom-api-path.",
+			mode,
+		),
+	)
+}
+
+// NewSbomAPIUnavailableError reports that the requested version of the SBOM API is no longer served.
+func (ef *SbomErrorFactory) NewSbomAPIUnavailableError(
+	err error, version string,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeSbomAPIUnavailable,
+		err,
+		fmt.Sprintf(
+			"Version %s of the Snyk SBOM API is no longer available. "+
+				"Please upgrade the Snyk CLI or select a newer version with --sbom-api-version.",
+			version,
+		),
+	)
+}
+
+// NewSbomJobFailedError reports that the job generating the SBOM on the Snyk API failed.
+func (ef *SbomErrorFactory) NewSbomJobFailedError(jobID, detail string) *containererrors.ContainerExtensionError {
+	userMsg := "The Snyk API failed to generate the SBOM. " +
+		"Should this issue persist, please reach out to customer support."
+	if detail != "" {
+		userMsg = fmt.Sprintf("The Snyk API failed to generate the SBOM: %s.", detail)
+	}
+	return ef.NewError(
+		containererrors.CodeSbomJobFailed,
+		fmt.Errorf("sbom job %q failed: %s", jobID, detail),
+		userMsg,
+	)
+}
+
+// NewSbomJobTimeoutError reports that the job generating the SBOM on the Snyk API did not finish in time.
+func (ef *SbomErrorFactory) NewSbomJobTimeoutError(
+	jobID string, limit time.Duration,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeSbomJobTimeout,
+		fmt.Errorf("sbom job %q did not finish within %s", jobID, limit),
+		fmt.Sprintf(
+			"The Snyk API did not finish generating the SBOM within %s. "+
+				"Should this issue persist, please reach out to customer support.",
+			limit,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewMultipleSbomsFailedError(
+	failed, total int, summary string,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeMultipleSbomsFailed,
+		fmt.Errorf("sbom generation failed for %d of %d images", failed, total),
+		fmt.Sprintf("SBOM generation failed for %d of %d images.\n%s", failed, total, summary),
+	)
+}
+
+func (ef *SbomErrorFactory) NewOutputFileError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeOutputFile,
+		fmt.Errorf("could not write output file %s: %w", path, err),
+		fmt.Sprintf("The SBOM could not be written to the output file (%s): %s", path, err),
+	)
+}
+
+func (ef *SbomErrorFactory) NewOutputFileTemplateError(template string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeOutputFileTemplate,
+		fmt.Errorf("output file %s does not contain a placeholder", template),
+		fmt.Sprintf(
+			"The output file (%s) must contain at least one of the placeholders "+
+				"{name}, {tag} or {index} when generating SBOMs for multiple images.",
+			template,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewSigningKeyError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeSigningKey,
+		fmt.Errorf("could not load signing key %s: %w", path, err),
+		fmt.Sprintf(
+			"The signing key (%s) could not be loaded: %s. "+
+				"Please provide an unencrypted ECDSA or Ed25519 private key in PEM format.",
+			path, err,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewAttestationFormatError(format string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeAttestationFormat,
+		fmt.Errorf("format %s cannot be attested", format),
+		fmt.Sprintf(
+			"Signed attestations can only be created for JSON SBOM formats, but %s was requested. "+
+				"Please choose a JSON format with --format.",
+			format,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewSubjectDigestError(target string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeSubjectDigest,
+		fmt.Errorf("could not determine the digest of %s: %w", target, err),
+		fmt.Sprintf(
+			"The digest of the image (%s) is required to sign the SBOM but could not be determined: %s. "+
+				"Please reference the image by digest, e.g. alpine@sha256:..., "+
+				"or save it as an OCI archive, which records the digest of the image manifest.",
+			target, err,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewAttachLocalImageError(target string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeAttachLocalImage,
+		fmt.Errorf("cannot attach sbom to local image %s", target),
+		fmt.Sprintf(
+			"The SBOM cannot be attached to the local image %s. "+
+				"Please push the image and generate the SBOM for the image in the registry.",
+			target,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewAttachError(target string, err error) *containererrors.ContainerExtensionError {
+	userMsg := fmt.Sprintf("The SBOM could not be attached to the image %s: %s", target, err)
+	if errors.Is(err, registry.ErrUnauthorized) {
+		userMsg = fmt.Sprintf(
+			"The registry denied access to the image %s. "+
+				"Please provide credentials with push access using --username and --password.",
+			target,
+		)
+	}
+	return ef.NewError(containererrors.CodeAttach, fmt.Errorf("could not attach sbom to %s: %w", target, err), userMsg)
+}
+
+func (ef *SbomErrorFactory) NewInvalidVulnerabilitiesModeError(
+	invalid string, validModes []string,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidVulnerabilitiesMode,
+		fmt.Errorf("invalid vulnerabilities mode provided (%s)", invalid),
+		fmt.Sprintf(
+			"The vulnerabilities mode provided (%s) is not one of the available modes. "+
+				"Available modes are: %s",
+			invalid,
+			strings.Join(validModes, ", "),
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewVulnerabilitiesFormatError(format string) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeVulnerabilitiesFormat,
+		fmt.Errorf("vulnerabilities cannot be embedded into format %s", format),
+		fmt.Sprintf(
+			"Vulnerabilities can only be embedded into CycloneDX JSON documents, but %s was requested. "+
+				"Please choose a CycloneDX JSON format with --format or use --vulnerabilities=openvex.",
+			format,
+		),
+	)
+}
+
+func (ef *SbomErrorFactory) NewVulnerabilitiesOfflineError() *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeVulnerabilitiesOffline,
+		fmt.Errorf("vulnerabilities requested offline"),
+		"Vulnerabilities are found by the Snyk API and cannot be added to SBOMs generated with --offline.",
+	)
+}
+
+func (ef *SbomErrorFactory) NewVexWithoutVulnerabilitiesError() *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeVexWithoutVulnerabilities,
+		fmt.Errorf("vex requested without vulnerabilities"),
+		"VEX statements apply to the vulnerabilities of the image. Please choose a mode with --vulnerabilities.",
+	)
+}
+
+func (ef *SbomErrorFactory) NewVexFileError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeVexFile,
+		fmt.Errorf("could not load vex file %s: %w", path, err),
+		fmt.Sprintf("The VEX file (%s) could not be loaded: %s", path, err),
+	)
+}
+
+func (ef *SbomErrorFactory) NewCacheConfigError(err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeCacheConfig,
+		fmt.Errorf("invalid cache configuration: %w", err),
+		fmt.Sprintf("The depgraph cache could not be configured: %s. Use --no-cache to disable it.", err),
+	)
+}
+
+func (ef *SbomErrorFactory) NewPolicyFileError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodePolicyFile,
+		fmt.Errorf("could not load policy file %s: %w", path, err),
+		fmt.Sprintf("The policy file (%s) could not be loaded: %s", path, err),
+	)
+}
+
+func (ef *SbomErrorFactory) NewPolicyViolationError(
+	target string, violations int, report string,
+) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodePolicyViolation,
+		fmt.Errorf("sbom of %s violates the policy %d times", target, violations),
+		fmt.Sprintf("The SBOM of %s violates the policy (%d violations):\n%s", target, violations, report),
+	)
+}
+
+// NewDepGraphWorkflowError reports a failed analysis of the image. Errors the depgraph workflow already
+// explains to the user are returned as they are.
 func (ef *SbomErrorFactory) NewDepGraphWorkflowError(err error) *containererrors.ContainerExtensionError {
+	var xerr *containererrors.ContainerExtensionError
+	if errors.As(err, &xerr) {
+		return xerr
+	}
 	return ef.NewError(
+		containererrors.CodeDepGraphWorkflow,
 		fmt.Errorf("error while invoking depgraph workflow: %w", err),
 		"An error occurred while running the underlying analysis needed to generate the SBOM.",
 	)
@@ -82,6 +404,7 @@ func (ef *SbomErrorFactory) NewDepGraphWorkflowError(err error) *containererrors
 
 func (ef *SbomErrorFactory) NewInternalError(err error) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeInternal,
 		err,
 		"An error occurred while running the underlying analysis which is required to generate the SBOM. "+
 			"Should this issue persist, please reach out to customer support.",
@@ -90,6 +413,7 @@ func (ef *SbomErrorFactory) NewInternalError(err error) *containererrors.Contain
 
 func (ef *SbomErrorFactory) NewRemoteError(err error) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeRemote,
 		err,
 		"An error occurred while generating the SBOM. "+
 			"Should this issue persist, please reach out to customer support.",
@@ -98,6 +422,7 @@ func (ef *SbomErrorFactory) NewRemoteError(err error) *containererrors.Container
 
 func (ef *SbomErrorFactory) NewBadRequestError(err error) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeBadRequest,
 		err,
 		"SBOM generation failed due to bad input arguments. "+
 			"Please make sure you are using the latest version of the Snyk CLI.",
@@ -106,14 +431,28 @@ func (ef *SbomErrorFactory) NewBadRequestError(err error) *containererrors.Conta
 
 func (ef *SbomErrorFactory) NewUnauthorizedError(err error) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeUnauthorized,
 		err,
 		"Snyk failed to authenticate you based on your API token. "+
 			"Please ensure that you have authenticated by running `snyk auth`.",
 	)
 }
 
+func (ef *SbomErrorFactory) NewRateLimitedError(
+	err error, retryAfter time.Duration,
+) *containererrors.ContainerExtensionError {
+	userMsg := "Too many requests were sent to the Snyk API and retrying did not succeed. "
+	if retryAfter > 0 {
+		userMsg += fmt.Sprintf("Please try again in %s.", retryAfter.Round(time.Second))
+	} else {
+		userMsg += "Please try again later."
+	}
+	return ef.NewError(containererrors.CodeRateLimited, err, userMsg)
+}
+
 func (ef *SbomErrorFactory) NewForbiddenError(err error, orgID string) *containererrors.ContainerExtensionError {
 	return ef.NewError(
+		containererrors.CodeForbidden,
 		err,
 		fmt.Sprintf(
 			"Your account is not authorized to perform this action. "+
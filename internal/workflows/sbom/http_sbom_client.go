@@ -15,113 +15,245 @@
 package sbom
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/snyk/container-cli/internal/common/constants"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
 	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
 )
 
 // HTTPSbomClientConfig represents the configuration for HTTPSbomClient
 type HTTPSbomClientConfig struct {
-	APIHost    string
-	Client     *http.Client
-	Logger     *zerolog.Logger
-	ErrFactory *sbomerrors.SbomErrorFactory
+	APIHost     string
+	Client      *http.Client
+	Logger      *zerolog.Logger
+	ErrFactory  *sbomerrors.SbomErrorFactory
+	RetryPolicy RetryPolicy
 }
 
 // HTTPSbomClient represents the HTTP client for the SBOM API
 type HTTPSbomClient struct {
-	apiHost    string
-	client     *http.Client
-	logger     *zerolog.Logger
-	errFactory *sbomerrors.SbomErrorFactory
+	apiHost     string
+	client      *http.Client
+	logger      *zerolog.Logger
+	errFactory  *sbomerrors.SbomErrorFactory
+	retryPolicy RetryPolicy
+	sleep       func(ctx context.Context, d time.Duration) error
+	// pollInterval is the wait between two polls of an SBOM job, unless the API asks for another one.
+	pollInterval time.Duration
+	// maxJobWait is how long an SBOM job is polled for before it is given up.
+	maxJobWait time.Duration
+	// deprecationWarning ensures the user is warned about a deprecated API version only once.
+	deprecationWarning sync.Once
+	// stderr receives the warnings about deprecated API versions, which users need to see.
+	stderr io.Writer
 }
 
 // NewHTTPSbomClient creates a new HTTPSbomClient value
 func NewHTTPSbomClient(conf HTTPSbomClientConfig) *HTTPSbomClient {
 	return &HTTPSbomClient{
-		apiHost:    conf.APIHost,
-		client:     conf.Client,
-		logger:     conf.Logger,
-		errFactory: conf.ErrFactory,
+		apiHost:      conf.APIHost,
+		client:       conf.Client,
+		logger:       conf.Logger,
+		errFactory:   conf.ErrFactory,
+		retryPolicy:  conf.RetryPolicy,
+		sleep:        sleepContext,
+		pollInterval: defaultPollInterval,
+		maxJobWait:   defaultMaxJobWait,
+		stderr:       os.Stderr,
 	}
 }
 
-// GetSbomForDepGraph retrieves the SBOM for a depgraph
+// GetSbomForDepGraph retrieves the SBOM for a depgraph from the API version and endpoint selected by the request.
 func (c *HTTPSbomClient) GetSbomForDepGraph(
 	ctx context.Context,
 	orgID, format, platform string,
 	req *GetSbomForDepGraphRequest,
 ) (*GetSbomForDepGraphResult, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to marshal sbom request: %w", err))
-	}
-
+	api := req.API.withDefaults()
 	urlWithParams := fmt.Sprintf(
-		"%s/hidden/orgs/%s/sbom?version=%s&format=%s",
-		c.apiHost,
-		orgID,
-		"2022-03-31~experimental",
+		"%s?version=%s&format=%s",
+		api.url(c.apiHost, orgID),
+		url.QueryEscape(api.Version),
 		url.QueryEscape(format),
 	)
 	if platform != "" {
 		urlWithParams += fmt.Sprintf("&platform=%s", url.QueryEscape(platform))
 	}
 
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		urlWithParams,
-		bytes.NewReader(body),
-	)
+	var res *http.Response
+	var err error
+	if api.Async {
+		res, err = c.runSbomJob(ctx, urlWithParams, orgID, req)
+	} else {
+		body := &requestBody{value: req, gzip: true}
+		res, err = c.send(ctx, http.MethodPost, urlWithParams, body, orgID, actionConvert)
+	}
 	if err != nil {
-		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to create http request: %w", err))
+		return nil, err
 	}
-	httpReq.Header.Add(constants.HeaderContentType, constants.ContentTypeJSON)
+	defer res.Body.Close()
 
-	res, err := c.client.Do(httpReq)
+	result := &GetSbomForDepGraphResult{MIMEType: res.Header.Get(constants.HeaderContentType)}
+	if req.Output != nil {
+		w, err := req.Output.Writer(result.MIMEType)
+		if err != nil {
+			return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to open the sbom output: %w", err))
+		}
+		if _, err := io.Copy(w, res.Body); err != nil {
+			return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to stream response body: %w", err))
+		}
+		return result, nil
+	}
+
+	if result.Doc, err = io.ReadAll(res.Body); err != nil {
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to read response body: %w", err))
+	}
+	return result, nil
+}
+
+// TestDepGraph tests the packages of a depgraph for vulnerabilities.
+func (c *HTTPSbomClient) TestDepGraph(
+	ctx context.Context,
+	orgID string,
+	depGraph json.RawMessage,
+) (*TestDepGraphResult, error) {
+	body := &requestBody{value: struct {
+		DepGraph json.RawMessage `json:"depGraph"`
+	}{depGraph}}
+
+	urlWithParams := fmt.Sprintf("%s/v1/test/dep-graph?org=%s", c.apiHost, url.QueryEscape(orgID))
+	res, err := c.send(ctx, http.MethodPost, urlWithParams, body, orgID, "could not test the depgraph")
 	if err != nil {
-		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to perform http call: %w", err))
+		return nil, err
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		_, err = io.Copy(io.Discard, res.Body)
+	// the issues are nested in the result of the test, next to the summary of the test.
+	var response struct {
+		Result *TestDepGraphResult `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to decode test response: %w", err))
+	}
+	if response.Result == nil || response.Result.Issues == nil || response.Result.IssuesData == nil {
+		// an image without vulnerabilities still has empty issues, reporting none would hide a broken response.
+		return nil, c.errFactory.NewInternalError(
+			errors.New("test response does not contain result.issues and result.issuesData"))
+	}
+	return response.Result, nil
+}
+
+// send sends the request, retrying it as long as the retry policy of the context, or else of the client, allows.
+// Unsuccessful responses are turned into errors, which describe the failed action.
+func (c *HTTPSbomClient) send(
+	ctx context.Context,
+	method, url string,
+	body *requestBody,
+	orgID, action string,
+) (*http.Response, error) {
+	policy := retryPolicyOf(ctx, c.retryPolicy)
+	var res *http.Response
+	var err error
+	for attempt := 1; ; attempt++ {
+		res, err = c.do(ctx, method, url, body)
+		if err != nil {
+			return nil, err
+		}
+		if !isRetryableStatus(res.StatusCode) || attempt >= policy.MaxAttempts {
+			break
+		}
+
+		wait := policy.backoff(attempt, res)
+		c.discardBody(res)
+		c.logger.Warn().Msgf("request failed (status: %s), retrying in %s (attempt %d of %d)",
+			res.Status, wait, attempt+1, policy.MaxAttempts)
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to wait before retrying: %w", err))
+		}
+	}
+
+	c.checkDeprecation(res)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		errBody, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
 		if err != nil {
-			c.logger.Error().Err(err).Msg("failed to discard the body for unsuccessful response")
+			c.logger.Error().Err(err).Msg("failed to read the body for unsuccessful response")
 		}
-		return nil, c.errorFromResponse(res, orgID)
+		return nil, c.errorFromResponse(res, errBody, orgID, action)
 	}
+	return res, nil
+}
 
-	doc, err := io.ReadAll(res.Body)
+// do performs a single attempt of a request. Requests without a body are sent if body is nil.
+func (c *HTTPSbomClient) do(ctx context.Context, method, url string, body *requestBody) (*http.Response, error) {
+	reqBody := io.ReadCloser(http.NoBody)
+	if body != nil {
+		reqBody = body.open()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to read response body: %w", err))
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to create http request: %w", err))
 	}
+	if body != nil {
+		httpReq.Header.Add(constants.HeaderContentType, body.contentType())
+		if body.gzip {
+			httpReq.Header.Add(constants.HeaderContentEncoding, "gzip")
+		}
+	}
+
+	res, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to perform http call: %w", err))
+	}
+	return res, nil
+}
 
-	return &GetSbomForDepGraphResult{
-		Doc:      doc,
-		MIMEType: res.Header.Get(constants.HeaderContentType),
-	}, nil
+// discardBody drains and closes the body of a response that is not used, so the connection can be reused.
+func (c *HTTPSbomClient) discardBody(res *http.Response) {
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		c.logger.Error().Err(err).Msg("failed to discard the body for unsuccessful response")
+	}
+	res.Body.Close()
 }
 
-func (c *HTTPSbomClient) errorFromResponse(res *http.Response, orgID string) error {
-	err := fmt.Errorf("could not convert to SBOM (status: %s)", res.Status)
+func (c *HTTPSbomClient) errorFromResponse(res *http.Response, body []byte, orgID, action string) error {
+	apiErr := parseAPIError(res, body)
+	err := fmt.Errorf(
+		"%s (status: %s, code: %q, request id: %q): %s",
+		action, res.Status, apiErr.code, apiErr.requestID, apiErr.detail,
+	)
+
+	var xerr *containererrors.ContainerExtensionError
 	switch res.StatusCode {
 	case http.StatusBadRequest:
-		return c.errFactory.NewBadRequestError(err)
+		xerr = c.errFactory.NewBadRequestError(err)
 	case http.StatusUnauthorized:
-		return c.errFactory.NewUnauthorizedError(err)
+		xerr = c.errFactory.NewUnauthorizedError(err)
 	case http.StatusForbidden:
-		return c.errFactory.NewForbiddenError(err, orgID)
+		xerr = c.errFactory.NewForbiddenError(err, orgID)
+	case http.StatusGone:
+		// versions of the API which are no longer served are gone.
+		if version := requestedVersion(res); version != "" {
+			xerr = c.errFactory.NewSbomAPIUnavailableError(err, version)
+		} else {
+			xerr = c.errFactory.NewRemoteError(err)
+		}
+	case http.StatusTooManyRequests:
+		retryAfter, _ := parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		xerr = c.errFactory.NewRateLimitedError(err, retryAfter)
 	default:
-		return c.errFactory.NewRemoteError(err)
+		xerr = c.errFactory.NewRemoteError(err)
 	}
+	return xerr.WithAPIError(apiErr.code, apiErr.requestID, apiErr.detail)
 }
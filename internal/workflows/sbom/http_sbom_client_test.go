@@ -15,8 +15,11 @@
 package sbom
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,9 +27,11 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	zlog "github.com/rs/zerolog/log"
 	"github.com/snyk/container-cli/internal/common/constants"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
 	sbomconstants "github.com/snyk/container-cli/internal/workflows/sbom/constants"
 	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
 	"github.com/stretchr/testify/require"
@@ -79,7 +84,10 @@ func Test_GetSbomForDepGraph_GivenNoError_ShouldReturnSbom(t *testing.T) {
 					fmt.Sprintf("/hidden/orgs/%s/sbom?version=%s&format=%s", orgID, version, url.QueryEscape(tc.format)),
 				)
 
-				body, handlerErr := io.ReadAll(r.Body)
+				require.Equal(t, "gzip", r.Header.Get(constants.HeaderContentEncoding))
+				gz, handlerErr := gzip.NewReader(r.Body)
+				require.NoError(t, handlerErr)
+				body, handlerErr := io.ReadAll(gz)
 				require.NoError(t, handlerErr)
 
 				require.Equal(t, expectedReqBody, body)
@@ -108,3 +116,316 @@ func Test_GetSbomForDepGraph_GivenNoError_ShouldReturnSbom(t *testing.T) {
 		})
 	}
 }
+
+// bufferSink collects the streamed SBOM document.
+type bufferSink struct {
+	mimeType string
+	bytes.Buffer
+}
+
+func (s *bufferSink) Writer(mimeType string) (io.Writer, error) {
+	s.mimeType = mimeType
+	return &s.Buffer, nil
+}
+
+func Test_GetSbomForDepGraph_GivenOutput_ShouldStreamSbomIntoOutput(t *testing.T) {
+	expectedResBody, err := os.ReadFile("testdata/sbom_result_doc.json")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add(constants.HeaderContentType, "application/vnd.cyclonedx+json")
+		_, handlerErr := w.Write(expectedResBody)
+		require.NoError(t, handlerErr)
+	}))
+	defer server.Close()
+
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:    server.URL,
+		Client:     http.DefaultClient,
+		Logger:     &zlog.Logger,
+		ErrFactory: sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+	})
+
+	sink := &bufferSink{}
+	res, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.4+json", "",
+		&GetSbomForDepGraphRequest{Subject: Subject{Name: "alpine"}, Output: sink})
+	require.NoError(t, err)
+
+	require.Equal(t, &GetSbomForDepGraphResult{MIMEType: "application/vnd.cyclonedx+json"}, res)
+	require.Equal(t, "application/vnd.cyclonedx+json", sink.mimeType)
+	require.Equal(t, expectedResBody, sink.Bytes())
+}
+
+func Test_GetSbomForDepGraph_GivenRetryableResponses_ShouldRetryWithBackoff(t *testing.T) {
+	type test struct {
+		statusCodes    []int
+		retryAfter     string
+		expectedSleeps []time.Duration
+		expectedError  string
+	}
+
+	tests := map[string]test{
+		"transient failures followed by success": {
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedSleeps: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		"rate limited until attempts are exhausted": {
+			statusCodes:    []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			retryAfter:     "7",
+			expectedSleeps: []time.Duration{7 * time.Second, 7 * time.Second},
+			expectedError: "Too many requests were sent to the Snyk API and retrying did not succeed. " +
+				"Please try again in 7s.",
+		},
+		"retry after exceeding max backoff": {
+			statusCodes:    []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:     "3600",
+			expectedSleeps: []time.Duration{time.Minute},
+		},
+		"non-retryable failure": {
+			statusCodes:   []int{http.StatusBadRequest},
+			expectedError: "SBOM generation failed due to bad input arguments.",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, handlerErr := io.ReadAll(r.Body)
+				require.NoError(t, handlerErr)
+				require.NotEmpty(t, body)
+
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.statusCodes[attempts])
+				attempts++
+			}))
+			defer server.Close()
+
+			client := NewHTTPSbomClient(HTTPSbomClientConfig{
+				APIHost:    server.URL,
+				Client:     http.DefaultClient,
+				Logger:     &zlog.Logger,
+				ErrFactory: sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+				RetryPolicy: RetryPolicy{
+					MaxAttempts:    3,
+					InitialBackoff: 10 * time.Millisecond,
+					MaxBackoff:     time.Minute,
+				},
+			})
+			var sleeps []time.Duration
+			client.sleep = func(_ context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}
+
+			_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.4+json", "",
+				&GetSbomForDepGraphRequest{Subject: Subject{Name: "alpine"}})
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, len(tc.statusCodes), attempts)
+			require.Equal(t, tc.expectedSleeps, sleeps)
+		})
+	}
+}
+
+func Test_GetSbomForDepGraph_GivenRetryPolicyInContext_ShouldPreferItToTheClientPolicy(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:     server.URL,
+		Client:      http.DefaultClient,
+		Logger:      &zlog.Logger,
+		ErrFactory:  sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+		RetryPolicy: RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+	})
+	var sleeps []time.Duration
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	ctx := withRetryPolicy(context.Background(),
+		RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second, MaxBackoff: time.Minute})
+	_, err := client.GetSbomForDepGraph(ctx, orgID, "cyclonedx1.4+json", "", &GetSbomForDepGraphRequest{})
+	require.Error(t, err)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, []time.Duration{time.Second}, sleeps)
+}
+
+func Test_GetSbomForDepGraph_GivenContextCancelledWhileWaiting_ShouldStopRetrying(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:     server.URL,
+		Client:      http.DefaultClient,
+		Logger:      &zlog.Logger,
+		ErrFactory:  sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+		RetryPolicy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.GetSbomForDepGraph(ctx, orgID, "cyclonedx1.4+json", "", &GetSbomForDepGraphRequest{})
+	require.Error(t, err)
+	require.LessOrEqual(t, attempts, 1)
+}
+
+func Test_GetSbomForDepGraph_GivenJSONAPIErrorResponse_ShouldSurfaceErrorDetails(t *testing.T) {
+	type test struct {
+		statusCode        int
+		header            http.Header
+		body              string
+		expectedCode      string
+		expectedRequestID string
+		expectedDetail    string
+		expectedError     string
+	}
+
+	tests := map[string]test{
+		"bad request with detail and request id header": {
+			statusCode: http.StatusBadRequest,
+			header:     http.Header{"Snyk-Request-Id": []string{"7d2a9c3e"}},
+			body: `{"jsonapi":{"version":"1.0"},"errors":[{"status":"400","code":"SNYK-SBOM-0004",` +
+				`"title":"Bad Request","detail":"dep-graph too large"}]}`,
+			expectedCode:      "SNYK-SBOM-0004",
+			expectedRequestID: "7d2a9c3e",
+			expectedDetail:    "dep-graph too large",
+			expectedError: "SBOM generation failed due to bad input arguments. " +
+				"Please make sure you are using the latest version of the Snyk CLI.\n" +
+				"Details: dep-graph too large\nError code: SNYK-SBOM-0004\nRequest ID: 7d2a9c3e",
+		},
+		"multiple errors with request id in meta": {
+			statusCode: http.StatusInternalServerError,
+			body: `{"errors":[{"title":"Internal Server Error","meta":{"request_id":"abc"}},` +
+				`{"detail":"conversion failed"},{"detail":"conversion failed"}]}`,
+			expectedRequestID: "abc",
+			expectedDetail:    "Internal Server Error; conversion failed",
+		},
+		"body is not a JSON:API document": {
+			statusCode: http.StatusBadGateway,
+			body:       "<html>Bad Gateway</html>",
+			expectedError: "An error occurred while generating the SBOM. " +
+				"Should this issue persist, please reach out to customer support.",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tc.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tc.statusCode)
+				_, handlerErr := w.Write([]byte(tc.body))
+				require.NoError(t, handlerErr)
+			}))
+			defer server.Close()
+
+			client := NewHTTPSbomClient(HTTPSbomClientConfig{
+				APIHost:    server.URL,
+				Client:     http.DefaultClient,
+				Logger:     &zlog.Logger,
+				ErrFactory: sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+			})
+
+			_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.4+json", "",
+				&GetSbomForDepGraphRequest{})
+
+			var xerr *containererrors.ContainerExtensionError
+			require.True(t, errors.As(err, &xerr))
+			require.Equal(t, tc.expectedCode, xerr.APIErrorCode())
+			require.Equal(t, tc.expectedRequestID, xerr.RequestID())
+			require.Equal(t, tc.expectedDetail, xerr.Detail())
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func Test_TestDepGraph_GivenNoError_ShouldReturnIssues(t *testing.T) {
+	depGraphBytes, err := os.ReadFile("testdata/sbom_request_depgraph.json")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, fmt.Sprintf("/v1/test/dep-graph?org=%s", orgID), r.URL.String())
+
+		var req struct {
+			DepGraph json.RawMessage `json:"depGraph"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.JSONEq(t, string(depGraphBytes), string(req.DepGraph))
+
+		w.Header().Add(constants.HeaderContentType, constants.ContentTypeJSON)
+		_, handlerErr := w.Write([]byte(`{"result":{"affectedPkgs":{},"issues":[{"pkgName":"testpkg",` +
+			`"pkgVersion":"10.10","issueId":"SNYK-1","fixInfo":{"nearestFixedInVersion":"10.11"}}],` +
+			`"issuesData":{"SNYK-1":{"id":"SNYK-1","type":"vuln","severity":"high",` +
+			`"identifiers":{"CVE":["CVE-2023-1234"],"CWE":[]}}}},"meta":{"isPrivate":true}}`))
+		require.NoError(t, handlerErr)
+	}))
+	defer server.Close()
+
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:    server.URL,
+		Client:     http.DefaultClient,
+		Logger:     &zlog.Logger,
+		ErrFactory: sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+	})
+
+	res, err := client.TestDepGraph(context.Background(), orgID, depGraphBytes)
+	require.NoError(t, err)
+
+	require.Equal(t, &TestDepGraphResult{
+		Issues: []Issue{{PkgName: "testpkg", PkgVersion: "10.10", IssueID: "SNYK-1",
+			FixInfo: FixInfo{NearestFixedInVersion: "10.11"}}},
+		IssuesData: map[string]IssueData{"SNYK-1": {ID: "SNYK-1", Type: "vuln", Severity: "high",
+			Identifiers: Identifiers{CVE: []string{"CVE-2023-1234"}, CWE: []string{}}}},
+	}, res)
+}
+
+func Test_TestDepGraph_GivenResponseWithoutResult_ShouldReturnError(t *testing.T) {
+	tests := map[string]string{
+		"issues at the top level": `{"issues":[],"issuesData":{}}`,
+		"missing issues data":     `{"result":{"issues":[]}}`,
+		"missing issues":          `{"result":{"issuesData":{}}}`,
+	}
+
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add(constants.HeaderContentType, constants.ContentTypeJSON)
+				_, _ = w.Write([]byte(body))
+			}))
+			defer server.Close()
+
+			client := NewHTTPSbomClient(HTTPSbomClientConfig{
+				APIHost:    server.URL,
+				Client:     http.DefaultClient,
+				Logger:     &zlog.Logger,
+				ErrFactory: sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+			})
+
+			_, err := client.TestDepGraph(context.Background(), orgID, json.RawMessage(`{}`))
+			requireErrorCode(t, containererrors.CodeInternal, err)
+			require.ErrorContains(t, errors.Unwrap(err),
+				"test response does not contain result.issues and result.issuesData")
+		})
+	}
+}
@@ -16,6 +16,10 @@ package sbom
 
 import (
 	"context"
+	"encoding/json"
+
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/registry"
 )
 
 //go:generate mockgen -source=./interfaces.go -destination=./interfaces_mocks.go -package=sbom
@@ -30,3 +34,19 @@ type SbomClient interface {
 		req *GetSbomForDepGraphRequest,
 	) (*GetSbomForDepGraphResult, error)
 }
+
+// VulnerabilityClient provides vulnerability testing operations
+type VulnerabilityClient interface {
+	TestDepGraph(ctx context.Context, orgID string, depGraph json.RawMessage) (*TestDepGraphResult, error)
+}
+
+// RegistryClient provides container registry operations
+type RegistryClient interface {
+	Resolve(ctx context.Context, imageRef, platform string, creds registry.Credentials) (*registry.ResolvedImage, error)
+	PushReferrer(
+		ctx context.Context,
+		imageRef, platform string,
+		creds registry.Credentials,
+		artifact registry.Artifact,
+	) (image.Descriptor, error)
+}
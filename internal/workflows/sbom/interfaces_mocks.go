@@ -6,9 +6,12 @@ package sbom
 
 import (
 	context "context"
+	json "encoding/json"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	image "github.com/snyk/container-cli/internal/common/image"
+	registry "github.com/snyk/container-cli/internal/common/registry"
 )
 
 // MockSbomClient is a mock of SbomClient interface.
@@ -35,7 +38,7 @@ func (m *MockSbomClient) EXPECT() *MockSbomClientMockRecorder {
 }
 
 // GetSbomForDepGraph mocks base method.
-	func (m *MockSbomClient) GetSbomForDepGraph(ctx context.Context, orgID string, format string, platform string, req *GetSbomForDepGraphRequest) (*GetSbomForDepGraphResult, error) {
+func (m *MockSbomClient) GetSbomForDepGraph(ctx context.Context, orgID, format, platform string, req *GetSbomForDepGraphRequest) (*GetSbomForDepGraphResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSbomForDepGraph", ctx, orgID, format, platform, req)
 	ret0, _ := ret[0].(*GetSbomForDepGraphResult)
@@ -44,7 +47,98 @@ func (m *MockSbomClient) EXPECT() *MockSbomClientMockRecorder {
 }
 
 // GetSbomForDepGraph indicates an expected call of GetSbomForDepGraph.
-func (mr *MockSbomClientMockRecorder) GetSbomForDepGraph(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+func (mr *MockSbomClientMockRecorder) GetSbomForDepGraph(ctx, orgID, format, platform, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSbomForDepGraph", reflect.TypeOf((*MockSbomClient)(nil).GetSbomForDepGraph), arg0, arg1, arg2, arg3, arg4)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSbomForDepGraph", reflect.TypeOf((*MockSbomClient)(nil).GetSbomForDepGraph), ctx, orgID, format, platform, req)
+}
+
+// MockVulnerabilityClient is a mock of VulnerabilityClient interface.
+type MockVulnerabilityClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockVulnerabilityClientMockRecorder
+}
+
+// MockVulnerabilityClientMockRecorder is the mock recorder for MockVulnerabilityClient.
+type MockVulnerabilityClientMockRecorder struct {
+	mock *MockVulnerabilityClient
+}
+
+// NewMockVulnerabilityClient creates a new mock instance.
+func NewMockVulnerabilityClient(ctrl *gomock.Controller) *MockVulnerabilityClient {
+	mock := &MockVulnerabilityClient{ctrl: ctrl}
+	mock.recorder = &MockVulnerabilityClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVulnerabilityClient) EXPECT() *MockVulnerabilityClientMockRecorder {
+	return m.recorder
+}
+
+// TestDepGraph mocks base method.
+func (m *MockVulnerabilityClient) TestDepGraph(ctx context.Context, orgID string, depGraph json.RawMessage) (*TestDepGraphResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TestDepGraph", ctx, orgID, depGraph)
+	ret0, _ := ret[0].(*TestDepGraphResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TestDepGraph indicates an expected call of TestDepGraph.
+func (mr *MockVulnerabilityClientMockRecorder) TestDepGraph(ctx, orgID, depGraph interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestDepGraph", reflect.TypeOf((*MockVulnerabilityClient)(nil).TestDepGraph), ctx, orgID, depGraph)
+}
+
+// MockRegistryClient is a mock of RegistryClient interface.
+type MockRegistryClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockRegistryClientMockRecorder
+}
+
+// MockRegistryClientMockRecorder is the mock recorder for MockRegistryClient.
+type MockRegistryClientMockRecorder struct {
+	mock *MockRegistryClient
+}
+
+// NewMockRegistryClient creates a new mock instance.
+func NewMockRegistryClient(ctrl *gomock.Controller) *MockRegistryClient {
+	mock := &MockRegistryClient{ctrl: ctrl}
+	mock.recorder = &MockRegistryClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRegistryClient) EXPECT() *MockRegistryClientMockRecorder {
+	return m.recorder
+}
+
+// PushReferrer mocks base method.
+func (m *MockRegistryClient) PushReferrer(ctx context.Context, imageRef, platform string, creds registry.Credentials, artifact registry.Artifact) (image.Descriptor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushReferrer", ctx, imageRef, platform, creds, artifact)
+	ret0, _ := ret[0].(image.Descriptor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PushReferrer indicates an expected call of PushReferrer.
+func (mr *MockRegistryClientMockRecorder) PushReferrer(ctx, imageRef, platform, creds, artifact interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushReferrer", reflect.TypeOf((*MockRegistryClient)(nil).PushReferrer), ctx, imageRef, platform, creds, artifact)
+}
+
+// Resolve mocks base method.
+func (m *MockRegistryClient) Resolve(ctx context.Context, imageRef, platform string, creds registry.Credentials) (*registry.ResolvedImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, imageRef, platform, creds)
+	ret0, _ := ret[0].(*registry.ResolvedImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockRegistryClientMockRecorder) Resolve(ctx, imageRef, platform, creds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockRegistryClient)(nil).Resolve), ctx, imageRef, platform, creds)
 }
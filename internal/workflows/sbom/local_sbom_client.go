@@ -0,0 +1,233 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
+)
+
+const (
+	mimeTypeCycloneDXJSON = "application/vnd.cyclonedx+json"
+	mimeTypeCycloneDXXML  = "application/vnd.cyclonedx+xml"
+	mimeTypeSPDXJSON      = "application/spdx+json"
+
+	toolVendor = "Snyk"
+	toolName   = "Snyk Container"
+)
+
+// LocalSbomClientConfig represents the configuration for LocalSbomClient
+type LocalSbomClientConfig struct {
+	// Clock returns the time recorded as the creation time of the documents, defaults to time.Now.
+	Clock func() time.Time
+	// Rand is the source of randomness for serial numbers and namespaces, defaults to crypto/rand.
+	Rand       io.Reader
+	ErrFactory *sbomerrors.SbomErrorFactory
+}
+
+// LocalSbomClient converts depgraphs into SBOM documents without calling the Snyk API, which allows
+// generating SBOMs on machines without network access.
+type LocalSbomClient struct {
+	clock      func() time.Time
+	rand       io.Reader
+	errFactory *sbomerrors.SbomErrorFactory
+}
+
+// NewLocalSbomClient creates a new LocalSbomClient value
+func NewLocalSbomClient(conf LocalSbomClientConfig) *LocalSbomClient {
+	c := &LocalSbomClient{
+		clock:      conf.Clock,
+		rand:       conf.Rand,
+		errFactory: conf.ErrFactory,
+	}
+	if c.clock == nil {
+		c.clock = time.Now
+	}
+	if c.rand == nil {
+		c.rand = rand.Reader
+	}
+	return c
+}
+
+// GetSbomForDepGraph renders the SBOM for a depgraph in the given format
+func (c *LocalSbomClient) GetSbomForDepGraph(
+	_ context.Context,
+	_, format, _ string,
+	req *GetSbomForDepGraphRequest,
+) (*GetSbomForDepGraphResult, error) {
+	doc, err := newSbomDocument(req)
+	if err != nil {
+		return nil, c.errFactory.NewDepGraphWorkflowError(err)
+	}
+
+	id, err := newUUID(c.rand)
+	if err != nil {
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to generate document id: %w", err))
+	}
+	created := c.clock().UTC().Truncate(time.Second)
+
+	var result *GetSbomForDepGraphResult
+	switch spec, encoding, _ := strings.Cut(format, "+"); {
+	case strings.HasPrefix(spec, "cyclonedx") && encoding == "json":
+		result, err = renderCycloneDXJSON(doc, strings.TrimPrefix(spec, "cyclonedx"), id, created)
+	case strings.HasPrefix(spec, "cyclonedx") && encoding == "xml":
+		result, err = renderCycloneDXXML(doc, strings.TrimPrefix(spec, "cyclonedx"), id, created)
+	case spec == "spdx2.3" && encoding == "json":
+		result, err = renderSPDXJSON(doc, id, created)
+	default:
+		err = fmt.Errorf("format %s is not supported", format)
+	}
+	if err != nil {
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to render sbom: %w", err))
+	}
+	return result, nil
+}
+
+// sbomComponent is a package listed in an SBOM document, identified by a document-unique reference.
+type sbomComponent struct {
+	ref     string
+	name    string
+	version string
+	purl    string
+}
+
+type sbomDependency struct {
+	ref       string
+	dependsOn []string
+}
+
+// sbomDocument is the format independent content of an SBOM: the image it describes, the packages
+// found in the image and the dependencies between them.
+type sbomDocument struct {
+	subject      sbomComponent
+	components   []sbomComponent
+	dependencies []sbomDependency
+}
+
+// newSbomDocument merges the depgraphs of an image into a single document. References follow the
+// scheme of the Snyk API ("<n>-<name>@<version>"), packages found in several depgraphs are listed once. The
+// root packages of the depgraphs stand for the image and are replaced by the subject.
+func newSbomDocument(req *GetSbomForDepGraphRequest) (*sbomDocument, error) {
+	doc := &sbomDocument{
+		subject: sbomComponent{
+			ref:     componentRef(1, req.Subject.Name, req.Subject.Version),
+			name:    req.Subject.Name,
+			version: req.Subject.Version,
+			purl:    dockerPURL(req.Subject.Name, req.Subject.Version),
+		},
+	}
+
+	refs := make(map[string]string)
+	edges := map[string][]string{doc.subject.ref: nil}
+	order := []string{doc.subject.ref}
+	addEdge := func(from, to string) {
+		if _, ok := edges[from]; !ok {
+			edges[from] = nil
+			order = append(order, from)
+		}
+		if to != "" && !slices.Contains(edges[from], to) {
+			edges[from] = append(edges[from], to)
+		}
+	}
+
+	for i, raw := range req.DepGraphs {
+		var g depgraph.DepGraph
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return nil, fmt.Errorf("depgraph %d is not valid: %w", i, err)
+		}
+		if err := g.Validate(); err != nil {
+			return nil, fmt.Errorf("depgraph %d is not valid: %w", i, err)
+		}
+
+		pkgIDs := make(map[string]string, len(g.Graph.Nodes))
+		for _, node := range g.Graph.Nodes {
+			pkgIDs[node.NodeID] = node.PkgID
+		}
+		// the root package of each depgraph is the image itself, which is the subject of the document.
+		rootPkgID := pkgIDs[g.Graph.RootNodeID]
+		ref := func(pkgID string) string {
+			if pkgID == rootPkgID {
+				return doc.subject.ref
+			}
+			return refs[pkgID]
+		}
+
+		for _, pkg := range g.Pkgs {
+			if _, ok := refs[pkg.ID]; ok || pkg.ID == rootPkgID {
+				continue
+			}
+			// the subject is the first component, so components are numbered from 2.
+			ref := componentRef(len(doc.components)+2, pkg.Info.Name, pkg.Info.Version)
+			refs[pkg.ID] = ref
+			doc.components = append(doc.components, sbomComponent{
+				ref:     ref,
+				name:    pkg.Info.Name,
+				version: pkg.Info.Version,
+				purl:    pkg.Info.PURL,
+			})
+		}
+
+		for _, node := range g.Graph.Nodes {
+			from := ref(node.PkgID)
+			addEdge(from, "")
+			for _, dep := range node.Deps {
+				addEdge(from, ref(pkgIDs[dep.NodeID]))
+			}
+		}
+	}
+
+	for _, ref := range order {
+		doc.dependencies = append(doc.dependencies, sbomDependency{ref: ref, dependsOn: edges[ref]})
+	}
+	return doc, nil
+}
+
+func componentRef(n int, name, version string) string {
+	if version == "" {
+		return fmt.Sprintf("%d-%s", n, name)
+	}
+	return fmt.Sprintf("%d-%s@%s", n, name, version)
+}
+
+func dockerPURL(name, version string) string {
+	if name == "" {
+		return ""
+	}
+	purl := "pkg:docker/" + name
+	if version != "" {
+		purl += "@" + version
+	}
+	return purl
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID(r io.Reader) (string, error) {
+	var b [16]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,161 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	sbomconstants "github.com/snyk/container-cli/internal/workflows/sbom/constants"
+	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	update   = flag.Bool("update", false, "update the golden files of the local sbom client")
+	testTime = time.Date(2023, 8, 25, 12, 34, 45, 0, time.UTC)
+)
+
+func Test_LocalGetSbomForDepGraph_GivenValidFormat_ShouldMatchGoldenFile(t *testing.T) {
+	expectedMIMETypes := map[string]string{
+		"cyclonedx1.4+json": "application/vnd.cyclonedx+json",
+		"cyclonedx1.4+xml":  "application/vnd.cyclonedx+xml",
+		"cyclonedx1.5+json": "application/vnd.cyclonedx+json",
+		"cyclonedx1.5+xml":  "application/vnd.cyclonedx+xml",
+		"cyclonedx1.6+json": "application/vnd.cyclonedx+json",
+		"cyclonedx1.6+xml":  "application/vnd.cyclonedx+xml",
+		"spdx2.3+json":      "application/spdx+json",
+	}
+
+	for _, format := range sbomconstants.SbomValidFormats {
+		t.Run(format, func(t *testing.T) {
+			res, err := newTestLocalSbomClient().GetSbomForDepGraph(
+				context.Background(), "", format, "", newLocalSbomRequest(t))
+			require.NoError(t, err)
+
+			require.Equal(t, expectedMIMETypes[format], res.MIMEType)
+
+			golden := "testdata/local_sbom_" + format + ".golden"
+			if *update {
+				require.NoError(t, os.WriteFile(golden, res.Doc, 0o600))
+			}
+			require.Equal(t, string(getSbom(t, golden)), string(res.Doc))
+		})
+	}
+}
+
+func Test_LocalGetSbomForDepGraph_GivenCycloneDXJSON_ShouldHaveShapeOfRemoteSbom(t *testing.T) {
+	type test struct {
+		format, remoteDoc string
+	}
+
+	tests := map[string]test{
+		"CycloneDX 1.4 JSON": {
+			format:    "cyclonedx1.4+json",
+			remoteDoc: "testdata/sbom_result_doc.json",
+		},
+		"CycloneDX 1.5 JSON": {
+			format:    "cyclonedx1.5+json",
+			remoteDoc: "testdata/sbom_result_doc_cyclonedx_15.json",
+		},
+		"CycloneDX 1.6 JSON": {
+			format:    "cyclonedx1.6+json",
+			remoteDoc: "testdata/sbom_result_doc_cyclonedx_16.json",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := newTestLocalSbomClient().GetSbomForDepGraph(
+				context.Background(), "", tc.format, "", newLocalSbomRequest(t))
+			require.NoError(t, err)
+
+			var remote, local any
+			require.NoError(t, json.Unmarshal(getSbom(t, tc.remoteDoc), &remote))
+			require.NoError(t, json.Unmarshal(res.Doc, &local))
+
+			requireSameShape(t, "$", remote, local)
+		})
+	}
+}
+
+func Test_LocalGetSbomForDepGraph_GivenInvalidDepGraph_ShouldReturnDepGraphWorkflowError(t *testing.T) {
+	req := &GetSbomForDepGraphRequest{
+		DepGraphs: []json.RawMessage{json.RawMessage(`{"schemaVersion":"1.2.0"}`)},
+		Subject:   Subject{Name: "alpine", Version: "3.17.0"},
+	}
+
+	_, err := newTestLocalSbomClient().GetSbomForDepGraph(context.Background(), "", "spdx2.3+json", "", req)
+	require.EqualError(t, err, errFactory.NewDepGraphWorkflowError(nil).Error())
+}
+
+func newTestLocalSbomClient() *LocalSbomClient {
+	return NewLocalSbomClient(LocalSbomClientConfig{
+		Clock:      func() time.Time { return testTime },
+		Rand:       bytes.NewReader(make([]byte, 16)),
+		ErrFactory: sbomerrors.NewSbomErrorFactory(&zlog.Logger),
+	})
+}
+
+// newLocalSbomRequest returns a request with the depgraph used for the remote client tests and an
+// application depgraph, which shares a package with the former.
+func newLocalSbomRequest(t *testing.T) *GetSbomForDepGraphRequest {
+	t.Helper()
+
+	b := depgraph.NewBuilder(depgraph.PkgManager{Name: "npm"}, depgraph.PkgInfo{Name: "app", Version: "1.0.0"})
+	b.AddDependency(depgraph.PkgInfo{Name: "testpkg", Version: "10.10"})
+	b.AddDependency(depgraph.PkgInfo{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"})
+	app, err := json.Marshal(b.Build())
+	require.NoError(t, err)
+
+	return &GetSbomForDepGraphRequest{
+		DepGraphs: []json.RawMessage{getSbom(t, "testdata/sbom_request_depgraph.json"), app},
+		Subject:   Subject{Name: "alpine", Version: "3.17.0"},
+	}
+}
+
+// requireSameShape asserts that every field of the remote document is present in the local document
+// with the same JSON type. Tools are described differently, as the SBOM is not generated by a service.
+func requireSameShape(t *testing.T, path string, remote, local any) {
+	t.Helper()
+
+	switch r := remote.(type) {
+	case map[string]any:
+		l, ok := local.(map[string]any)
+		require.True(t, ok, "%s: expected an object, got %T", path, local)
+		for key, value := range r {
+			if key == "tools" {
+				continue
+			}
+			require.Contains(t, l, key, "%s: missing field", path)
+			requireSameShape(t, path+"."+key, value, l[key])
+		}
+	case []any:
+		l, ok := local.([]any)
+		require.True(t, ok, "%s: expected an array, got %T", path, local)
+		if len(r) > 0 && len(l) > 0 {
+			requireSameShape(t, path+"[0]", r[0], l[0])
+		}
+	default:
+		require.IsType(t, remote, local, "%s: unexpected type", path)
+	}
+}
@@ -0,0 +1,183 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+const (
+	cycloneDXSchemaURL    = "http://cyclonedx.org/schema/bom-%s.schema.json"
+	cycloneDXNamespaceURL = "http://cyclonedx.org/schema/bom/%s"
+)
+
+type cdxBOM struct {
+	Schema       string          `json:"$schema"`
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     any          `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+// cdxTool is the tool representation of CycloneDX 1.4, deprecated in favour of cdxTools since 1.5.
+type cdxTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Type    string `json:"type"`
+	Author  string `json:"author,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+type cdxXMLBOM struct {
+	XMLName      xml.Name           `xml:"bom"`
+	Namespace    string             `xml:"xmlns,attr"`
+	SerialNumber string             `xml:"serialNumber,attr"`
+	Version      int                `xml:"version,attr"`
+	Metadata     cdxXMLMetadata     `xml:"metadata"`
+	Components   []cdxXMLComponent  `xml:"components>component"`
+	Dependencies []cdxXMLDependency `xml:"dependencies>dependency"`
+}
+
+type cdxXMLMetadata struct {
+	Timestamp      string            `xml:"timestamp"`
+	Tools          []cdxTool         `xml:"tools>tool,omitempty"`
+	ToolComponents []cdxXMLComponent `xml:"tools>components>component,omitempty"`
+	Component      cdxXMLComponent   `xml:"component"`
+}
+
+type cdxXMLComponent struct {
+	BOMRef  string `xml:"bom-ref,attr,omitempty"`
+	Type    string `xml:"type,attr"`
+	Author  string `xml:"author,omitempty"`
+	Name    string `xml:"name"`
+	Version string `xml:"version,omitempty"`
+	PURL    string `xml:"purl,omitempty"`
+}
+
+type cdxXMLDependency struct {
+	Ref       string             `xml:"ref,attr"`
+	DependsOn []cdxXMLDependency `xml:"dependency"`
+}
+
+func renderCycloneDXJSON(
+	doc *sbomDocument, specVersion, id string, created time.Time,
+) (*GetSbomForDepGraphResult, error) {
+	tool := cdxComponent{Type: "application", Author: toolVendor, Name: toolName}
+	bom := cdxBOM{
+		Schema:       fmt.Sprintf(cycloneDXSchemaURL, specVersion),
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  specVersion,
+		SerialNumber: "urn:uuid:" + id,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: created.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{tool}},
+			Component: cdxComponentFor(doc.subject, "container"),
+		},
+		Components:   []cdxComponent{},
+		Dependencies: []cdxDependency{},
+	}
+	if specVersion == "1.4" {
+		bom.Metadata.Tools = []cdxTool{{Vendor: toolVendor, Name: toolName}}
+	}
+	for _, c := range doc.components {
+		bom.Components = append(bom.Components, cdxComponentFor(c, "library"))
+	}
+	for _, d := range doc.dependencies {
+		dependsOn := append([]string{}, d.dependsOn...)
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: d.ref, DependsOn: dependsOn})
+	}
+
+	out, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &GetSbomForDepGraphResult{Doc: append(out, '\n'), MIMEType: mimeTypeCycloneDXJSON}, nil
+}
+
+func renderCycloneDXXML(
+	doc *sbomDocument, specVersion, id string, created time.Time,
+) (*GetSbomForDepGraphResult, error) {
+	bom := cdxXMLBOM{
+		Namespace:    fmt.Sprintf(cycloneDXNamespaceURL, specVersion),
+		SerialNumber: "urn:uuid:" + id,
+		Version:      1,
+		Metadata: cdxXMLMetadata{
+			Timestamp: created.Format(time.RFC3339),
+			Component: cdxXMLComponent(cdxComponentFor(doc.subject, "container")),
+		},
+	}
+	if specVersion == "1.4" {
+		bom.Metadata.Tools = []cdxTool{{Vendor: toolVendor, Name: toolName}}
+	} else {
+		bom.Metadata.ToolComponents = []cdxXMLComponent{{Type: "application", Author: toolVendor, Name: toolName}}
+	}
+	for _, c := range doc.components {
+		bom.Components = append(bom.Components, cdxXMLComponent(cdxComponentFor(c, "library")))
+	}
+	for _, d := range doc.dependencies {
+		dep := cdxXMLDependency{Ref: d.ref}
+		for _, ref := range d.dependsOn {
+			dep.DependsOn = append(dep.DependsOn, cdxXMLDependency{Ref: ref})
+		}
+		bom.Dependencies = append(bom.Dependencies, dep)
+	}
+
+	out, err := xml.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &GetSbomForDepGraphResult{
+		Doc:      append(append([]byte(xml.Header), out...), '\n'),
+		MIMEType: mimeTypeCycloneDXXML,
+	}, nil
+}
+
+func cdxComponentFor(c sbomComponent, componentType string) cdxComponent {
+	return cdxComponent{
+		BOMRef:  c.ref,
+		Type:    componentType,
+		Name:    c.name,
+		Version: c.version,
+		PURL:    c.purl,
+	}
+}
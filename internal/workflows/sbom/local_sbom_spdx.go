@@ -0,0 +1,135 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+)
+
+const spdxNamespaceURL = "https://snyk.io/spdx/"
+
+// spdxInvalidIDChars matches the characters that are not allowed in SPDX identifiers.
+var spdxInvalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func renderSPDXJSON(doc *sbomDocument, id string, created time.Time) (*GetSbomForDepGraphResult, error) {
+	name := doc.subject.name
+	if doc.subject.version != "" {
+		name += ":" + doc.subject.version
+	}
+
+	subjectID := spdxID("image", doc.subject.ref)
+	spdx := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: spdxNamespaceURL + spdxInvalidIDChars.ReplaceAllString(name, "-") + "-" + id,
+		CreationInfo: spdxCreationInfo{
+			Created:  created.Format(time.RFC3339),
+			Creators: []string{"Organization: " + toolVendor, "Tool: " + toolName},
+		},
+		Packages: []spdxPackage{spdxPackageFor(doc.subject, subjectID, "CONTAINER")},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: subjectID,
+		}},
+	}
+
+	ids := map[string]string{doc.subject.ref: subjectID}
+	for _, c := range doc.components {
+		ids[c.ref] = spdxID("pkg", c.ref)
+		spdx.Packages = append(spdx.Packages, spdxPackageFor(c, ids[c.ref], "LIBRARY"))
+	}
+	for _, d := range doc.dependencies {
+		for _, ref := range d.dependsOn {
+			spdx.Relationships = append(spdx.Relationships, spdxRelationship{
+				SPDXElementID:      ids[d.ref],
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSPDXElement: ids[ref],
+			})
+		}
+	}
+
+	out, err := json.MarshalIndent(spdx, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &GetSbomForDepGraphResult{Doc: append(out, '\n'), MIMEType: mimeTypeSPDXJSON}, nil
+}
+
+func spdxPackageFor(c sbomComponent, id, purpose string) spdxPackage {
+	pkg := spdxPackage{
+		Name:                  c.name,
+		SPDXID:                id,
+		VersionInfo:           c.version,
+		DownloadLocation:      "NOASSERTION",
+		PrimaryPackagePurpose: purpose,
+	}
+	if c.purl != "" {
+		pkg.ExternalRefs = []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  c.purl,
+		}}
+	}
+	return pkg
+}
+
+// spdxID derives a valid SPDX identifier from a component reference. References are unique within a
+// document and start with a unique number, so the derived identifiers are unique as well.
+func spdxID(kind, ref string) string {
+	return "SPDXRef-" + kind + "-" + spdxInvalidIDChars.ReplaceAllString(ref, "-")
+}
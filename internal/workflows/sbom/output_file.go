@@ -0,0 +1,291 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/attestation"
+	"github.com/snyk/container-cli/internal/workflows/sbom/vex"
+)
+
+// imagePlaceholders are the placeholders of the --output-file template which differ between images.
+var imagePlaceholders = []string{"{name}", "{tag}", "{index}"}
+
+// sbomOutput is an SBOM document along with the image it was generated for.
+type sbomOutput struct {
+	target string
+	// index is the 1-based position of the image among the images passed to the workflow.
+	index   int
+	subject Subject
+	result  *GetSbomForDepGraphResult
+	// vex is the OpenVEX document accompanying the SBOM, if any.
+	vex *GetSbomForDepGraphResult
+	// staleVex are the imported VEX statements for the image which no longer match any of its vulnerabilities.
+	staleVex []vex.Statement
+	// path is the file the SBOM was streamed into, if any. The result holds no document then.
+	path string
+}
+
+// extensionForMIMEType returns the conventional file extension of an SBOM document.
+func extensionForMIMEType(mimeType string) string {
+	switch {
+	case mimeType == attestation.MediaTypeEnvelope:
+		return ".dsse.json"
+	case mimeType == MIMETypeOpenVEX:
+		return ".openvex.json"
+	case strings.Contains(mimeType, "cyclonedx+json"):
+		return ".cdx.json"
+	case strings.Contains(mimeType, "spdx+json"):
+		return ".spdx.json"
+	case strings.HasSuffix(mimeType, "+xml") || strings.HasSuffix(mimeType, "/xml"):
+		return ".xml"
+	default:
+		return ".json"
+	}
+}
+
+// hasOutputFilePlaceholder returns true if the template yields a different file per image.
+func hasOutputFilePlaceholder(template string) bool {
+	for _, p := range imagePlaceholders {
+		if strings.Contains(template, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputFilePath expands the --output-file template for the SBOM at the given (1-based) index. If the
+// template neither contains {ext} nor an extension, the extension matching the document is appended.
+func outputFilePath(template string, index int, out sbomOutput) string {
+	ext := extensionForMIMEType(out.result.MIMEType)
+
+	subject := out.subject
+	if subject.Name == "" {
+		parsed, err := parseSubject(out.target)
+		if err != nil {
+			parsed = Subject{Name: out.target}
+		}
+		subject = parsed
+	}
+
+	name, tag := subject.Name, subject.Version
+	if tag == "" {
+		tag = "latest"
+	}
+
+	path := strings.NewReplacer(
+		"{name}", sanitizeFileName(name),
+		"{tag}", sanitizeFileName(tag),
+		"{index}", strconv.Itoa(index),
+		"{ext}", ext,
+	).Replace(template)
+
+	// only the template decides whether an extension was given, image names such as "app.tar" don't.
+	literal := strings.NewReplacer("{name}", "", "{tag}", "", "{index}", "").Replace(template)
+	if !strings.Contains(template, "{ext}") && filepath.Ext(literal) == "" {
+		path += ext
+	}
+	return path
+}
+
+// vexFilePath returns the path of the VEX document accompanying the SBOM written to sbomPath.
+func vexFilePath(sbomPath string, out sbomOutput) string {
+	ext := extensionForMIMEType(out.result.MIMEType)
+	if !strings.HasSuffix(sbomPath, ext) {
+		ext = filepath.Ext(sbomPath)
+	}
+	return strings.TrimSuffix(sbomPath, ext) + extensionForMIMEType(out.vex.MIMEType)
+}
+
+// sanitizeFileName replaces the characters of image references which are not safe in file names.
+func sanitizeFileName(s string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "\\", "_", "@", "_").Replace(s)
+}
+
+// atomicFile is written via a temporary file in the same directory, which is renamed once completely written,
+// so readers never observe a partially written document.
+type atomicFile struct {
+	path string
+	tmp  *os.File
+}
+
+func createAtomicFile(path string) (*atomicFile, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := createTempFile(dir, "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{path: path, tmp: tmp}, nil
+}
+
+// createTempFile creates a new file in dir whose name starts with the prefix. Unlike os.CreateTemp, which restricts
+// the file to its owner, the file gets the permissions os.Create gives files, 0666 less the umask, as it replaces
+// a document users expect to be created that way.
+func createTempFile(dir, prefix string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+	return nil, &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, prefix+"*"), Err: fs.ErrExist}
+}
+
+func (f *atomicFile) Write(p []byte) (int, error) {
+	return f.tmp.Write(p)
+}
+
+// commit moves the completely written file into place. The file is removed if that fails.
+func (f *atomicFile) commit() (err error) {
+	defer func() {
+		if err != nil {
+			f.abort()
+		}
+	}()
+
+	if err = f.tmp.Sync(); err != nil {
+		return err
+	}
+	if err = f.tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.tmp.Name(), f.path)
+}
+
+// abort removes the partially written file.
+func (f *atomicFile) abort() {
+	_ = f.tmp.Close()
+	_ = os.Remove(f.tmp.Name())
+}
+
+// writeFileAtomic writes the file in one go, see atomicFile.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := createAtomicFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
+
+// fileSink streams the SBOM document of a single image into the file the --output-file template expands to.
+// The path is only known once the media type of the document is.
+type fileSink struct {
+	template string
+	target   string
+	subject  Subject
+
+	path string
+	file *atomicFile
+	// err is the first error writing the file, as opposed to errors receiving the document.
+	err error
+}
+
+// Writer implements DocumentSink.
+func (s *fileSink) Writer(mimeType string) (io.Writer, error) {
+	s.path = outputFilePath(s.template, 1, sbomOutput{
+		target:  s.target,
+		subject: s.subject,
+		result:  &GetSbomForDepGraphResult{MIMEType: mimeType},
+	})
+	if s.file, s.err = createAtomicFile(s.path); s.err != nil {
+		return nil, s.err
+	}
+	return s, nil
+}
+
+func (s *fileSink) Write(p []byte) (int, error) {
+	n, err := s.file.Write(p)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// finish moves the written document into place. Clients which don't stream return the document in the
+// result instead, which is written now.
+func (s *fileSink) finish(result *GetSbomForDepGraphResult) error {
+	if s.file == nil {
+		if _, err := s.Writer(result.MIMEType); err != nil {
+			return err
+		}
+		if _, err := s.Write(result.Doc); err != nil {
+			s.file.abort()
+			return err
+		}
+	}
+	return s.file.commit()
+}
+
+// discard removes the partially written document, if any.
+func (s *fileSink) discard() {
+	if s.file != nil {
+		s.file.abort()
+	}
+}
+
+// writeOutputFiles writes the SBOMs to the files the template expands to and returns a summary listing
+// the written files.
+func writeOutputFiles(template string, outputs []sbomOutput) (string, error) {
+	paths := make([]string, len(outputs))
+	seen := make(map[string]string, len(outputs))
+	for i, out := range outputs {
+		paths[i] = out.path
+		if paths[i] == "" {
+			paths[i] = outputFilePath(template, out.index, out)
+		}
+		if other, ok := seen[paths[i]]; ok {
+			return "", fmt.Errorf("the SBOMs of %s and %s would both be written to %s", other, out.target, paths[i])
+		}
+		seen[paths[i]] = out.target
+	}
+
+	var sb strings.Builder
+	for i, out := range outputs {
+		// documents streamed into their file are already written.
+		if out.path == "" {
+			if err := writeFileAtomic(paths[i], out.result.Doc); err != nil {
+				return "", fmt.Errorf("could not write %s: %w", paths[i], err)
+			}
+		}
+		fmt.Fprintf(&sb, "SBOM for %s written to %s\n", out.target, paths[i])
+
+		if out.vex != nil {
+			path := vexFilePath(paths[i], out)
+			if err := writeFileAtomic(path, out.vex.Doc); err != nil {
+				return "", fmt.Errorf("could not write %s: %w", path, err)
+			}
+			fmt.Fprintf(&sb, "VEX for %s written to %s\n", out.target, path)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,169 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OutputFilePath_GivenTemplate_ShouldExpandPlaceholdersAndExtension(t *testing.T) {
+	type test struct {
+		template, target, mimeType string
+		subject                    Subject
+		expected                   string
+	}
+
+	tests := map[string]test{
+		"default cyclonedx json extension": {
+			template: "out/sbom", target: "alpine:3.17.0", mimeType: "application/vnd.cyclonedx+json",
+			expected: "out/sbom.cdx.json",
+		},
+		"default spdx extension": {
+			template: "sbom", target: "alpine:3.17.0", mimeType: "application/spdx+json",
+			expected: "sbom.spdx.json",
+		},
+		"default xml extension": {
+			template: "sbom", target: "alpine:3.17.0", mimeType: "application/vnd.cyclonedx+xml",
+			expected: "sbom.xml",
+		},
+		"explicit extension is kept": {
+			template: "sbom.json", target: "alpine:3.17.0", mimeType: "application/vnd.cyclonedx+json",
+			expected: "sbom.json",
+		},
+		"placeholders": {
+			template: "sboms/{index}-{name}-{tag}{ext}", target: "gcr.io/distroless/static:nonroot",
+			mimeType: "application/spdx+json",
+			expected: "sboms/2-gcr.io_distroless_static-nonroot.spdx.json",
+		},
+		"untagged image": {
+			template: "{name}-{tag}", target: "alpine", mimeType: "application/vnd.cyclonedx+json",
+			expected: "alpine-latest.cdx.json",
+		},
+		"archive": {
+			template: "{name}", target: "docker-archive:/tmp/app.tar", mimeType: "application/vnd.cyclonedx+json",
+			expected: "app.tar.cdx.json",
+		},
+		"archive with name read from manifest": {
+			template: "{name}-{tag}", target: "docker-archive:/tmp/app.tar", mimeType: "application/vnd.cyclonedx+json",
+			subject:  Subject{Name: "nginx", Version: "1.25"},
+			expected: "nginx-1.25.cdx.json",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := sbomOutput{
+				target:  tc.target,
+				subject: tc.subject,
+				result:  &GetSbomForDepGraphResult{MIMEType: tc.mimeType},
+			}
+			require.Equal(t, tc.expected, outputFilePath(tc.template, 2, out))
+		})
+	}
+}
+
+func Test_WriteOutputFiles_GivenExistingFile_ShouldReplaceItAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sbom.cdx.json")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o600))
+
+	summary, err := writeOutputFiles(filepath.Join(dir, "sbom"), []sbomOutput{{
+		target: "alpine:3.17.0",
+		result: &GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: "application/vnd.cyclonedx+json"},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, "SBOM for alpine:3.17.0 written to "+path+"\n", summary)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(content))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files must be renamed")
+}
+
+func Test_WriteOutputFiles_GivenUmask_ShouldCreateFileWithPermissionsOfOsCreate(t *testing.T) {
+	dir := t.TempDir()
+	reference, err := os.Create(filepath.Join(dir, "reference"))
+	require.NoError(t, err)
+	require.NoError(t, reference.Close())
+
+	_, err = writeOutputFiles(filepath.Join(dir, "sbom"), []sbomOutput{{
+		target: "alpine:3.17.0",
+		result: &GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: "application/vnd.cyclonedx+json"},
+	}})
+	require.NoError(t, err)
+
+	expected, err := os.Stat(reference.Name())
+	require.NoError(t, err)
+	actual, err := os.Stat(filepath.Join(dir, "sbom.cdx.json"))
+	require.NoError(t, err)
+	require.Equal(t, expected.Mode().Perm(), actual.Mode().Perm())
+}
+
+func Test_WriteOutputFiles_GivenCollidingPaths_ShouldReturnErrorWithoutWriting(t *testing.T) {
+	dir := t.TempDir()
+	result := &GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: "application/vnd.cyclonedx+json"}
+
+	_, err := writeOutputFiles(filepath.Join(dir, "{name}"), []sbomOutput{
+		{target: "alpine:3.17.0", result: result},
+		{target: "alpine:3.18.0", result: result},
+	})
+	require.ErrorContains(t, err, "the SBOMs of alpine:3.17.0 and alpine:3.18.0 would both be written to")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func Test_FileSink_GivenStreamedDocument_ShouldWriteFileOnFinish(t *testing.T) {
+	dir := t.TempDir()
+	sink := &fileSink{template: filepath.Join(dir, "{name}"), target: "alpine:3.17.0", subject: Subject{Name: "alpine"}}
+
+	w, err := sink.Writer("application/vnd.cyclonedx+json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("{}"))
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "alpine.cdx.json")
+	require.Equal(t, path, sink.path)
+	require.NoFileExists(t, path, "the document must only be visible once complete")
+
+	require.NoError(t, sink.finish(&GetSbomForDepGraphResult{MIMEType: "application/vnd.cyclonedx+json"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(content))
+}
+
+func Test_FileSink_GivenDiscard_ShouldRemovePartialDocument(t *testing.T) {
+	dir := t.TempDir()
+	sink := &fileSink{template: filepath.Join(dir, "sbom"), target: "alpine:3.17.0"}
+
+	w, err := sink.Writer("application/vnd.cyclonedx+json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("{"))
+	require.NoError(t, err)
+	sink.discard()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
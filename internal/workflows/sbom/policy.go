@@ -0,0 +1,35 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"fmt"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	"github.com/snyk/container-cli/internal/workflows/sbom/policy"
+)
+
+// enforcePolicy fails if the SBOM of the target violates the policy, listing all violations.
+func (w *Workflow) enforcePolicy(sbomResult *GetSbomForDepGraphResult, target string, p *policy.Policy) error {
+	doc, err := document.Parse(sbomResult.Doc)
+	if err != nil {
+		return w.errFactory.NewInternalError(fmt.Errorf("could not read the sbom to evaluate the policy: %w", err))
+	}
+
+	if violations := p.Evaluate(doc); len(violations) > 0 {
+		return w.errFactory.NewPolicyViolationError(target, len(violations), policy.Report(violations))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"errors"
+	"strings"
+)
+
+// deniedLicenses returns the denied licenses the package is bound to by its license. The license is either
+// a license name or an SPDX license expression; an expression only violates the policy if it cannot be
+// satisfied without a denied license, e.g. "GPL-3.0-only OR MIT" is fine unless MIT is denied as well.
+func deniedLicenses(license string, denied []string) []string {
+	if len(denied) == 0 {
+		return nil
+	}
+
+	isDenied := func(l string) bool {
+		for _, d := range denied {
+			if strings.EqualFold(l, d) {
+				return true
+			}
+		}
+		return false
+	}
+	if isDenied(strings.TrimSpace(license)) {
+		return []string{strings.TrimSpace(license)}
+	}
+
+	p := &expressionParser{tokens: tokenizeExpression(license), isDenied: isDenied}
+	result, err := p.parseOr()
+	if err != nil || p.pos != len(p.tokens) {
+		// license names aren't expressions and have already been compared as a whole.
+		return nil
+	}
+	return result
+}
+
+func tokenizeExpression(expr string) []string {
+	expr = strings.ReplaceAll(expr, "(", " ( ")
+	expr = strings.ReplaceAll(expr, ")", " ) ")
+	return strings.Fields(expr)
+}
+
+var errInvalidExpression = errors.New("invalid license expression")
+
+// expressionParser evaluates SPDX license expressions. Each production returns the denied licenses
+// it requires, which is nil if it can be satisfied with allowed licenses only.
+type expressionParser struct {
+	tokens   []string
+	pos      int
+	isDenied func(string) bool
+}
+
+func (p *expressionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *expressionParser) parseOr() ([]string, error) {
+	result, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || right == nil {
+			result = nil
+			continue
+		}
+		result = append(result, right...)
+	}
+	return result, nil
+}
+
+func (p *expressionParser) parseAnd() ([]string, error) {
+	result, err := p.parseWith()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "AND") {
+		p.pos++
+		right, err := p.parseWith()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, right...)
+	}
+	return result, nil
+}
+
+func (p *expressionParser) parseWith() ([]string, error) {
+	result, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(p.peek(), "WITH") {
+		// an exception relaxes the license, but doesn't change which license it is.
+		p.pos += 2
+		if p.pos > len(p.tokens) {
+			return nil, errInvalidExpression
+		}
+	}
+	return result, nil
+}
+
+func (p *expressionParser) parsePrimary() ([]string, error) {
+	token := p.peek()
+	switch {
+	case token == "(":
+		p.pos++
+		result, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errInvalidExpression
+		}
+		p.pos++
+		return result, nil
+	case token == "", token == ")", isOperator(token):
+		return nil, errInvalidExpression
+	default:
+		p.pos++
+		if p.isDenied(token) {
+			return []string{token}, nil
+		}
+		return nil, nil
+	}
+}
+
+func isOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
@@ -0,0 +1,280 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy evaluates SBOM documents against a policy of denied licenses, denied packages and
+// fields every package must have.
+//
+// Policies are YAML files such as:
+//
+//	deny:
+//	  licenses: [GPL-3.0-only, AGPL-3.0-or-later]
+//	  packages:
+//	    - purl: pkg:apk/alpine/openssl
+//	      versions: ">= 3.0.0, < 3.0.8-r0"
+//	      reason: CVE-2023-0286
+//	require:
+//	  fields: [version, purl, licenses]
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	"gopkg.in/yaml.v3"
+)
+
+// imageComponentPrefix marks the component representing the image itself in depgraph based SBOMs,
+// which is not a package and therefore exempt from the policy.
+const imageComponentPrefix = "docker-image|"
+
+// Field is a field packages are required to have.
+type Field string
+
+const (
+	FieldVersion  Field = "version"
+	FieldPURL     Field = "purl"
+	FieldLicenses Field = "licenses"
+)
+
+var validFields = []Field{FieldVersion, FieldPURL, FieldLicenses}
+
+// Rule identifies the kind of rule a package violates.
+type Rule string
+
+const (
+	RuleDeniedLicense Rule = "denied-license"
+	RuleDeniedPackage Rule = "denied-package"
+	RuleMissingField  Rule = "missing-field"
+)
+
+// Policy lists what the packages of an SBOM must not and must have.
+type Policy struct {
+	Deny    Deny    `yaml:"deny"`
+	Require Require `yaml:"require"`
+}
+
+// Deny lists the licenses and packages which must not appear in an SBOM.
+type Deny struct {
+	// Licenses are SPDX license ids or license names, compared case-insensitively.
+	Licenses []string      `yaml:"licenses"`
+	Packages []PackageRule `yaml:"packages"`
+}
+
+// PackageRule denies a package identified by its package URL. The rule applies to all versions of the
+// package, unless the purl contains a version or versions restricts it to a range.
+type PackageRule struct {
+	PURL string `yaml:"purl"`
+	// Versions is a version range such as ">= 1.2.0, < 1.2.5 || 2.0.0". Versions are ordered like the package
+	// manager of the purl type orders them, ranges are only supported for apk, deb, rpm, npm, cargo and golang.
+	Versions string `yaml:"versions"`
+	Reason   string `yaml:"reason"`
+
+	versions versionRange
+}
+
+// Require lists the fields every package must have.
+type Require struct {
+	Fields []Field `yaml:"fields"`
+}
+
+// Violation is a package breaking a rule of the policy.
+type Violation struct {
+	Rule      Rule               `json:"rule"`
+	Component document.Component `json:"component"`
+	Message   string             `json:"message"`
+}
+
+// Load reads the policy from a YAML file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads a YAML policy. Unknown keys are rejected, so that typos don't silently disable rules.
+func Parse(data []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var p Policy
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	for i := range p.Deny.Packages {
+		rule := &p.Deny.Packages[i]
+		if !strings.HasPrefix(rule.PURL, "pkg:") {
+			return fmt.Errorf("deny.packages[%d]: %q is not a package URL", i, rule.PURL)
+		}
+		versions, err := parseVersionRange(rule.Versions, purlType(rule.PURL))
+		if err != nil {
+			return fmt.Errorf("deny.packages[%d]: %w", i, err)
+		}
+		rule.versions = versions
+	}
+
+	for _, f := range p.Require.Fields {
+		if !isValidField(f) {
+			return fmt.Errorf("require.fields: unknown field %q, expected one of %s", f, fieldNames())
+		}
+	}
+	return nil
+}
+
+func isValidField(f Field) bool {
+	for _, v := range validFields {
+		if f == v {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldNames() string {
+	names := make([]string, len(validFields))
+	for i, f := range validFields {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Evaluate returns the violations of the policy by the packages of the document, in the order of the packages.
+func (p *Policy) Evaluate(doc *document.Document) []Violation {
+	var violations []Violation
+	for _, c := range doc.Components {
+		if strings.HasPrefix(c.Name, imageComponentPrefix) {
+			continue
+		}
+		violations = append(violations, p.evaluateComponent(c)...)
+	}
+	return violations
+}
+
+func (p *Policy) evaluateComponent(c document.Component) []Violation {
+	var violations []Violation
+	violation := func(rule Rule, format string, args ...any) {
+		violations = append(violations, Violation{Rule: rule, Component: c, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, f := range p.Require.Fields {
+		if !hasField(c, f) {
+			violation(RuleMissingField, "the %s is missing", f)
+		}
+	}
+
+	for _, l := range c.Licenses {
+		if denied := deniedLicenses(l, p.Deny.Licenses); len(denied) > 0 {
+			violation(RuleDeniedLicense, "the license %s is denied", strings.Join(denied, ", "))
+		}
+	}
+
+	for _, rule := range p.Deny.Packages {
+		if rule.matches(c) {
+			if rule.Reason != "" {
+				violation(RuleDeniedPackage, "the package is denied: %s", rule.Reason)
+			} else {
+				violation(RuleDeniedPackage, "the package is denied")
+			}
+		}
+	}
+	return violations
+}
+
+func hasField(c document.Component, f Field) bool {
+	switch f {
+	case FieldVersion:
+		return c.Version != ""
+	case FieldPURL:
+		return c.PURL != ""
+	case FieldLicenses:
+		return len(c.Licenses) > 0
+	default:
+		return true
+	}
+}
+
+// matches returns true if the rule denies the component. Package URLs are compared without their
+// qualifiers and subpath, so "pkg:apk/alpine/musl" matches "pkg:apk/alpine/musl@1.2.3-r4?arch=x86_64".
+func (r *PackageRule) matches(c document.Component) bool {
+	if c.PURL == "" {
+		return false
+	}
+
+	name, version := splitPURL(r.PURL)
+	componentName, componentVersion := splitPURL(c.PURL)
+	if !strings.EqualFold(name, componentName) {
+		return false
+	}
+	if componentVersion == "" {
+		componentVersion = c.Version
+	}
+	if version != "" && version != componentVersion {
+		return false
+	}
+	return r.versions.contains(componentVersion)
+}
+
+// splitPURL splits a package URL into the package and its version, dropping qualifiers and subpath. Both are
+// percent-decoded, so "pkg:deb/debian/bash@1%3A5.2" has the version "1:5.2".
+func splitPURL(purl string) (name, version string) {
+	purl, _, _ = strings.Cut(purl, "#")
+	purl, _, _ = strings.Cut(purl, "?")
+	// the version follows the last "@", because namespaces such as npm scopes may contain "@" too.
+	if i := strings.LastIndex(purl, "@"); i > strings.LastIndex(purl, "/") {
+		return unescapePURL(purl[:i]), unescapePURL(purl[i+1:])
+	}
+	return unescapePURL(purl), ""
+}
+
+// unescapePURL percent-decodes a part of a package URL. Parts which are not validly encoded are kept as they are.
+func unescapePURL(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+// Report renders the violations as human readable text, one line per violation.
+func Report(violations []Violation) string {
+	var sb strings.Builder
+	for _, v := range violations {
+		fmt.Fprintf(&sb, "  %-15s %s: %s\n", v.Rule, formatComponent(v.Component), v.Message)
+	}
+	return sb.String()
+}
+
+func formatComponent(c document.Component) string {
+	name := c.Name
+	if c.Version != "" {
+		name += "@" + c.Version
+	}
+	if c.PURL != "" {
+		name += " (" + c.PURL + ")"
+	}
+	return name
+}
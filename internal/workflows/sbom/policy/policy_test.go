@@ -0,0 +1,254 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+deny:
+  licenses: [GPL-3.0-only, agpl-3.0-or-later]
+  packages:
+    - purl: pkg:apk/alpine/openssl
+      versions: ">= 3.0.0, < 3.0.8-r0"
+      reason: CVE-2023-0286
+    - purl: pkg:npm/event-stream@3.3.6
+require:
+  fields: [version, purl]
+`
+
+func Test_Evaluate_GivenDocument_ShouldReturnViolations(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	require.NoError(t, err)
+
+	openssl := document.Component{
+		Name: "openssl", Version: "3.0.7-r2", PURL: "pkg:apk/alpine/openssl@3.0.7-r2?arch=x86_64",
+	}
+	eventStream := document.Component{Name: "event-stream", Version: "3.3.6", PURL: "pkg:npm/event-stream@3.3.6"}
+	readline := document.Component{
+		Name: "readline", Version: "8.2", PURL: "pkg:deb/debian/readline@8.2", Licenses: []string{"GPL-3.0-only"},
+	}
+	unversioned := document.Component{Name: "unversioned"}
+
+	doc := &document.Document{Components: []document.Component{
+		{Name: "docker-image|alpine", Version: "3.17.0"},
+		openssl,
+		{Name: "openssl", Version: "3.0.8-r0", PURL: "pkg:apk/alpine/openssl@3.0.8-r0"},
+		eventStream,
+		{Name: "event-stream", Version: "4.0.1", PURL: "pkg:npm/event-stream@4.0.1"},
+		readline,
+		{Name: "lib", Version: "1", PURL: "pkg:npm/lib@1", Licenses: []string{"GPL-3.0-only OR MIT"}},
+		unversioned,
+	}}
+
+	require.Equal(t, []Violation{
+		{Rule: RuleDeniedPackage, Component: openssl, Message: "the package is denied: CVE-2023-0286"},
+		{Rule: RuleDeniedPackage, Component: eventStream, Message: "the package is denied"},
+		{Rule: RuleDeniedLicense, Component: readline, Message: "the license GPL-3.0-only is denied"},
+		{Rule: RuleMissingField, Component: unversioned, Message: "the version is missing"},
+		{Rule: RuleMissingField, Component: unversioned, Message: "the purl is missing"},
+	}, p.Evaluate(doc))
+}
+
+func Test_Evaluate_GivenPercentEncodedPURLs_ShouldCompareDecodedVersions(t *testing.T) {
+	p, err := Parse([]byte(`
+deny:
+  packages:
+    - purl: pkg:deb/debian/bash@1:5.2.15-2
+    - purl: pkg:npm/%40acme/lib
+      versions: "< 2.0.0"
+    - purl: pkg:golang/example.com/mod
+      versions: ">= 1.0.0, < 1.1.0"
+`))
+	require.NoError(t, err)
+
+	bash := document.Component{Name: "bash", Version: "1:5.2.15-2", PURL: "pkg:deb/debian/bash@1%3A5.2.15-2"}
+	lib := document.Component{Name: "@acme/lib", Version: "1.0.0", PURL: "pkg:npm/%40acme/lib@1.0.0"}
+	mod := document.Component{
+		Name: "example.com/mod", Version: "1.0.0+incompatible", PURL: "pkg:golang/example.com/mod@1.0.0%2Bincompatible",
+	}
+
+	doc := &document.Document{Components: []document.Component{
+		bash,
+		{Name: "bash", Version: "1:5.2.15-3", PURL: "pkg:deb/debian/bash@1%3A5.2.15-3"},
+		lib,
+		{Name: "@acme/lib", Version: "2.0.0", PURL: "pkg:npm/%40acme/lib@2.0.0"},
+		mod,
+	}}
+
+	require.Equal(t, []Violation{
+		{Rule: RuleDeniedPackage, Component: bash, Message: "the package is denied"},
+		{Rule: RuleDeniedPackage, Component: lib, Message: "the package is denied"},
+		{Rule: RuleDeniedPackage, Component: mod, Message: "the package is denied"},
+	}, p.Evaluate(doc))
+}
+
+func Test_SplitPURL_GivenPURL_ShouldReturnDecodedNameAndVersion(t *testing.T) {
+	tests := map[string][2]string{
+		"pkg:deb/debian/bash@1%3A5.2.15-2?arch=amd64": {"pkg:deb/debian/bash", "1:5.2.15-2"},
+		"pkg:npm/%40acme/lib@1.0.0":                   {"pkg:npm/@acme/lib", "1.0.0"},
+		"pkg:npm/@acme/lib":                           {"pkg:npm/@acme/lib", ""},
+		"pkg:golang/mod@1.0.0%2Bincompatible#sub":     {"pkg:golang/mod", "1.0.0+incompatible"},
+		"pkg:npm/lib@1%ZZ":                            {"pkg:npm/lib", "1%ZZ"},
+	}
+
+	for purl, expected := range tests {
+		t.Run(purl, func(t *testing.T) {
+			name, version := splitPURL(purl)
+			require.Equal(t, expected, [2]string{name, version})
+		})
+	}
+}
+
+func Test_Parse_GivenInvalidPolicy_ShouldReturnError(t *testing.T) {
+	tests := map[string]struct {
+		policy        string
+		expectedError string
+	}{
+		"unknown key": {
+			"deny:\n  license: [MIT]\n",
+			"field license not found",
+		},
+		"invalid purl": {
+			"deny:\n  packages:\n    - purl: openssl\n",
+			`deny.packages[0]: "openssl" is not a package URL`,
+		},
+		"invalid version range": {
+			"deny:\n  packages:\n    - purl: pkg:npm/lib\n      versions: '>= 1, <'\n",
+			`deny.packages[0]: invalid version range ">= 1, <"`,
+		},
+		"invalid apk version": {
+			"deny:\n  packages:\n    - purl: pkg:apk/alpine/openssl\n      versions: '< 3.0.8-rc1'\n",
+			`deny.packages[0]: invalid version range "< 3.0.8-rc1": "3.0.8-rc1" is not a valid pkg:apk version`,
+		},
+		"version range of unsupported ecosystem": {
+			"deny:\n  packages:\n    - purl: pkg:maven/org.example/lib\n      versions: '< 2.0'\n",
+			"deny.packages[0]: version ranges are not supported for pkg:maven packages, expected one of " +
+				"apk, cargo, deb, golang, npm, rpm",
+		},
+		"unknown field": {
+			"require:\n  fields: [supplier]\n",
+			`require.fields: unknown field "supplier", expected one of version, purl, licenses`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.policy))
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_Parse_GivenEmptyPolicy_ShouldAllowEverything(t *testing.T) {
+	p, err := Parse(nil)
+	require.NoError(t, err)
+
+	doc := &document.Document{Components: []document.Component{{Name: "lib", Licenses: []string{"GPL-3.0-only"}}}}
+	require.Empty(t, p.Evaluate(doc))
+}
+
+func Test_DeniedLicenses_GivenLicense_ShouldEvaluateExpressions(t *testing.T) {
+	denied := []string{"GPL-3.0-only", "AGPL-3.0-only", "Some Custom License"}
+
+	tests := map[string][]string{
+		"MIT":                                            nil,
+		"gpl-3.0-only":                                   {"gpl-3.0-only"},
+		"Some Custom License":                            {"Some Custom License"},
+		"GPL-3.0-only OR MIT":                            nil,
+		"GPL-3.0-only AND MIT":                           {"GPL-3.0-only"},
+		"GPL-3.0-only OR AGPL-3.0-only":                  {"GPL-3.0-only", "AGPL-3.0-only"},
+		"MIT AND (GPL-3.0-only OR Apache-2.0)":           nil,
+		"(MIT OR Apache-2.0) AND AGPL-3.0-only":          {"AGPL-3.0-only"},
+		"GPL-3.0-only WITH Classpath-exception-2.0":      {"GPL-3.0-only"},
+		"Apache-2.0 WITH LLVM-exception OR GPL-3.0-only": nil,
+		"not an ( expression":                            nil,
+	}
+
+	for license, expected := range tests {
+		t.Run(license, func(t *testing.T) {
+			require.Equal(t, expected, deniedLicenses(license, denied))
+		})
+	}
+}
+
+func Test_VersionRange_GivenVersions_ShouldMatchRange(t *testing.T) {
+	r, err := parseVersionRange(">= 1.2.0, < 1.2.5 || 2.0.0 || > 3", "npm")
+	require.NoError(t, err)
+
+	tests := map[string]bool{
+		"1.1.9":    false,
+		"1.2.0":    true,
+		"1.2.4-r1": true,
+		"1.2.5":    false,
+		"1.2.10":   false,
+		"2.0.0":    true,
+		"2.0.1":    false,
+		"3.0.1":    true,
+		"":         false,
+	}
+
+	for version, expected := range tests {
+		t.Run(version, func(t *testing.T) {
+			require.Equal(t, expected, r.contains(version))
+		})
+	}
+}
+
+func Test_VersionSchemes_GivenOrderedVersions_ShouldOrderThem(t *testing.T) {
+	tests := map[string]struct {
+		ordered []string
+		equal   [][2]string
+	}{
+		"npm": {
+			ordered: []string{"0.9", "v1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2",
+				"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.0.10", "1.1.0", "2.0.0-rc1", "2.0.0"},
+			equal: [][2]string{{"1.0.0+build.1", "1.0.0"}, {"1.2", "1.2.0"}},
+		},
+		"deb": {
+			ordered: []string{"1.0~rc1", "1.0", "1.0-1", "1.0-1ubuntu1", "1.0a", "1.0.1", "2.4", "2.10~beta",
+				"1:1.0~rc1", "1:2.3"},
+			equal: [][2]string{{"0:1.0-1", "1.0-1"}, {"1.01", "1.1"}},
+		},
+		"apk": {
+			ordered: []string{"1.0_alpha", "1.0_beta2", "1.0_rc1", "1.0", "1.0-r1", "1.0_p1", "1.0a", "1.0.1",
+				"3.0.7-r2", "3.0.8-r0", "3.0.10-r1"},
+			equal: [][2]string{{"1.0-r0", "1.0"}},
+		},
+		"rpm": {
+			ordered: []string{"1.0~rc1", "1.0", "1.0-1.el9", "1.0-2.el9", "1.0^git1", "1.0.a", "1.0.1", "1.0.1a",
+				"1.10", "1:0.1"},
+			equal: [][2]string{{"1.0_1", "1.0.1"}, {"0:1.0", "1.0"}},
+		},
+	}
+
+	for purlType, tc := range tests {
+		t.Run(purlType, func(t *testing.T) {
+			compare := versionSchemes[purlType].compare
+			for i := 1; i < len(tc.ordered); i++ {
+				a, b := tc.ordered[i-1], tc.ordered[i]
+				require.Negative(t, compare(a, b), "%s < %s", a, b)
+				require.Positive(t, compare(b, a), "%s > %s", b, a)
+			}
+			for _, versions := range tc.equal {
+				require.Zero(t, compare(versions[0], versions[1]), "%s = %s", versions[0], versions[1])
+			}
+		})
+	}
+}
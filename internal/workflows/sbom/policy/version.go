@@ -0,0 +1,135 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// versionRange is a disjunction of version constraint sets, e.g. ">= 1.2.0, < 1.2.5 || 2.0.0", whose versions
+// are ordered by the version scheme of an ecosystem. An empty range contains every version.
+type versionRange struct {
+	alternatives [][]versionConstraint
+	scheme       versionScheme
+}
+
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+// operators are ordered so that the two character operators are matched first.
+var operators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// parseVersionRange parses the range of versions of packages of the purl type, e.g. "deb" or "npm". Ranges are
+// only supported for ecosystems whose versions we know how to order.
+func parseVersionRange(s, purlType string) (versionRange, error) {
+	if strings.TrimSpace(s) == "" {
+		return versionRange{}, nil
+	}
+
+	scheme, ok := versionSchemes[purlType]
+	if !ok {
+		return versionRange{}, fmt.Errorf("version ranges are not supported for pkg:%s packages, expected one of %s",
+			purlType, schemeNames())
+	}
+
+	r := versionRange{scheme: scheme}
+	for _, alternative := range strings.Split(s, "||") {
+		var constraints []versionConstraint
+		for _, c := range strings.Split(alternative, ",") {
+			c = strings.TrimSpace(c)
+			if c == "" {
+				return versionRange{}, fmt.Errorf("invalid version range %q: empty constraint", s)
+			}
+
+			op := "="
+			for _, candidate := range operators {
+				if rest, ok := strings.CutPrefix(c, candidate); ok {
+					op, c = candidate, strings.TrimSpace(rest)
+					break
+				}
+			}
+			if c == "" || strings.ContainsAny(c, " <>=!") {
+				return versionRange{}, fmt.Errorf("invalid version range %q", s)
+			}
+			if scheme.valid != nil && !scheme.valid(c) {
+				return versionRange{}, fmt.Errorf("invalid version range %q: %q is not a valid pkg:%s version",
+					s, c, purlType)
+			}
+			constraints = append(constraints, versionConstraint{op: op, version: c})
+		}
+		r.alternatives = append(r.alternatives, constraints)
+	}
+	return r, nil
+}
+
+func (r versionRange) contains(version string) bool {
+	if len(r.alternatives) == 0 {
+		return true
+	}
+	if version == "" {
+		return false
+	}
+
+	for _, constraints := range r.alternatives {
+		if r.allSatisfied(constraints, version) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r versionRange) allSatisfied(constraints []versionConstraint, version string) bool {
+	for _, c := range constraints {
+		cmp := r.scheme.compare(version, c.version)
+		var ok bool
+		switch c.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// purlType returns the lowercase type of a package URL, e.g. "deb" for "pkg:deb/debian/bash@5.2".
+func purlType(purl string) string {
+	t, _, _ := strings.Cut(strings.TrimPrefix(purl, "pkg:"), "/")
+	return strings.ToLower(t)
+}
+
+func schemeNames() string {
+	names := make([]string, 0, len(versionSchemes))
+	for name := range versionSchemes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,393 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionScheme orders the versions of an ecosystem.
+type versionScheme struct {
+	// compare returns a negative number if a < b, zero if a == b and a positive number if a > b.
+	compare func(a, b string) int
+	// valid reports whether a version of a range can be ordered, nil accepts every version.
+	valid func(v string) bool
+}
+
+// versionSchemes maps purl types to the version ordering of their package managers. Ordering versions of other
+// ecosystems generically gets pre-releases and epochs wrong, which would let denied packages slip through.
+var versionSchemes = map[string]versionScheme{
+	"apk":    {compare: compareAPK, valid: isAPKVersion},
+	"deb":    {compare: compareDebian},
+	"rpm":    {compare: compareRPM},
+	"npm":    {compare: compareSemver},
+	"cargo":  {compare: compareSemver},
+	"golang": {compare: compareSemver},
+}
+
+// compareSemver orders semantic versions, where pre-releases precede their release ("1.0.0-rc.1" < "1.0.0")
+// and build metadata is ignored. Missing minor and patch numbers count as zero and a leading "v" is dropped,
+// as it is for go modules.
+func compareSemver(a, b string) int {
+	aCore, aPre := splitSemver(a)
+	bCore, bPre := splitSemver(b)
+
+	as, bs := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if c := compareIdentifiers(at(as, i, "0"), at(bs, i, "0")); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	as, bs = strings.Split(aPre, "."), strings.Split(bPre, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifiers(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
+
+func splitSemver(v string) (core, prerelease string) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, prerelease, _ = strings.Cut(v, "-")
+	return core, prerelease
+}
+
+// compareIdentifiers compares numeric identifiers numerically, and orders them before alphanumeric ones which
+// compare lexically.
+func compareIdentifiers(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareUint(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareDebian orders versions like dpkg does: "[epoch:]upstream[-revision]", where the epoch takes precedence
+// ("1:2.3" > "2.4") and a tilde sorts before anything, even the end of the version ("1.0~rc1" < "1.0").
+func compareDebian(a, b string) int {
+	aEpoch, aUpstream, aRevision := splitDebian(a)
+	bEpoch, bUpstream, bRevision := splitDebian(b)
+	if c := compareUint(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := verrevcmp(aUpstream, bUpstream); c != 0 {
+		return c
+	}
+	return verrevcmp(aRevision, bRevision)
+}
+
+func splitDebian(v string) (epoch uint64, upstream, revision string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		if n, err := strconv.ParseUint(e, 10, 64); err == nil {
+			epoch, v = n, rest
+		}
+	}
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// verrevcmp is the comparison of dpkg: runs of non-digits compare character by character, with letters sorting
+// before other characters and tildes before everything, and runs of digits compare numerically.
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := debianOrder(a, i), debianOrder(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		firstDiff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// debianOrder is the weight of the character at i; digits and the end of the version weigh nothing.
+func debianOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	switch c := s[i]; {
+	case isDigit(c):
+		return 0
+	case isLetter(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+// compareRPM orders versions like rpm does: "[epoch:]version[-release]", compared segment by segment with
+// numbers being newer than letters, and a tilde sorting before and a caret after the end of the version.
+func compareRPM(a, b string) int {
+	aEpoch, aVersion, aRelease := splitDebian(a)
+	bEpoch, bVersion, bRelease := splitDebian(b)
+	if c := compareUint(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := rpmvercmp(aVersion, bVersion); c != 0 {
+		return c
+	}
+	return rpmvercmp(aRelease, bRelease)
+}
+
+func rpmvercmp(a, b string) int {
+	for {
+		a = strings.TrimLeftFunc(a, isRPMSeparator)
+		b = strings.TrimLeftFunc(b, isRPMSeparator)
+
+		aTilde, bTilde := strings.HasPrefix(a, "~"), strings.HasPrefix(b, "~")
+		if aTilde || bTilde {
+			if !aTilde {
+				return 1
+			}
+			if !bTilde {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		aCaret, bCaret := strings.HasPrefix(a, "^"), strings.HasPrefix(b, "^")
+		if aCaret || bCaret {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case !aCaret:
+				return 1
+			case !bCaret:
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		var aSeg, bSeg string
+		if isDigit(a[0]) {
+			aSeg, a = cutRun(a, isDigit)
+			bSeg, b = cutRun(b, isDigit)
+			if bSeg == "" {
+				// numeric segments are newer than alphabetic ones.
+				return 1
+			}
+			aSeg, bSeg = strings.TrimLeft(aSeg, "0"), strings.TrimLeft(bSeg, "0")
+			if len(aSeg) != len(bSeg) {
+				return len(aSeg) - len(bSeg)
+			}
+		} else {
+			aSeg, a = cutRun(a, isLetter)
+			bSeg, b = cutRun(b, isLetter)
+			if bSeg == "" {
+				return -1
+			}
+		}
+		if c := strings.Compare(aSeg, bSeg); c != 0 {
+			return c
+		}
+	}
+
+	// whichever version has segments left is newer.
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+func isRPMSeparator(r rune) bool {
+	return r < 128 && !isDigit(byte(r)) && !isLetter(byte(r)) && r != '~' && r != '^'
+}
+
+// apkVersionPattern matches versions of alpine packages, e.g. "1.2.3a_rc1_p2-r4".
+var apkVersionPattern = regexp.MustCompile(`^(\d+(?:\.\d+)*)([a-z]?)((?:_[a-z]+\d*)*)(?:-r(\d+))?$`)
+
+// apkSuffixes are ordered like apk orders them, pre-release suffixes sort before a version without suffix.
+var apkSuffixes = []string{"alpha", "beta", "pre", "rc", "", "cvs", "svn", "git", "hg", "p"}
+
+type apkVersion struct {
+	numbers  []string
+	letter   string
+	suffixes []string
+	revision uint64
+}
+
+func isAPKVersion(v string) bool {
+	_, ok := parseAPKVersion(v)
+	return ok
+}
+
+func parseAPKVersion(v string) (apkVersion, bool) {
+	m := apkVersionPattern.FindStringSubmatch(v)
+	if m == nil {
+		return apkVersion{}, false
+	}
+
+	parsed := apkVersion{numbers: strings.Split(m[1], "."), letter: m[2]}
+	if m[3] != "" {
+		parsed.suffixes = strings.Split(m[3][1:], "_")
+		for _, s := range parsed.suffixes {
+			if apkSuffixRank(s) < 0 {
+				return apkVersion{}, false
+			}
+		}
+	}
+	if m[4] != "" {
+		revision, err := strconv.ParseUint(m[4], 10, 64)
+		if err != nil {
+			return apkVersion{}, false
+		}
+		parsed.revision = revision
+	}
+	return parsed, true
+}
+
+// compareAPK orders versions like apk does: "1.2.3[letter][_suffix[n]...][-rN]", where pre-release suffixes
+// precede the release ("1.0_rc1" < "1.0") and the package revision is compared last. Versions apk wouldn't
+// accept are compared like dpkg compares them.
+func compareAPK(a, b string) int {
+	av, aOK := parseAPKVersion(a)
+	bv, bOK := parseAPKVersion(b)
+	if !aOK || !bOK {
+		return verrevcmp(a, b)
+	}
+
+	for i := 0; i < len(av.numbers) && i < len(bv.numbers); i++ {
+		if c := verrevcmp(av.numbers[i], bv.numbers[i]); c != 0 {
+			return c
+		}
+	}
+	if c := len(av.numbers) - len(bv.numbers); c != 0 {
+		return c
+	}
+	if c := strings.Compare(av.letter, bv.letter); c != 0 {
+		return c
+	}
+
+	for i := 0; i < len(av.suffixes) || i < len(bv.suffixes); i++ {
+		as, bs := at(av.suffixes, i, ""), at(bv.suffixes, i, "")
+		if c := apkSuffixRank(as) - apkSuffixRank(bs); c != 0 {
+			return c
+		}
+		if c := verrevcmp(strings.TrimLeft(as, "abcdefghijklmnopqrstuvwxyz"),
+			strings.TrimLeft(bs, "abcdefghijklmnopqrstuvwxyz")); c != 0 {
+			return c
+		}
+	}
+	return compareUint(av.revision, bv.revision)
+}
+
+// apkSuffixRank returns the position of the suffix, without its number, in apkSuffixes or -1 if it is unknown.
+func apkSuffixRank(suffix string) int {
+	name := strings.TrimRight(suffix, "0123456789")
+	for i, s := range apkSuffixes {
+		if s == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func at(s []string, i int, fallback string) string {
+	if i < len(s) {
+		return s[i]
+	}
+	return fallback
+}
+
+func cutRun(s string, fn func(byte) bool) (run, rest string) {
+	i := 0
+	for i < len(s) && fn(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
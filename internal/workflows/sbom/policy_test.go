@@ -0,0 +1,77 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/snyk/container-cli/internal/common/flags"
+	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+// writePolicy writes the YAML policy into a temporary file and returns its path.
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func expectSbomResultDoc(t *testing.T) {
+	t.Helper()
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: getSbom(t, "testdata/sbom_result_doc.json"),
+			MIMEType: "application/vnd.cyclonedx+json"}, nil)
+}
+
+func Test_Entrypoint_GivenPolicyViolation_ShouldReturnViolations(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagPolicy.Name, writePolicy(t, "require:\n  fields: [purl]\n"))
+	expectSbomResultDoc(t)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewPolicyViolationError("alpine:3.17.0", 1,
+		"  missing-field   testpkg@10.10: the purl is missing\n").Error())
+}
+
+func Test_Entrypoint_GivenSatisfiedPolicy_ShouldReturnSbom(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagPolicy.Name, writePolicy(t, "deny:\n  licenses: [GPL-3.0-only]\nrequire:\n  fields: [version]\n"))
+	expectSbomResultDoc(t)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, getSbom(t, "testdata/sbom_result_doc.json"), result[0].GetPayload())
+}
+
+func Test_Entrypoint_GivenInvalidPolicy_ShouldReturnPolicyFileError(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagPolicy.Name, writePolicy(t, "deny:\n  license: [MIT]\n"))
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "The policy file")
+	require.ErrorContains(t, err, "field license not found")
+}
@@ -0,0 +1,139 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+)
+
+// requestBody is the JSON body of a request to the Snyk API. The body is encoded while it is sent rather than
+// encoded in memory first, as a copy of the depgraphs of large images takes up hundreds of megabytes.
+type requestBody struct {
+	value any
+	// gzip compresses the body, which is announced with the Content-Encoding header.
+	gzip bool
+	// mediaType is the media type of the JSON document, application/json unless set.
+	mediaType string
+}
+
+func (b requestBody) contentType() string {
+	if b.mediaType == "" {
+		return constants.ContentTypeJSON
+	}
+	return b.mediaType
+}
+
+// jsonStreamer is implemented by values which are encoded piece by piece, as opposed to json.Encoder, which
+// encodes the whole value in memory before writing it.
+type jsonStreamer interface {
+	writeJSON(w io.Writer) error
+}
+
+// open starts encoding the body. Every attempt of a request reads a freshly opened body, as a streamed body
+// can only be read once. Closing the reader stops the encoding.
+func (b requestBody) open() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(b.encode(pw))
+	}()
+	return pr
+}
+
+func (b requestBody) encode(w io.Writer) error {
+	if !b.gzip {
+		return encodeJSON(w, b.value)
+	}
+
+	gz := gzip.NewWriter(w)
+	if err := encodeJSON(gz, b.value); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func encodeJSON(w io.Writer, value any) error {
+	if s, ok := value.(jsonStreamer); ok {
+		return s.writeJSON(w)
+	}
+	return json.NewEncoder(w).Encode(value)
+}
+
+// writeJSON writes the same encoding as json.Marshal. The depgraphs of the request are already in memory, but
+// only one of them is encoded at a time instead of a copy of all of them.
+func (r *GetSbomForDepGraphRequest) writeJSON(w io.Writer) error {
+	subject, err := json.Marshal(r.Subject)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `{"depGraphs":`); err != nil {
+		return err
+	}
+	if r.DepGraphs == nil {
+		if _, err := io.WriteString(w, "null"); err != nil {
+			return err
+		}
+	} else if err := writeDepGraphs(w, r.DepGraphs); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `,"subject":`); err != nil {
+		return err
+	}
+	if _, err := w.Write(subject); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "}")
+	return err
+}
+
+func writeDepGraphs(w io.Writer, depGraphs []json.RawMessage) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for i, depGraph := range depGraphs {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		if len(depGraph) == 0 {
+			if _, err := io.WriteString(w, "null"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// json.Marshal validates and compacts raw messages, so the depgraphs are sent the same way.
+		buf.Reset()
+		if err := json.Compact(&buf, depGraph); err != nil {
+			return err
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]")
+	return err
+}
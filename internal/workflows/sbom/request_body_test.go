@@ -0,0 +1,219 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/metrics"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteJSON_GivenRequest_ShouldMatchJSONMarshal(t *testing.T) {
+	tests := map[string]struct {
+		depGraphs []json.RawMessage
+	}{
+		"no depgraphs": {
+			depGraphs: nil,
+		},
+		"empty depgraphs": {
+			depGraphs: []json.RawMessage{},
+		},
+		"indented depgraphs": {
+			depGraphs: []json.RawMessage{
+				json.RawMessage("{\n  \"schemaVersion\": \"1.2.0\",\n  \"pkgs\": [ ]\n}"),
+				json.RawMessage(`{"schemaVersion":"1.2.0"}`),
+			},
+		},
+		"missing depgraph": {
+			depGraphs: []json.RawMessage{nil, json.RawMessage(`{}`)},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := &GetSbomForDepGraphRequest{
+				DepGraphs: tc.depGraphs,
+				Subject:   Subject{Name: "alpine", Version: "3.17.0"},
+			}
+			expected, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, req.writeJSON(&buf))
+
+			require.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func Test_WriteJSON_GivenInvalidDepGraph_ShouldReturnError(t *testing.T) {
+	req := &GetSbomForDepGraphRequest{DepGraphs: []json.RawMessage{json.RawMessage(`{"pkgs":`)}}
+
+	require.Error(t, req.writeJSON(io.Discard))
+}
+
+func Test_Open_GivenGzip_ShouldStreamCompressedBody(t *testing.T) {
+	req := &GetSbomForDepGraphRequest{
+		DepGraphs: []json.RawMessage{json.RawMessage(`{"schemaVersion":"1.2.0"}`)},
+		Subject:   Subject{Name: "alpine"},
+	}
+	expected, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	body := requestBody{value: req, gzip: true}.open()
+	defer body.Close()
+	gz, err := gzip.NewReader(body)
+	require.NoError(t, err)
+	actual, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	require.Equal(t, expected, actual)
+}
+
+func Test_Open_GivenClosedBody_ShouldStopEncoding(t *testing.T) {
+	done := make(chan error, 1)
+	body := requestBody{value: streamerFunc(func(w io.Writer) error {
+		_, err := w.Write([]byte("{}"))
+		done <- err
+		return err
+	})}.open()
+
+	require.NoError(t, body.Close())
+	require.ErrorIs(t, <-done, io.ErrClosedPipe)
+}
+
+type streamerFunc func(w io.Writer) error
+
+func (f streamerFunc) writeJSON(w io.Writer) error {
+	return f(w)
+}
+
+// BenchmarkRequestBody compares the peak heap of sending a request holding the depgraphs of an image with
+// many applications, encoded in memory as before, to the streamed and compressed body.
+func BenchmarkRequestBody(b *testing.B) {
+	req := syntheticRequest(b, 50, 5000)
+
+	b.Run("marshal", func(b *testing.B) {
+		reportPeakHeap(b, func() {
+			body, err := json.Marshal(req)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := io.Copy(io.Discard, bytes.NewReader(body)); err != nil {
+				b.Fatal(err)
+			}
+		})
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		reportPeakHeap(b, func() {
+			body := requestBody{value: req, gzip: true}.open()
+			defer body.Close()
+			if _, err := io.Copy(io.Discard, body); err != nil {
+				b.Fatal(err)
+			}
+		})
+	})
+}
+
+// syntheticRequest returns a request with the given number of depgraphs, each with the given number of packages.
+func syntheticRequest(b *testing.B, depGraphs, pkgs int) *GetSbomForDepGraphRequest {
+	b.Helper()
+
+	req := &GetSbomForDepGraphRequest{Subject: Subject{Name: "acme/app", Version: "1.0.0"}}
+	var size int
+	for i := range depGraphs {
+		builder := depgraph.NewBuilder(depgraph.PkgManager{Name: "maven"}, depgraph.PkgInfo{
+			Name:    fmt.Sprintf("/app/lib/app-%d.jar", i),
+			Version: "1.0.0",
+		})
+		for j := range pkgs {
+			name := fmt.Sprintf("com.acme.group%d:artifact-%d", i, j)
+			builder.AddDependency(depgraph.PkgInfo{
+				Name:    name,
+				Version: "1.2.3",
+				PURL:    fmt.Sprintf("pkg:maven/com.acme.group%d/artifact-%d@1.2.3", i, j),
+			})
+		}
+
+		data, err := json.Marshal(builder.Build())
+		if err != nil {
+			b.Fatal(err)
+		}
+		req.DepGraphs = append(req.DepGraphs, data)
+		size += len(data)
+	}
+	b.Logf("synthetic request with %d depgraphs of %d MB", depGraphs, size>>20)
+	return req
+}
+
+// reportPeakHeap runs f b.N times and reports the highest heap usage above the usage before the benchmark,
+// which holds the request itself.
+func reportPeakHeap(b *testing.B, f func()) {
+	b.Helper()
+
+	runtime.GC()
+	baseline := heapObjectBytes()
+
+	var peak atomic.Uint64
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(100 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			if used := heapObjectBytes(); used > peak.Load() {
+				peak.Store(used)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		f()
+	}
+	b.StopTimer()
+
+	close(stop)
+	<-sampled
+	if p := peak.Load(); p > baseline {
+		b.ReportMetric(float64(p-baseline)/(1<<20), "peak-heap-MB")
+	} else {
+		b.ReportMetric(0, "peak-heap-MB")
+	}
+}
+
+func heapObjectBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
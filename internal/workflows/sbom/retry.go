@@ -0,0 +1,123 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/constants"
+)
+
+// RetryPolicy configures how requests to the SBOM API are retried after transient failures.
+// The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, it doubles with every further retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts, including waits requested via Retry-After.
+	MaxBackoff time.Duration
+	// Jitter randomizes the backoff by up to the given fraction (0-1) to spread out concurrent retries.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the retry policy used by the CLI. The workflows replace its limits by the ones configured
+// with --retry-max-attempts and --retry-max-backoff, see withRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    constants.DefaultRetryMaxAttempts,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     constants.DefaultRetryMaxBackoff,
+	Jitter:         0.2,
+}
+
+// retryPolicyKey is the key of the retry policy in the context of a request.
+type retryPolicyKey struct{}
+
+// withRetryPolicy returns a context whose requests are retried according to the given policy instead of the
+// policy of the client sending them.
+func withRetryPolicy(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+// retryPolicyOf returns the retry policy of the context, or the fallback if it has none.
+func retryPolicyOf(ctx context.Context, fallback RetryPolicy) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return p
+	}
+	return fallback
+}
+
+// isRetryableStatus returns true for responses that indicate a transient failure, after which the
+// request can safely be sent again.
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns how long to wait before the given retry (starting at 1). A Retry-After header of
+// the response takes precedence over the exponential backoff.
+func (p RetryPolicy) backoff(retry int, res *http.Response) time.Duration {
+	if res != nil {
+		if d, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			return min(d, p.MaxBackoff)
+		}
+	}
+
+	d := p.InitialBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxBackoff)
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return max(d, 0)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRetryAfter_GivenHeaderValue_ShouldReturnWait(t *testing.T) {
+	now := time.Date(2023, 8, 25, 12, 0, 0, 0, time.UTC)
+
+	type test struct {
+		value        string
+		expected     time.Duration
+		expectedOkay bool
+	}
+
+	tests := map[string]test{
+		"empty":         {value: "", expected: 0, expectedOkay: false},
+		"seconds":       {value: "120", expected: 2 * time.Minute, expectedOkay: true},
+		"negative":      {value: "-5", expected: 0, expectedOkay: true},
+		"http date":     {value: "Fri, 25 Aug 2023 12:00:30 GMT", expected: 30 * time.Second, expectedOkay: true},
+		"date in past":  {value: "Fri, 25 Aug 2023 11:00:00 GMT", expected: 0, expectedOkay: true},
+		"invalid value": {value: "soon", expected: 0, expectedOkay: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, ok := parseRetryAfter(tc.value, now)
+			require.Equal(t, tc.expectedOkay, ok)
+			require.Equal(t, tc.expected, d)
+		})
+	}
+}
+
+func Test_Backoff_GivenJitter_ShouldStayWithinBoundsAndCap(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		d := p.backoff(2, nil)
+		require.GreaterOrEqual(t, d, time.Second)
+		require.LessOrEqual(t, d, 3*time.Second)
+	}
+
+	p.Jitter = 0
+	require.Equal(t, 10*time.Second, p.backoff(5, nil))
+	require.Equal(t, 10*time.Second, p.backoff(100, nil))
+
+	res := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	require.Equal(t, 3*time.Second, p.backoff(1, res))
+}
@@ -16,39 +16,88 @@ package sbom
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
 	"slices"
+	"sync"
+	"time"
 
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/attestation"
+	"github.com/snyk/container-cli/internal/common/cache"
 	"github.com/snyk/container-cli/internal/common/constants"
 	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/registry"
 	"github.com/snyk/container-cli/internal/common/workflows"
 	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
-	sbomconstants "github.com/snyk/container-cli/internal/workflows/sbom/constants"
 	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
+	"github.com/snyk/container-cli/internal/workflows/sbom/policy"
+	"github.com/snyk/container-cli/internal/workflows/sbom/vex"
 	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/local_workflows"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 )
 
 // Workflow represents the SBOM workflow
 type Workflow struct {
 	workflows.BaseWorkflow
-	depGraph   *containerdepgraph.DepGraphWorkflow
-	sbomClient SbomClient
-	errFactory *sbomerrors.SbomErrorFactory
+	depGraph        *containerdepgraph.DepGraphWorkflow
+	sbomClient      SbomClient
+	localSbomClient SbomClient
+	vulnClient      VulnerabilityClient
+	registryClient  RegistryClient
+	errFactory      *sbomerrors.SbomErrorFactory
+	now             func() time.Time
+	// stderr receives the warnings users need to see next to the SBOM printed to stdout.
+	stderr io.Writer
 }
 
-// NewWorkflow creates a new SBOM workflow value
-func NewWorkflow(sbomClient SbomClient, errFactory *sbomerrors.SbomErrorFactory) *Workflow {
+// NewWorkflow creates a new SBOM workflow value. The local client is used instead of the SBOM client
+// when running offline, the vulnerability client tests the depgraphs of images if the SBOMs are to be
+// enriched with vulnerabilities, the registry client resolves image digests and attaches the SBOMs to their images.
+func NewWorkflow(
+	sbomClient, localSbomClient SbomClient,
+	vulnClient VulnerabilityClient,
+	registryClient RegistryClient,
+	errFactory *sbomerrors.SbomErrorFactory,
+) *Workflow {
 	return &Workflow{
 		BaseWorkflow: workflows.BaseWorkflow{
 			Name: "container sbom",
-			Flags: append(
-				[]flags.Flag{flags.FlagSbomFormat},
-				flags.CommonFlags...,
+			Flags: slices.Concat(
+				[]flags.Flag{
+					flags.FlagSbomFormat,
+					flags.FlagTargetsFile,
+					flags.FlagConcurrency,
+					flags.FlagTimeout,
+					flags.FlagRetryMaxAttempts,
+					flags.FlagRetryMaxBackoff,
+					flags.FlagOffline,
+					flags.FlagSbomAPIVersion,
+					flags.FlagSbomAPIPath,
+					flags.FlagSbomAPIMode,
+					flags.FlagOutputFile,
+					flags.FlagSignKey,
+					flags.FlagAttach,
+					flags.FlagVulnerabilities,
+					flags.FlagVex,
+					flags.FlagPolicy,
+					flags.FlagNoCache,
+					flags.FlagJSON,
+				},
+				flags.CacheFlags,
+				flags.CommonFlags,
 			),
 		},
-		depGraph:   containerdepgraph.Workflow,
-		sbomClient: sbomClient,
-		errFactory: errFactory,
+		depGraph:        containerdepgraph.Workflow,
+		sbomClient:      sbomClient,
+		localSbomClient: localSbomClient,
+		vulnClient:      vulnClient,
+		registryClient:  registryClient,
+		errFactory:      errFactory,
+		now:             time.Now,
+		stderr:          os.Stderr,
 	}
 }
 
@@ -57,7 +106,7 @@ func (w *Workflow) Init(e workflow.Engine) error {
 	_, err := e.Register(
 		w.Identifier(),
 		w.GetConfigurationOptionsFromFlagSet(),
-		w.entrypoint,
+		workflows.JSONErrors(w.entrypoint),
 	)
 	return err
 }
@@ -67,85 +116,439 @@ func (w *Workflow) entrypoint(ictx workflow.InvocationContext, _ []workflow.Data
 	logger.Info().Msg("starting the sbom workflow")
 
 	var config = ictx.GetConfiguration()
+	if _, err := flags.Resolve(config, w.Flags); err != nil {
+		return nil, w.errFactory.NewConfigFileError(err)
+	}
 
 	logger.Debug().Msg("getting the sbom format")
 	var format = flags.FlagSbomFormat.GetFlagValue(config)
-	if err := validateSBOMFormat(format, sbomconstants.SbomValidFormats, w.errFactory); err != nil {
+	if err := validateSBOMFormat(format, w.errFactory); err != nil {
 		return nil, err
 	}
 
 	logger.Debug().Msg("getting the platform")
 	var platform = flags.FlagPlatform.GetFlagValue(config)
-	if err := validatePlatform(platform, sbomconstants.ValidPlatforms, w.errFactory); err != nil {
+	if err := validatePlatform(platform, w.errFactory); err != nil {
 		return nil, err
 	}
 
-	logger.Debug().Msg("getting preferred organization id")
-	orgID := config.GetString(configuration.ORGANIZATION)
-	if orgID == "" {
-		return nil, w.errFactory.NewEmptyOrgError()
+	logger.Debug().Msg("getting the vulnerabilities mode")
+	var vulnerabilities = flags.FlagVulnerabilities.GetFlagValue(config)
+	if err := w.validateVulnerabilities(vulnerabilities, format); err != nil {
+		return nil, err
+	}
+
+	opts := generateOptions{client: w.sbomClient, format: format, platform: platform, vulnerabilities: vulnerabilities}
+	opts.offline = flags.FlagOffline.GetFlagValue(config)
+	if opts.offline {
+		if vulnerabilities != "" {
+			return nil, w.errFactory.NewVulnerabilitiesOfflineError()
+		}
+
+		logger.Debug().Msg("generating the sbom locally")
+		opts.client = w.localSbomClient
+	} else {
+		logger.Debug().Msg("getting preferred organization id")
+		opts.orgID = config.GetString(configuration.ORGANIZATION)
+		if opts.orgID == "" {
+			return nil, w.errFactory.NewEmptyOrgError()
+		}
+
+		opts.api = SbomAPI{
+			Version: flags.FlagSbomAPIVersion.GetFlagValue(config),
+			Path:    flags.FlagSbomAPIPath.GetFlagValue(config),
+			Async:   flags.FlagSbomAPIMode.GetFlagValue(config) == sbomAPIModeAsync,
+		}
+		if !validSbomAPIPath(opts.api.Path) {
+			return nil, w.errFactory.NewInvalidSbomAPIPathError(opts.api.Path)
+		}
+		if opts.api.Async && opts.api.Path == "" {
+			return nil, w.errFactory.NewMissingSbomAPIPathError(sbomAPIModeAsync)
+		}
+
+		opts.retry = DefaultRetryPolicy
+		opts.retry.MaxAttempts = flags.FlagRetryMaxAttempts.GetFlagValue(config)
+		if opts.retry.MaxAttempts < 1 {
+			return nil, w.errFactory.NewInvalidRetryMaxAttemptsError(opts.retry.MaxAttempts)
+		}
+		opts.retry.MaxBackoff = flags.FlagRetryMaxBackoff.GetFlagValue(config)
+		if opts.retry.MaxBackoff < 0 {
+			return nil, w.errFactory.NewInvalidRetryMaxBackoffError(opts.retry.MaxBackoff)
+		}
+	}
+
+	if signKey := flags.FlagSignKey.GetFlagValue(config); signKey != "" {
+		logger.Debug().Msg("loading the signing key")
+		signer, err := w.loadSigner(signKey, format)
+		if err != nil {
+			return nil, err
+		}
+		opts.signer = signer
+	}
+
+	if vexFiles := flags.FlagVex.GetFlagValue(config); len(vexFiles) > 0 {
+		if vulnerabilities == "" {
+			return nil, w.errFactory.NewVexWithoutVulnerabilitiesError()
+		}
+		logger.Debug().Msg("loading the vex statements")
+		for _, path := range vexFiles {
+			statements, err := vex.Load(path)
+			if err != nil {
+				return nil, w.errFactory.NewVexFileError(path, err)
+			}
+			opts.vex = append(opts.vex, statements...)
+		}
+	}
+
+	if policyFile := flags.FlagPolicy.GetFlagValue(config); policyFile != "" {
+		logger.Debug().Msg("loading the policy")
+		p, err := policy.Load(policyFile)
+		if err != nil {
+			return nil, w.errFactory.NewPolicyFileError(policyFile, err)
+		}
+		opts.policy = p
+	}
+
+	if !flags.FlagNoCache.GetFlagValue(config) {
+		c, err := cache.NewFromConfiguration(config)
+		if err != nil {
+			return nil, w.errFactory.NewCacheConfigError(err)
+		}
+		opts.cache = c
+	}
+
+	opts.timeout = flags.FlagTimeout.GetFlagValue(config)
+	if opts.timeout < 0 {
+		return nil, w.errFactory.NewInvalidTimeoutError(opts.timeout)
+	}
+	ctx, interrupts, cancel := workflowContext(opts.timeout)
+	opts.interrupts = interrupts
+	defer cancel()
+	if !opts.offline {
+		ctx = withRetryPolicy(ctx, opts.retry)
+	}
+
+	opts.attach = flags.FlagAttach.GetFlagValue(config)
+	opts.credentials = registry.Credentials{
+		Username: flags.FlagUsername.GetFlagValue(config),
+		Password: flags.FlagPassword.GetFlagValue(config),
+	}
+
+	args := workflows.TargetArgs(config)
+	targetsFile := flags.FlagTargetsFile.GetFlagValue(config)
+	if targetsFile == "" && len(args) <= 1 {
+		// the host passes a single positional argument either as a string or as a list of one.
+		if len(args) == 1 {
+			config.Set(constants.ContainerTargetArgName, args[0])
+		}
+
+		// documents which are not processed any further are streamed into the output file, large SBOMs are never
+		// held in memory then.
+		if opts.vulnerabilities == "" && opts.policy == nil && opts.signer == nil && !opts.attach {
+			opts.outputFile = flags.FlagOutputFile.GetFlagValue(config)
+		}
+
+		out, err := w.generateSbom(ctx, ictx.GetEngine(), logger, config, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Info().Msg("successfully generated SBOM document")
+		out.index = 1
+		if outputFile := flags.FlagOutputFile.GetFlagValue(config); outputFile != "" {
+			summary, err := writeOutputFiles(outputFile, []sbomOutput{*out})
+			if err != nil {
+				return nil, w.errFactory.NewOutputFileError(outputFile, err)
+			}
+			if len(out.staleVex) > 0 {
+				summary += staleVexReport(out.target, out.staleVex)
+			}
+			return []workflow.Data{w.newSummaryData(summary)}, nil
+		}
+		if len(out.staleVex) > 0 {
+			// the SBOM is printed to stdout, where the report would end up in the document.
+			_, _ = fmt.Fprint(w.stderr, staleVexReport(out.target, out.staleVex))
+		}
+		return w.newOutputData(out, ""), nil
 	}
 
-	logger.Debug().Msg("invoking depgraph workflow")
-	depGraphs, err := ictx.GetEngine().InvokeWithConfig(w.depGraph.Identifier(), config.Clone())
+	targets, err := readTargets(args, targetsFile)
 	if err != nil {
-		return nil, w.errFactory.NewDepGraphWorkflowError(err)
+		return nil, w.errFactory.NewTargetsFileError(targetsFile, err)
 	}
 
+	outputFile := flags.FlagOutputFile.GetFlagValue(config)
+	if outputFile != "" && len(targets) > 1 && !hasOutputFilePlaceholder(outputFile) {
+		return nil, w.errFactory.NewOutputFileTemplateError(outputFile)
+	}
+
+	return w.generateSboms(ctx, ictx, logger, config, targets, opts)
+}
+
+// generateSboms generates the SBOMs of multiple images concurrently. SBOMs of successfully analysed
+// images are always returned, the workflow only fails if at least one image failed.
+func (w *Workflow) generateSboms(
+	ctx context.Context,
+	ictx workflow.InvocationContext,
+	logger *zerolog.Logger,
+	config configuration.Configuration,
+	targets []string,
+	opts generateOptions,
+) ([]workflow.Data, error) {
+	engine := ictx.GetEngine()
+
+	concurrency := flags.FlagConcurrency.GetFlagValue(config)
+	if concurrency < 1 {
+		return nil, w.errFactory.NewInvalidConcurrencyError(concurrency)
+	}
+
+	logger.Info().Msgf("generating SBOMs for %d images with a concurrency of %d", len(targets), concurrency)
+
+	results := make([]targetResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			targetConfig := config.Clone()
+			targetConfig.Set(constants.ContainerTargetArgName, target)
+			out, err := w.generateSbom(ctx, engine, logger, targetConfig, opts)
+			results[i] = targetResult{target: target, output: out, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var output []workflow.Data
+	var written []sbomOutput
+	for i, r := range results {
+		if r.err == nil {
+			// the index of the SBOM is the position of its image in the input, regardless of earlier failures.
+			r.output.index = i + 1
+			output = append(output, w.newOutputData(r.output, r.target)...)
+			written = append(written, *r.output)
+		}
+	}
+
+	summary, failed := summarize(results)
+	if outputFile := flags.FlagOutputFile.GetFlagValue(config); outputFile != "" {
+		files, err := writeOutputFiles(outputFile, written)
+		if err != nil {
+			return nil, w.errFactory.NewOutputFileError(outputFile, err)
+		}
+		summary += "\n" + files
+		if failed > 0 {
+			return nil, w.errFactory.NewMultipleSbomsFailedError(failed, len(results), summary)
+		}
+		return []workflow.Data{w.newSummaryData(summary)}, nil
+	}
+
+	if failed > 0 {
+		// the host only prints the output of successful workflows, so we print the SBOMs we managed to
+		// generate ourselves before reporting the failure.
+		if len(output) > 0 {
+			if _, err := engine.InvokeWithInput(localworkflows.WORKFLOWID_OUTPUT_WORKFLOW, output); err != nil {
+				logger.Error().Err(err).Msg("failed to print the generated SBOM documents")
+			}
+		}
+		return nil, w.errFactory.NewMultipleSbomsFailedError(failed, len(results), summary)
+	}
+
+	logger.Info().Msgf("successfully generated %d SBOM documents", len(written))
+	return append(output, w.newSummaryData(summary)), nil
+}
+
+// generateOptions holds the settings which apply to the SBOMs of all images.
+type generateOptions struct {
+	client   SbomClient
+	orgID    string
+	format   string
+	platform string
+	// offline is set if the SBOMs are generated locally, image digests are not resolved from registries then.
+	offline bool
+	// api is the version and endpoint of the SBOM API, unless the SBOM is generated locally.
+	api SbomAPI
+	// retry is the retry policy of the requests to the SBOM API, unless the SBOM is generated locally.
+	retry RetryPolicy
+	// vulnerabilities is the mode the test results of the images are added in, if any.
+	vulnerabilities string
+	// vex are the imported VEX statements applied to the vulnerabilities.
+	vex []vex.Statement
+	// policy is set if the SBOMs are to be evaluated against a policy.
+	policy *policy.Policy
+	// signer is set if the SBOMs are to be wrapped into signed attestations.
+	signer attestation.Signer
+	// attach pushes the SBOMs to the registries of their images.
+	attach bool
+	// credentials authenticate against the registries of the images.
+	credentials registry.Credentials
+	// cache holds the depgraphs of images analysed before, unless caching is disabled.
+	cache *cache.Cache
+	// timeout bounds the generation of all SBOMs, 0 means no timeout.
+	timeout time.Duration
+	// interrupts cancel the generation of the SBOMs, unless they are paused while it can't be canceled.
+	interrupts *interruptTrap
+	// outputFile is the --output-file template the SBOM of a single image is streamed into, if set.
+	outputFile string
+}
+
+// generateSbom generates the SBOM of the image configured as target in the given configuration. The generation
+// is aborted once the context is done.
+func (w *Workflow) generateSbom(
+	ctx context.Context,
+	engine workflow.Engine,
+	logger *zerolog.Logger,
+	config configuration.Configuration,
+	opts generateOptions,
+) (*sbomOutput, error) {
 	imageAndVersion := config.GetString(constants.ContainerTargetArgName)
-	imageName, imageVersion, err := depGraphMetadata(imageAndVersion)
+	subject, err := parseSubject(imageAndVersion)
 	if err != nil {
 		return nil, w.errFactory.NewDepGraphWorkflowError(err)
 	}
 
-	logger.Debug().Msgf("image name: '%v', image version: '%v'", imageName, imageVersion)
+	// the digest is resolved first, as it identifies the cached depgraphs of the image.
+	subject = w.resolveSubject(ctx, logger, imageAndVersion, subject, opts)
+	if err := w.interrupted(ctx, imageAndVersion, phaseResolve, opts); err != nil {
+		return nil, err
+	}
+	logger.Debug().Msgf("image name: '%v', image version: '%v'", subject.Name, subject.Version)
+
+	depGraphs, err := w.invokeDepGraph(ctx, engine, logger, config, subject, opts)
+	if err != nil {
+		if ierr := w.interrupted(ctx, imageAndVersion, phaseDepGraph, opts); ierr != nil {
+			return nil, ierr
+		}
+		return nil, w.errFactory.NewDepGraphWorkflowError(err)
+	}
+
 	depGraphsBytes, err := parseDepGraph(depGraphs)
 	if err != nil {
 		return nil, w.errFactory.NewDepGraphWorkflowError(err)
 	}
 
-	sbomResult, err := w.sbomClient.GetSbomForDepGraph(
-		context.Background(),
-		orgID,
-		format,
-		platform,
-		&GetSbomForDepGraphRequest{
-			DepGraphs: depGraphsBytes,
-			Subject: Subject{
-				Name:    imageName,
-				Version: imageVersion,
-			},
-		},
-	)
+	req := &GetSbomForDepGraphRequest{
+		DepGraphs: depGraphsBytes,
+		Subject:   subject,
+		API:       opts.api,
+	}
+	var sink *fileSink
+	if opts.outputFile != "" {
+		sink = &fileSink{template: opts.outputFile, target: imageAndVersion, subject: subject}
+		req.Output = sink
+	}
+
+	sbomResult, err := opts.client.GetSbomForDepGraph(ctx, opts.orgID, opts.format, opts.platform, req)
 	if err != nil {
+		if sink != nil {
+			sink.discard()
+			if sink.err != nil {
+				return nil, w.errFactory.NewOutputFileError(sink.path, sink.err)
+			}
+		}
+		if ierr := w.interrupted(ctx, imageAndVersion, phaseSbom, opts); ierr != nil {
+			return nil, ierr
+		}
 		return nil, err
 	}
 
-	logger.Info().Msg("successfully generated SBOM document")
-	return []workflow.Data{
-		workflow.NewDataFromInput(nil, w.typeIdentifier(), sbomResult.MIMEType, sbomResult.Doc),
-	}, nil
+	out := &sbomOutput{target: imageAndVersion, subject: subject, result: sbomResult}
+	if sink != nil {
+		if err := sink.finish(sbomResult); err != nil {
+			return nil, w.errFactory.NewOutputFileError(sink.path, err)
+		}
+		out.path = sink.path
+	}
+	if opts.vulnerabilities != "" {
+		logger.Debug().Msg("testing the depgraphs for vulnerabilities")
+		if err := w.addVulnerabilities(ctx, out, depGraphsBytes, opts); err != nil {
+			if ierr := w.interrupted(ctx, imageAndVersion, phaseVulnerabilities, opts); ierr != nil {
+				return nil, ierr
+			}
+			return nil, err
+		}
+		if len(out.staleVex) > 0 {
+			logger.Warn().Msg(staleVexReport(imageAndVersion, out.staleVex))
+		}
+		sbomResult = out.result
+	}
+
+	if opts.policy != nil {
+		logger.Debug().Msg("evaluating the sbom against the policy")
+		if err := w.enforcePolicy(sbomResult, imageAndVersion, opts.policy); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.signer != nil {
+		logger.Debug().Msg("signing the sbom attestation")
+		if sbomResult, err = w.attest(sbomResult, imageAndVersion, subject, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.attach {
+		logger.Debug().Msgf("attaching the sbom to %s", imageAndVersion)
+		if err := w.attach(ctx, logger, sbomResult, imageAndVersion, subject, opts); err != nil {
+			if ierr := w.interrupted(ctx, imageAndVersion, phaseAttach, opts); ierr != nil {
+				return nil, ierr
+			}
+			return nil, err
+		}
+	}
+	out.result = sbomResult
+	return out, nil
+}
+
+// newOutputData wraps the SBOM document, followed by the accompanying VEX document if any, into workflow data.
+func (w *Workflow) newOutputData(out *sbomOutput, target string) []workflow.Data {
+	data := []workflow.Data{w.newSbomData(out.result, target)}
+	if out.vex != nil {
+		data = append(data, w.newSbomData(out.vex, target))
+	}
+	return data
+}
+
+// newSbomData wraps the SBOM document into workflow data. The target is recorded as content location,
+// unless it is empty.
+func (w *Workflow) newSbomData(sbomResult *GetSbomForDepGraphResult, target string) workflow.Data {
+	data := workflow.NewDataFromInput(nil, w.typeIdentifier(), sbomResult.MIMEType, sbomResult.Doc)
+	if target != "" {
+		data.SetMetaData(constants.HeaderContentLocation, target)
+	}
+	return data
+}
+
+// newSummaryData wraps a human readable summary of the run into workflow data.
+func (w *Workflow) newSummaryData(summary string) workflow.Data {
+	return workflow.NewData(w.typeIdentifier(), "text/plain", []byte(summary))
 }
 
 func (w *Workflow) typeIdentifier() workflow.Identifier {
 	return workflow.NewTypeIdentifier(w.Identifier(), constants.DataTypeSbom)
 }
 
-func validateSBOMFormat(candidate string, sbomFormats []string, errFactory *sbomerrors.SbomErrorFactory) error {
+// validateSBOMFormat checks the format if it was not passed on the command line, where the flag validates it.
+func validateSBOMFormat(candidate string, errFactory *sbomerrors.SbomErrorFactory) error {
 	if candidate == "" {
-		return errFactory.NewEmptySbomFormatError(sbomFormats)
+		return errFactory.NewEmptySbomFormatError(flags.FlagSbomFormat.AllowedValues())
 	}
 
-	if !slices.Contains(sbomFormats, candidate) {
-		return errFactory.NewInvalidSbomFormatError(candidate, sbomFormats)
+	if !flags.FlagSbomFormat.IsAllowed(candidate) {
+		return errFactory.NewInvalidSbomFormatError(candidate, flags.FlagSbomFormat.AllowedValues())
 	}
 
 	return nil
 }
 
-func validatePlatform(candidate string, platforms []string, errFactory *sbomerrors.SbomErrorFactory) error {
-	if candidate != "" && !slices.Contains(platforms, candidate) {
-		return errFactory.NewInvalidPlatformError(candidate, platforms)
+func validatePlatform(candidate string, errFactory *sbomerrors.SbomErrorFactory) error {
+	if candidate != "" && !flags.FlagPlatform.IsAllowed(candidate) {
+		return errFactory.NewInvalidPlatformError(candidate, flags.FlagPlatform.AllowedValues())
 	}
 	return nil
 }
@@ -0,0 +1,131 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+)
+
+// DefaultSbomAPIVersion is the version of the SBOM API requested unless another one is configured.
+const DefaultSbomAPIVersion = "2022-03-31~experimental"
+
+const (
+	// orgIDPlaceholder is replaced by the organization id in the paths of the SBOM API.
+	orgIDPlaceholder = "{org_id}"
+	// defaultSbomAPIPath is the endpoint of the default version, which returns the SBOM in the response.
+	defaultSbomAPIPath = "/hidden/orgs/" + orgIDPlaceholder + "/sbom"
+	// sbomAPIModeAsync selects the generation of the SBOM in a job.
+	sbomAPIModeAsync = "async"
+)
+
+// SbomAPI selects the version and endpoint of the Snyk SBOM API. The zero value selects the default version.
+// Neither is negotiated with the API: they are requested as configured, and deprecated versions are only
+// reported, see checkDeprecation.
+type SbomAPI struct {
+	// Version is the version requested, e.g. 2022-03-31~experimental.
+	Version string
+	// Path is the path of the endpoint, in which {org_id} is replaced by the organization id. It defaults to
+	// the endpoint serving the default version.
+	Path string
+	// Async creates a job generating the SBOM, which is polled until it finishes, instead of receiving the
+	// SBOM in the response. There is no default endpoint for jobs, so Path needs to be set.
+	Async bool
+}
+
+// withDefaults fills in the default version and endpoint.
+func (a SbomAPI) withDefaults() SbomAPI {
+	if a.Version == "" {
+		a.Version = DefaultSbomAPIVersion
+	}
+	if a.Path == "" {
+		a.Path = defaultSbomAPIPath
+	}
+	return a
+}
+
+// url returns the URL of the endpoint for the organization.
+func (a SbomAPI) url(apiHost, orgID string) string {
+	return apiHost + strings.ReplaceAll(a.Path, orgIDPlaceholder, url.PathEscape(orgID))
+}
+
+// validSbomAPIPath returns true if the path is empty or an absolute path naming the organization.
+func validSbomAPIPath(path string) bool {
+	return path == "" || (strings.HasPrefix(path, "/") && strings.Contains(path, orgIDPlaceholder))
+}
+
+// requestedVersion returns the API version the response was requested with, if any.
+func requestedVersion(res *http.Response) string {
+	if res.Request == nil {
+		return ""
+	}
+	return res.Request.URL.Query().Get("version")
+}
+
+// checkDeprecation warns the user on stderr once if the API announces that the requested version is deprecated, see
+// https://datatracker.ietf.org/doc/html/rfc9745 and https://datatracker.ietf.org/doc/html/rfc8594.
+func (c *HTTPSbomClient) checkDeprecation(res *http.Response) {
+	version := requestedVersion(res)
+	if version == "" {
+		return
+	}
+	if served := res.Header.Get(constants.HeaderSnykVersionServed); served != "" && served != version {
+		c.logger.Debug().Msgf("requested version %s of the sbom api, version %s was served", version, served)
+	}
+
+	deprecation := res.Header.Get(constants.HeaderDeprecation)
+	sunset := res.Header.Get(constants.HeaderSunset)
+	if deprecation == "" && sunset == "" {
+		return
+	}
+	c.deprecationWarning.Do(func() {
+		warning := deprecationWarning(version, deprecation, sunset)
+		c.logger.Warn().Msg(warning)
+		_, _ = fmt.Fprintf(c.stderr, "Warning: %s.\n", warning)
+	})
+}
+
+func deprecationWarning(version, deprecation, sunset string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "version %s of the Snyk SBOM API is deprecated", version)
+	if date := headerDate(deprecation); date != "" && deprecation != "true" {
+		fmt.Fprintf(&sb, " since %s", date)
+	}
+	if date := headerDate(sunset); date != "" {
+		fmt.Fprintf(&sb, " and will be removed on %s", date)
+	}
+	sb.WriteString(", please upgrade the Snyk CLI or select a newer version with --sbom-api-version")
+	return sb.String()
+}
+
+// headerDate formats the date of a Deprecation or Sunset header, which is either a structured field date
+// (@<unix seconds>) or an HTTP date. Other values are returned as they are.
+func headerDate(value string) string {
+	if seconds, ok := strings.CutPrefix(value, "@"); ok {
+		if s, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+			return time.Unix(s, 0).UTC().Format(time.DateOnly)
+		}
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.UTC().Format(time.DateOnly)
+	}
+	return value
+}
@@ -0,0 +1,195 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/constants"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SbomAPI_WithDefaults_GivenVersionAndPath_ShouldSelectEndpoint(t *testing.T) {
+	tests := map[string]struct {
+		api      SbomAPI
+		expected SbomAPI
+	}{
+		"default": {
+			expected: SbomAPI{Version: DefaultSbomAPIVersion, Path: "/hidden/orgs/{org_id}/sbom"},
+		},
+		"experimental version": {
+			api:      SbomAPI{Version: "2023-03-01~experimental"},
+			expected: SbomAPI{Version: "2023-03-01~experimental", Path: "/hidden/orgs/{org_id}/sbom"},
+		},
+		"stable version": {
+			api:      SbomAPI{Version: "2024-10-15"},
+			expected: SbomAPI{Version: "2024-10-15", Path: "/hidden/orgs/{org_id}/sbom"},
+		},
+		"async": {
+			api:      SbomAPI{Path: "/rest/orgs/{org_id}/sbom_jobs", Async: true},
+			expected: SbomAPI{Version: DefaultSbomAPIVersion, Path: "/rest/orgs/{org_id}/sbom_jobs", Async: true},
+		},
+		"custom path": {
+			api:      SbomAPI{Path: "/beta/orgs/{org_id}/sbom"},
+			expected: SbomAPI{Version: DefaultSbomAPIVersion, Path: "/beta/orgs/{org_id}/sbom"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.api.withDefaults())
+		})
+	}
+}
+
+func Test_ValidSbomAPIPath_GivenPath_ShouldRequireOrgPlaceholder(t *testing.T) {
+	require.True(t, validSbomAPIPath(""))
+	require.True(t, validSbomAPIPath("/rest/orgs/{org_id}/sbom_jobs"))
+	require.False(t, validSbomAPIPath("/rest/orgs/sbom_jobs"))
+	require.False(t, validSbomAPIPath("rest/orgs/{org_id}/sbom_jobs"))
+}
+
+func Test_DeprecationWarning_GivenHeaders_ShouldNameDates(t *testing.T) {
+	tests := map[string]struct {
+		deprecation string
+		sunset      string
+		expected    string
+	}{
+		"structured field date": {
+			deprecation: "@1735689600",
+			expected:    "version v1 of the Snyk SBOM API is deprecated since 2025-01-01",
+		},
+		"boolean deprecation and sunset": {
+			deprecation: "true",
+			sunset:      "Wed, 01 Jul 2026 00:00:00 GMT",
+			expected:    "version v1 of the Snyk SBOM API is deprecated and will be removed on 2026-07-01",
+		},
+		"sunset only": {
+			sunset:   "2026-07-01",
+			expected: "version v1 of the Snyk SBOM API is deprecated and will be removed on 2026-07-01",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(
+				t,
+				tc.expected+", please upgrade the Snyk CLI or select a newer version with --sbom-api-version",
+				deprecationWarning("v1", tc.deprecation, tc.sunset),
+			)
+		})
+	}
+}
+
+func Test_GetSbomForDepGraph_GivenDeprecatedVersion_ShouldWarnOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(constants.HeaderDeprecation, "@1735689600")
+		w.Header().Set(constants.HeaderSunset, "Wed, 01 Jul 2026 00:00:00 GMT")
+		w.Header().Set(constants.HeaderSnykVersionServed, "2022-03-31~experimental")
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSON)
+		_, err := w.Write([]byte("{}"))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:    server.URL,
+		Client:     http.DefaultClient,
+		Logger:     &zlog.Logger,
+		ErrFactory: errFactory,
+	})
+	var stderr bytes.Buffer
+	client.stderr = &stderr
+
+	for range 2 {
+		_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.4+json", "",
+			&GetSbomForDepGraphRequest{Subject: Subject{Name: "alpine"}})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, "Warning: version 2022-03-31~experimental of the Snyk SBOM API is deprecated "+
+		"since 2025-01-01 and will be removed on 2026-07-01, please upgrade the Snyk CLI or select a newer "+
+		"version with --sbom-api-version.\n", stderr.String())
+}
+
+func Test_GetSbomForDepGraph_GivenRemovedVersion_ShouldReturnSbomAPIUnavailableError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:    server.URL,
+		Client:     http.DefaultClient,
+		Logger:     &zlog.Logger,
+		ErrFactory: errFactory,
+	})
+
+	_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.4+json", "",
+		&GetSbomForDepGraphRequest{API: SbomAPI{Version: "2023-03-01~experimental"}})
+
+	require.ErrorContains(t, err, "Version 2023-03-01~experimental of the Snyk SBOM API is no longer available.")
+	requireErrorCode(t, containererrors.CodeSbomAPIUnavailable, err)
+}
+
+func Test_Entrypoint_GivenSbomAPIFlags_ShouldSendRequestToConfiguredAPI(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagSbomAPIVersion.Name, "2024-10-15")
+	config.Set(flags.FlagSbomAPIPath.Name, "/rest/orgs/{org_id}/sbom_jobs")
+	config.Set(flags.FlagSbomAPIMode.Name, "async")
+
+	mockEngine.EXPECT().InvokeWithConfig(gomock.Any(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context, _, _, _ string, req *GetSbomForDepGraphRequest,
+		) (*GetSbomForDepGraphResult, error) {
+			require.Equal(t, SbomAPI{Version: "2024-10-15", Path: "/rest/orgs/{org_id}/sbom_jobs", Async: true},
+				req.API)
+			return &GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: constants.ContentTypeJSON}, nil
+		})
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+}
+
+func Test_Entrypoint_GivenSbomAPIPathWithoutOrg_ShouldReturnInvalidSbomAPIPathError(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagSbomAPIPath.Name, "/rest/sbom_jobs")
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+
+	require.EqualError(t, err, errFactory.NewInvalidSbomAPIPathError("/rest/sbom_jobs").Error())
+	requireErrorCode(t, containererrors.CodeInvalidSbomAPIPath, err)
+}
+
+func Test_Entrypoint_GivenAsyncSbomAPIModeWithoutPath_ShouldReturnMissingSbomAPIPathError(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagSbomAPIMode.Name, "async")
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+
+	require.EqualError(t, err, errFactory.NewMissingSbomAPIPathError("async").Error())
+	requireErrorCode(t, containererrors.CodeMissingSbomAPIPath, err)
+}
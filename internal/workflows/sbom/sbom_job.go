@@ -0,0 +1,207 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/constants"
+)
+
+// actionConvert describes the conversion of depgraphs to an SBOM in errors.
+const actionConvert = "could not convert to SBOM"
+
+// defaultPollInterval is the wait between two polls of an SBOM job, unless the API asks for another one.
+const defaultPollInterval = 2 * time.Second
+
+// defaultMaxJobWait is how long an SBOM job is polled for before it is given up.
+const defaultMaxJobWait = 30 * time.Minute
+
+// The statuses of an SBOM job the client acts on, jobs in any other status are still running.
+const (
+	sbomJobFinished = "finished"
+	sbomJobErrored  = "errored"
+)
+
+// sbomJobRequest is the JSON:API document creating a job which generates the SBOM of the request.
+type sbomJobRequest struct {
+	req *GetSbomForDepGraphRequest
+}
+
+func (r sbomJobRequest) writeJSON(w io.Writer) error {
+	if _, err := io.WriteString(w, `{"data":{"type":"sbom_job","attributes":`); err != nil {
+		return err
+	}
+	if err := r.req.writeJSON(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "}}")
+	return err
+}
+
+// sbomJob is the JSON:API document describing an SBOM job.
+type sbomJob struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status string `json:"status"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Links struct {
+		// Self is polled while the job is running.
+		Self string `json:"self"`
+		// Related is the SBOM document, once the job finished.
+		Related string `json:"related"`
+	} `json:"links"`
+	Errors []jsonAPIError `json:"errors"`
+}
+
+// detail returns what the API reported about a failed job.
+func (j *sbomJob) detail() string {
+	var details []string
+	for _, e := range j.Errors {
+		if e.Detail != "" {
+			details = append(details, e.Detail)
+		} else if e.Title != "" {
+			details = append(details, e.Title)
+		}
+	}
+	return strings.Join(details, "; ")
+}
+
+// runSbomJob creates a job generating the SBOM and polls it until it completes, or gives it up once the waits
+// between the polls exceed maxJobWait. It returns the response holding the SBOM document, which the API either
+// links from the finished job or redirects the poll to.
+func (c *HTTPSbomClient) runSbomJob(
+	ctx context.Context,
+	jobsURL, orgID string,
+	req *GetSbomForDepGraphRequest,
+) (*http.Response, error) {
+	body := &requestBody{value: sbomJobRequest{req}, gzip: true, mediaType: constants.ContentTypeJSONAPI}
+	res, err := c.send(ctx, http.MethodPost, jobsURL, body, orgID, actionConvert)
+	if err != nil {
+		return nil, err
+	}
+
+	// the job is polled at the URL it was last linked from, polls may omit the link.
+	var pollURL string
+	var waited time.Duration
+	for isJSONAPI(res) {
+		job, err := c.decodeJob(res)
+		if err != nil {
+			return nil, err
+		}
+
+		var next string
+		switch job.Data.Attributes.Status {
+		case sbomJobFinished:
+			if job.Links.Related == "" {
+				return nil, c.errFactory.NewInternalError(
+					fmt.Errorf("sbom job %q finished without a link to the sbom", job.Data.ID),
+				)
+			}
+			if next, err = c.jobLink(jobsURL, job, job.Links.Related); err != nil {
+				return nil, err
+			}
+		case sbomJobErrored:
+			return nil, c.errFactory.NewSbomJobFailedError(job.Data.ID, job.detail())
+		default:
+			if link := cmp.Or(job.Links.Self, res.Header.Get(constants.HeaderLocation)); link != "" {
+				if pollURL, err = c.jobLink(jobsURL, job, link); err != nil {
+					return nil, err
+				}
+			}
+			if pollURL == "" {
+				return nil, c.errFactory.NewInternalError(
+					fmt.Errorf("sbom job %q (status %q) has no link to poll", job.Data.ID, job.Data.Attributes.Status),
+				)
+			}
+			next = pollURL
+
+			wait := c.pollInterval
+			if d, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				wait = d
+			}
+			if waited += wait; waited > c.maxJobWait {
+				return nil, c.errFactory.NewSbomJobTimeoutError(job.Data.ID, c.maxJobWait)
+			}
+			c.logger.Debug().Msgf("sbom job %s is %s, polling again in %s",
+				job.Data.ID, job.Data.Attributes.Status, wait)
+			if err := c.sleep(ctx, wait); err != nil {
+				return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to wait for the sbom job: %w", err))
+			}
+		}
+
+		if res, err = c.send(ctx, http.MethodGet, next, nil, orgID, actionConvert); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// decodeJob reads the job described by the response and closes its body.
+func (c *HTTPSbomClient) decodeJob(res *http.Response) (*sbomJob, error) {
+	defer res.Body.Close()
+
+	var job sbomJob
+	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+		return nil, c.errFactory.NewInternalError(fmt.Errorf("failed to decode sbom job: %w", err))
+	}
+	return &job, nil
+}
+
+// jobLink resolves a link of the job, see resolveJobLink.
+func (c *HTTPSbomClient) jobLink(jobsURL string, job *sbomJob, link string) (string, error) {
+	u, err := resolveJobLink(jobsURL, link)
+	if err != nil {
+		return "", c.errFactory.NewInternalError(fmt.Errorf("invalid link of sbom job %q: %w", job.Data.ID, err))
+	}
+	return u, nil
+}
+
+// isJSONAPI returns true if the response is a JSON:API document, as opposed to an SBOM document.
+func isJSONAPI(res *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get(constants.HeaderContentType))
+	return mediaType == constants.ContentTypeJSONAPI
+}
+
+// resolveJobLink resolves a link of a job against the URL the job was created with. The links of the REST API
+// don't repeat the version, which is then taken from that URL.
+func resolveJobLink(jobsURL, link string) (string, error) {
+	base, err := url.Parse(jobsURL)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	if query.Get("version") == "" {
+		query.Set("version", base.Query().Get("version"))
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
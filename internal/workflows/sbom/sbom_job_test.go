@@ -0,0 +1,218 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/constants"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const jobsVersion = "2024-10-15"
+
+// jobsAPI is the SBOM API generating SBOMs in jobs.
+var jobsAPI = SbomAPI{Version: jobsVersion, Path: "/rest/orgs/{org_id}/sbom_jobs", Async: true}
+
+// newJobTestClient returns a client of the server, which records the waits between polls instead of waiting.
+func newJobTestClient(server *httptest.Server, sleeps *[]time.Duration) *HTTPSbomClient {
+	client := NewHTTPSbomClient(HTTPSbomClientConfig{
+		APIHost:    server.URL,
+		Client:     http.DefaultClient,
+		Logger:     &zlog.Logger,
+		ErrFactory: errFactory,
+	})
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return nil
+	}
+	return client
+}
+
+func writeJob(t *testing.T, w http.ResponseWriter, status int, job string) {
+	t.Helper()
+
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeJSONAPI)
+	w.WriteHeader(status)
+	_, err := w.Write([]byte(job))
+	require.NoError(t, err)
+}
+
+func Test_GetSbomForDepGraph_GivenAsyncMode_ShouldPollSbomJobUntilFinished(t *testing.T) {
+	jobsPath := fmt.Sprintf("/rest/orgs/%s/sbom_jobs", orgID)
+	jobPath := jobsPath + "/job-1"
+	req := &GetSbomForDepGraphRequest{
+		DepGraphs: []json.RawMessage{json.RawMessage(`{"schemaVersion":"1.2.0"}`)},
+		Subject:   Subject{Name: "alpine", Version: "3.17.0"},
+		API:       jobsAPI,
+	}
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST " + jobsPath:
+			require.Equal(t, "version="+jobsVersion+"&format=cyclonedx1.5%2Bjson", r.URL.RawQuery)
+			require.Equal(t, constants.ContentTypeJSONAPI, r.Header.Get(constants.HeaderContentType))
+			gz, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(gz)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"data":{"type":"sbom_job","attributes":{"depGraphs":[{"schemaVersion":"1.2.0"}],`+
+				`"subject":{"name":"alpine","version":"3.17.0"}}}}`, string(body))
+
+			writeJob(t, w, http.StatusAccepted, `{"data":{"id":"job-1","attributes":{"status":"pending"}},`+
+				`"links":{"self":"`+jobPath+`"}}`)
+		case "GET " + jobPath:
+			require.Equal(t, jobsVersion, r.URL.Query().Get("version"))
+			polls++
+			if polls == 1 {
+				w.Header().Set("Retry-After", "5")
+				writeJob(t, w, http.StatusOK, `{"data":{"id":"job-1","attributes":{"status":"processing"}}}`)
+				return
+			}
+			writeJob(t, w, http.StatusOK, `{"data":{"id":"job-1","attributes":{"status":"finished"}},`+
+				`"links":{"self":"`+jobPath+`","related":"`+jobPath+`/sbom"}}`)
+		case "GET " + jobPath + "/sbom":
+			require.Equal(t, jobsVersion, r.URL.Query().Get("version"))
+			w.Header().Set(constants.HeaderContentType, "application/vnd.cyclonedx+json")
+			_, err := w.Write([]byte(`{"bomFormat":"CycloneDX"}`))
+			require.NoError(t, err)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	client := newJobTestClient(server, &sleeps)
+
+	res, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.5+json", "", req)
+	require.NoError(t, err)
+
+	require.Equal(t, &GetSbomForDepGraphResult{
+		Doc:      []byte(`{"bomFormat":"CycloneDX"}`),
+		MIMEType: "application/vnd.cyclonedx+json",
+	}, res)
+	require.Equal(t, 2, polls)
+	// the job is polled at the default interval, unless the API asks for another one, and no longer once finished.
+	require.Equal(t, []time.Duration{defaultPollInterval, 5 * time.Second}, sleeps)
+}
+
+func Test_GetSbomForDepGraph_GivenSbomJobRedirectingToDocument_ShouldReturnDocument(t *testing.T) {
+	jobsPath := fmt.Sprintf("/rest/orgs/%s/sbom_jobs", orgID)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case jobsPath:
+			w.Header().Set(constants.HeaderLocation, jobsPath+"/job-1")
+			writeJob(t, w, http.StatusAccepted, `{"data":{"id":"job-1","attributes":{"status":"pending"}}}`)
+		case jobsPath + "/job-1":
+			http.Redirect(w, r, jobsPath+"/job-1/sbom?version="+jobsVersion, http.StatusSeeOther)
+		case jobsPath + "/job-1/sbom":
+			w.Header().Set(constants.HeaderContentType, "application/spdx+json")
+			_, err := w.Write([]byte(`{"spdxVersion":"SPDX-2.3"}`))
+			require.NoError(t, err)
+		}
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	client := newJobTestClient(server, &sleeps)
+
+	res, err := client.GetSbomForDepGraph(context.Background(), orgID, "spdx2.3+json", "",
+		&GetSbomForDepGraphRequest{API: jobsAPI})
+	require.NoError(t, err)
+
+	require.Equal(t, &GetSbomForDepGraphResult{
+		Doc:      []byte(`{"spdxVersion":"SPDX-2.3"}`),
+		MIMEType: "application/spdx+json",
+	}, res)
+	require.Len(t, sleeps, 1)
+}
+
+func Test_GetSbomForDepGraph_GivenErroredSbomJob_ShouldReturnSbomJobFailedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJob(t, w, http.StatusAccepted, `{"data":{"id":"job-1","attributes":{"status":"errored"}},`+
+			`"errors":[{"detail":"the depgraph is invalid"}]}`)
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	client := newJobTestClient(server, &sleeps)
+
+	_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.5+json", "",
+		&GetSbomForDepGraphRequest{API: SbomAPI{Version: jobsVersion, Path: "/custom/{org_id}/jobs", Async: true}})
+
+	require.EqualError(t, err, errFactory.NewSbomJobFailedError("job-1", "the depgraph is invalid").Error())
+	requireErrorCode(t, containererrors.CodeSbomJobFailed, err)
+	require.Empty(t, sleeps)
+}
+
+func Test_GetSbomForDepGraph_GivenRunningSbomJobWithoutLink_ShouldReturnInternalError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJob(t, w, http.StatusAccepted, `{"data":{"id":"job-1","attributes":{"status":"pending"}}}`)
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	client := newJobTestClient(server, &sleeps)
+
+	_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.5+json", "",
+		&GetSbomForDepGraphRequest{API: jobsAPI})
+
+	require.ErrorContains(t, errors.Unwrap(err), `sbom job "job-1" (status "pending") has no link to poll`)
+	requireErrorCode(t, containererrors.CodeInternal, err)
+}
+
+func Test_GetSbomForDepGraph_GivenSbomJobNotFinishing_ShouldReturnSbomJobTimeoutError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJob(t, w, http.StatusAccepted, `{"data":{"id":"job-1","attributes":{"status":"pending"}},`+
+			`"links":{"self":"/rest/orgs/org/sbom_jobs/job-1"}}`)
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	client := newJobTestClient(server, &sleeps)
+	client.maxJobWait = 5 * time.Second
+
+	_, err := client.GetSbomForDepGraph(context.Background(), orgID, "cyclonedx1.5+json", "",
+		&GetSbomForDepGraphRequest{API: jobsAPI})
+
+	require.EqualError(t, err, errFactory.NewSbomJobTimeoutError("job-1", 5*time.Second).Error())
+	requireErrorCode(t, containererrors.CodeSbomJobTimeout, err)
+	require.Equal(t, []time.Duration{defaultPollInterval, defaultPollInterval}, sleeps)
+}
+
+func Test_ResolveJobLink_GivenLink_ShouldKeepVersion(t *testing.T) {
+	jobsURL := "https://api.snyk.io/rest/orgs/org/sbom_jobs?version=" + jobsVersion + "&format=spdx2.3%2Bjson"
+
+	link, err := resolveJobLink(jobsURL, "/rest/orgs/org/sbom_jobs/job-1")
+	require.NoError(t, err)
+	require.Equal(t, "https://api.snyk.io/rest/orgs/org/sbom_jobs/job-1?version="+jobsVersion, link)
+
+	link, err = resolveJobLink(jobsURL, "sbom_jobs/job-1?version=2025-01-01")
+	require.NoError(t, err)
+	require.Equal(t, "https://api.snyk.io/rest/orgs/org/sbom_jobs/job-1?version=2025-01-01", link)
+}
@@ -15,19 +15,27 @@
 package sbom
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	zlog "github.com/rs/zerolog/log"
 	"github.com/snyk/container-cli/internal/common/constants"
 	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/snyk/container-cli/internal/common/registry"
 	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
+	depgrapherrors "github.com/snyk/container-cli/internal/workflows/depgraph/errors"
 	sbomconstants "github.com/snyk/container-cli/internal/workflows/sbom/constants"
 	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
 	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/local_workflows"
 	"github.com/snyk/go-application-framework/pkg/mocks"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 	"github.com/stretchr/testify/require"
@@ -40,6 +48,7 @@ var (
 	mockEngine            *mocks.MockEngine
 	mockInvocationContext *mocks.MockInvocationContext
 	mockSbomClient        *MockSbomClient
+	mockRegistryClient    *MockRegistryClient
 	errFactory            = sbomerrors.NewSbomErrorFactory(&zlog.Logger)
 
 	sbomWorkflow *Workflow
@@ -47,9 +56,29 @@ var (
 
 func beforeEach(t *testing.T) {
 	mockCtrl = gomock.NewController(t)
+	// registry images must not be found in the docker daemon of the machine running the tests.
+	imagetest.NoDockerDaemon(t)
 
 	mockConfig = mocks.NewMockConfiguration(mockCtrl)
 	mockConfig.EXPECT().Clone().Return(configuration.NewInMemory()).MaxTimes(1)
+	mockConfig.EXPECT().GetString(flags.FlagTargetsFile.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().Get(constants.ContainerTargetArgName).Return(nil).AnyTimes()
+	mockConfig.EXPECT().GetBool(flags.FlagOffline.Name).Return(false).AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagOutputFile.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagSignKey.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetBool(flags.FlagAttach.Name).Return(false).AnyTimes()
+	mockConfig.EXPECT().GetBool(flags.FlagNoCache.Name).Return(true).AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagVulnerabilities.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().Get(flags.FlagVex.Name).Return(nil).AnyTimes()
+	mockConfig.EXPECT().Get(flags.FlagTimeout.Name).Return(nil).AnyTimes()
+	mockConfig.EXPECT().Get(flags.FlagRetryMaxAttempts.Name).Return(nil).AnyTimes()
+	mockConfig.EXPECT().Get(flags.FlagRetryMaxBackoff.Name).Return(nil).AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagSbomAPIVersion.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagSbomAPIPath.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagSbomAPIMode.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagPolicy.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagUsername.Name).Return("").AnyTimes()
+	mockConfig.EXPECT().GetString(flags.FlagPassword.Name).Return("").AnyTimes()
 
 	mockEngine = mocks.NewMockEngine(mockCtrl)
 
@@ -59,8 +88,9 @@ func beforeEach(t *testing.T) {
 	mockInvocationContext.EXPECT().GetEngine().Return(mockEngine).MaxTimes(1)
 
 	mockSbomClient = NewMockSbomClient(mockCtrl)
+	mockRegistryClient = newResolvingRegistryClient(mockCtrl)
 
-	sbomWorkflow = NewWorkflow(mockSbomClient, errFactory)
+	sbomWorkflow = NewWorkflow(mockSbomClient, nil, nil, mockRegistryClient, errFactory)
 }
 
 func afterEach() {
@@ -108,6 +138,7 @@ func Test_Entrypoint_GivenDepGraphWorkflowError_ShouldReturnDepGraphWorkflowErro
 	mockConfig.EXPECT().GetString(flags.FlagSbomFormat.Name).Return(sbomconstants.SbomValidFormats[0])
 	mockConfig.EXPECT().GetString(configuration.ORGANIZATION).Return("aaacbb21-19b4-44f4-8483-d03746156f6b")
 	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return("alpine:3.17.0")
 
 	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), configuration.NewInMemory()).
 		Return(nil, errors.New("test error"))
@@ -116,6 +147,24 @@ func Test_Entrypoint_GivenDepGraphWorkflowError_ShouldReturnDepGraphWorkflowErro
 	require.EqualError(t, err, errFactory.NewDepGraphWorkflowError(err).Error())
 }
 
+func Test_Entrypoint_GivenDepGraphWorkflowContainerError_ShouldReturnItUnchanged(t *testing.T) {
+	beforeEach(t)
+	defer afterEach()
+
+	mockConfig.EXPECT().GetString(flags.FlagSbomFormat.Name).Return(sbomconstants.SbomValidFormats[0])
+	mockConfig.EXPECT().GetString(configuration.ORGANIZATION).Return("aaacbb21-19b4-44f4-8483-d03746156f6b")
+	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
+	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return("alpine:3.17.0")
+
+	depGraphErr := depgrapherrors.NewDepGraphErrorFactory(&zlog.Logger).
+		NewImageNotFoundError("alpine:3.17.0", errors.New("manifest unknown"))
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), configuration.NewInMemory()).
+		Return(nil, depGraphErr)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.Same(t, depGraphErr, err)
+}
+
 func Test_Entrypoint_GivenInvalidImageReference_ShouldReturnDepGraphWorkflowError(t *testing.T) {
 	beforeEach(t)
 	defer afterEach()
@@ -123,10 +172,8 @@ func Test_Entrypoint_GivenInvalidImageReference_ShouldReturnDepGraphWorkflowErro
 	mockConfig.EXPECT().GetString(flags.FlagSbomFormat.Name).Return(sbomconstants.SbomValidFormats[0])
 	mockConfig.EXPECT().GetString(flags.FlagPlatform.Name).Return("")
 	mockConfig.EXPECT().GetString(configuration.ORGANIZATION).Return("aaacbb21-19b4-44f4-8483-d03746156f6b")
-	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), configuration.NewInMemory()).
-		Return([]workflow.Data{}, nil)
 
-	// uppercase image references are not valid
+	// uppercase image references are not valid, which is detected before the image is analysed
 	mockConfig.EXPECT().GetString(constants.ContainerTargetArgName).Return("AlpINE:3.17.0")
 
 	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
@@ -193,10 +240,7 @@ func Test_Entrypoint_GivenSbomForDepGraphError_ShouldPropagateClientError(t *tes
 				tc.platform,
 				&GetSbomForDepGraphRequest{
 					DepGraphs: getDepGraphBytes(depGraphList),
-					Subject: Subject{
-						Name:    "alpine",
-						Version: "3.17.0",
-					},
+					Subject:   alpineSubject(tc.platform),
 				}).Return(nil, errFactory.NewInternalError(errors.New("test error")))
 
 			_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
@@ -269,10 +313,7 @@ func Test_Entrypoint_GivenNoError_ShouldReturnSbomAsWorkflowData(t *testing.T) {
 				tc.platform,
 				&GetSbomForDepGraphRequest{
 					DepGraphs: getDepGraphBytes(depGraphList),
-					Subject: Subject{
-						Name:    "alpine",
-						Version: "3.17.0",
-					},
+					Subject:   alpineSubject(tc.platform),
 				}).Return(&expectedSbomResult, nil)
 
 			result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
@@ -365,20 +406,280 @@ func Test_Entrypoint_GivenArchiveInput_ShouldReturnSbomWithArchiveBasename(t *te
 	}
 }
 
+func Test_Entrypoint_GivenTargetsFile_ShouldReturnSbomPerImageAndSummary(t *testing.T) {
+	config := beforeEachMultiTarget(t, "alpine:3.17.0", "alpine:3.18.0\n# comment\n\nalpine:3.17.0\nalpine:3.19.0\n")
+	config.Set(flags.FlagConcurrency.Name, "2")
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil).Times(3)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context, _, _, _ string, req *GetSbomForDepGraphRequest,
+		) (*GetSbomForDepGraphResult, error) {
+			return &GetSbomForDepGraphResult{Doc: []byte(req.Subject.Version), MIMEType: "application/json"}, nil
+		}).Times(3)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 4)
+	for i, target := range []string{"alpine:3.17.0", "alpine:3.18.0", "alpine:3.19.0"} {
+		require.Equal(t, target, getContentLocation(t, result[i]))
+		require.Equal(t, []byte(target[len("alpine:"):]), result[i].GetPayload())
+	}
+	require.Equal(t, "text/plain", result[3].GetContentType())
+	require.Contains(t, string(result[3].GetPayload().([]byte)), "Generated 3 of 3 SBOMs:")
+}
+
+func Test_Entrypoint_GivenTargetsFileAndFailingImage_ShouldPrintSuccessfulSbomsAndReturnError(t *testing.T) {
+	beforeEachMultiTarget(t, "alpine:3.17.0", "alpine:3.18.0\n")
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		DoAndReturn(func(_ workflow.Identifier, c configuration.Configuration) ([]workflow.Data, error) {
+			if c.GetString(constants.ContainerTargetArgName) == "alpine:3.18.0" {
+				return nil, errors.New("test error")
+			}
+			return []workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil
+		}).Times(2)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: "application/json"}, nil)
+
+	var printed []workflow.Data
+	mockEngine.EXPECT().InvokeWithInput(localworkflows.WORKFLOWID_OUTPUT_WORKFLOW, gomock.Any()).
+		DoAndReturn(func(_ workflow.Identifier, input []workflow.Data) ([]workflow.Data, error) {
+			printed = input
+			return nil, nil
+		})
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "SBOM generation failed for 1 of 2 images.")
+	require.ErrorContains(t, err, "  OK      alpine:3.17.0\n")
+	require.ErrorContains(t, err, "  FAILED  alpine:3.18.0: ")
+
+	require.Len(t, printed, 1)
+	require.Equal(t, "alpine:3.17.0", getContentLocation(t, printed[0]))
+}
+
+func Test_Entrypoint_GivenInvalidConcurrency_ShouldReturnInvalidConcurrencyError(t *testing.T) {
+	config := beforeEachMultiTarget(t, "alpine:3.17.0", "alpine:3.18.0\n")
+	config.Set(flags.FlagConcurrency.Name, "0")
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewInvalidConcurrencyError(0).Error())
+}
+
+func Test_Entrypoint_GivenInvalidRetryLimits_ShouldReturnError(t *testing.T) {
+	tests := map[string]struct {
+		flag          string
+		value         string
+		expectedError error
+	}{
+		"no attempts": {
+			flag:          flags.FlagRetryMaxAttempts.Name,
+			value:         "0",
+			expectedError: errFactory.NewInvalidRetryMaxAttemptsError(0),
+		},
+		"negative backoff": {
+			flag:          flags.FlagRetryMaxBackoff.Name,
+			value:         "-1s",
+			expectedError: errFactory.NewInvalidRetryMaxBackoffError(-time.Second),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := beforeEachInMemory(t, "alpine:3.17.0")
+			config.Set(tc.flag, tc.value)
+
+			_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+			require.EqualError(t, err, tc.expectedError.Error())
+		})
+	}
+}
+
+func Test_Entrypoint_GivenRetryLimits_ShouldPassThemToTheSbomClient(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagRetryMaxAttempts.Name, "2")
+	config.Set(flags.FlagRetryMaxBackoff.Name, "5s")
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _, _, _ string, _ *GetSbomForDepGraphRequest) (
+			*GetSbomForDepGraphResult, error,
+		) {
+			policy := retryPolicyOf(ctx, RetryPolicy{})
+			require.Equal(t, 2, policy.MaxAttempts)
+			require.Equal(t, 5*time.Second, policy.MaxBackoff)
+			require.Equal(t, DefaultRetryPolicy.InitialBackoff, policy.InitialBackoff)
+			return &GetSbomForDepGraphResult{Doc: getSbom(t, "testdata/sbom_result_doc.json"),
+				MIMEType: "application/vnd.cyclonedx+json"}, nil
+		})
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+}
+
+func Test_Entrypoint_GivenMissingTargetsFile_ShouldReturnTargetsFileError(t *testing.T) {
+	config := beforeEachMultiTarget(t, "alpine:3.17.0", "")
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	config.Set(flags.FlagTargetsFile.Name, missing)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewTargetsFileError(missing, os.ErrNotExist).Error())
+}
+
+func Test_Entrypoint_GivenOffline_ShouldGenerateSbomLocallyWithoutOrg(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagOffline.Name, true)
+	config.Set(configuration.ORGANIZATION, "")
+
+	sbomWorkflow = NewWorkflow(mockSbomClient, newTestLocalSbomClient(), nil, mockRegistryClient, errFactory)
+
+	depGraphList := []workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return(depGraphList, nil)
+
+	expectedSbomResult, err := newTestLocalSbomClient().GetSbomForDepGraph(
+		context.Background(), "", "cyclonedx1.4+json", "", &GetSbomForDepGraphRequest{
+			DepGraphs: getDepGraphBytes(depGraphList),
+			Subject:   Subject{Name: "alpine", Version: "3.17.0"},
+		})
+	require.NoError(t, err)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, expectedSbomResult.MIMEType, result[0].GetContentType())
+	require.Equal(t, expectedSbomResult.Doc, result[0].GetPayload())
+}
+
+func Test_Entrypoint_GivenOutputFile_ShouldWriteSbomAndReturnSummary(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	path := filepath.Join(t.TempDir(), "sbom")
+	config.Set(flags.FlagOutputFile.Name, path)
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&GetSbomForDepGraphResult{Doc: getSbom(t, "testdata/sbom_result_doc.json"),
+			MIMEType: "application/vnd.cyclonedx+json"}, nil)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, "text/plain", result[0].GetContentType())
+	require.Equal(t, "SBOM for alpine:3.17.0 written to "+path+".cdx.json\n", string(result[0].GetPayload().([]byte)))
+	require.Equal(t, getSbom(t, "testdata/sbom_result_doc.json"), getSbom(t, path+".cdx.json"))
+}
+
+func Test_Entrypoint_GivenOutputFileTemplateAndTargetsFile_ShouldWriteSbomPerImage(t *testing.T) {
+	config := beforeEachMultiTarget(t, "alpine:3.17.0", "alpine:3.18.0\n")
+	dir := t.TempDir()
+	config.Set(flags.FlagOutputFile.Name, filepath.Join(dir, "{name}-{tag}{ext}"))
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil).Times(2)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context, _, _, _ string, req *GetSbomForDepGraphRequest,
+		) (*GetSbomForDepGraphResult, error) {
+			return &GetSbomForDepGraphResult{Doc: []byte(req.Subject.Version), MIMEType: "application/spdx+json"}, nil
+		}).Times(2)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	summary := string(result[0].GetPayload().([]byte))
+	require.Contains(t, summary, "Generated 2 of 2 SBOMs:")
+	require.Contains(t, summary, "SBOM for alpine:3.18.0 written to "+filepath.Join(dir, "alpine-3.18.0.spdx.json"))
+	require.Equal(t, []byte("3.17.0"), getSbom(t, filepath.Join(dir, "alpine-3.17.0.spdx.json")))
+	require.Equal(t, []byte("3.18.0"), getSbom(t, filepath.Join(dir, "alpine-3.18.0.spdx.json")))
+}
+
+func Test_Entrypoint_GivenPositionalTargetsAndFailingImage_ShouldIndexSbomsByInputPosition(t *testing.T) {
+	config := beforeEachInMemory(t, "")
+	config.Set(constants.ContainerTargetArgName, []string{"alpine:3.17.0", "alpine:3.18.0", "alpine:3.19.0"})
+	dir := t.TempDir()
+	config.Set(flags.FlagOutputFile.Name, filepath.Join(dir, "{index}-{tag}{ext}"))
+
+	mockEngine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		DoAndReturn(func(_ workflow.Identifier, c configuration.Configuration) ([]workflow.Data, error) {
+			if c.GetString(constants.ContainerTargetArgName) == "alpine:3.17.0" {
+				return nil, errors.New("test error")
+			}
+			return []workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil
+		}).Times(3)
+	mockSbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context, _, _, _ string, req *GetSbomForDepGraphRequest,
+		) (*GetSbomForDepGraphResult, error) {
+			return &GetSbomForDepGraphResult{Doc: []byte(req.Subject.Version), MIMEType: "application/spdx+json"}, nil
+		}).Times(2)
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "SBOM generation failed for 1 of 3 images.")
+
+	require.NoFileExists(t, filepath.Join(dir, "1-3.17.0.spdx.json"))
+	require.Equal(t, []byte("3.18.0"), getSbom(t, filepath.Join(dir, "2-3.18.0.spdx.json")))
+	require.Equal(t, []byte("3.19.0"), getSbom(t, filepath.Join(dir, "3-3.19.0.spdx.json")))
+}
+
+func Test_Entrypoint_GivenOutputFileWithoutPlaceholderAndTargetsFile_ShouldReturnTemplateError(t *testing.T) {
+	config := beforeEachMultiTarget(t, "alpine:3.17.0", "alpine:3.18.0\n")
+	config.Set(flags.FlagOutputFile.Name, "sbom.json")
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewOutputFileTemplateError("sbom.json").Error())
+}
+
 func Test_Init_GivenWorkflowFlags_ShouldRegisterFlagsToWorkflowAndReturnThemInConfigInsteadOfNil(t *testing.T) {
 	config := configuration.New()
 	engine := workflow.NewWorkFlowEngine(config)
 
-	sbomWorkflow := NewWorkflow(nil, nil)
+	sbomWorkflow := NewWorkflow(nil, nil, nil, nil, nil)
 
 	err := sbomWorkflow.Init(engine)
 	require.Nil(t, err)
 
-	require.Len(t, sbomWorkflow.Flags, 7)
+	require.Len(t, sbomWorkflow.Flags, 28)
 
 	flagSbomFormat := config.Get(flags.FlagSbomFormat.Name)
 	require.NotNil(t, flagSbomFormat)
 
+	flagTargetsFile := config.Get(flags.FlagTargetsFile.Name)
+	require.NotNil(t, flagTargetsFile)
+
+	flagConcurrency := config.Get(flags.FlagConcurrency.Name)
+	require.NotNil(t, flagConcurrency)
+
+	flagOffline := config.Get(flags.FlagOffline.Name)
+	require.NotNil(t, flagOffline)
+
+	flagSbomAPIVersion := config.Get(flags.FlagSbomAPIVersion.Name)
+	require.NotNil(t, flagSbomAPIVersion)
+
+	flagSbomAPIPath := config.Get(flags.FlagSbomAPIPath.Name)
+	require.NotNil(t, flagSbomAPIPath)
+
+	flagSbomAPIMode := config.Get(flags.FlagSbomAPIMode.Name)
+	require.NotNil(t, flagSbomAPIMode)
+
+	flagOutputFile := config.Get(flags.FlagOutputFile.Name)
+	require.NotNil(t, flagOutputFile)
+
+	flagSignKey := config.Get(flags.FlagSignKey.Name)
+	require.NotNil(t, flagSignKey)
+
+	flagAttach := config.Get(flags.FlagAttach.Name)
+	require.NotNil(t, flagAttach)
+
+	flagPolicy := config.Get(flags.FlagPolicy.Name)
+	require.NotNil(t, flagPolicy)
+
 	flagExcludeAppVulns := config.Get(flags.FlagExcludeAppVulns.Name)
 	require.NotNil(t, flagExcludeAppVulns)
 
@@ -398,6 +699,86 @@ func Test_Init_GivenWorkflowFlags_ShouldRegisterFlagsToWorkflowAndReturnThemInCo
 	require.NotNil(t, flagNestedJarsDepth)
 }
 
+// beforeEachInMemory sets up the mocks for a run backed by an in-memory configuration.
+func beforeEachInMemory(t *testing.T, target string) configuration.Configuration {
+	t.Helper()
+
+	mockCtrl = gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+	imagetest.NoDockerDaemon(t)
+
+	config := configuration.NewInMemory()
+	config.Set(flags.FlagSbomFormat.Name, "cyclonedx1.4+json")
+	config.Set(configuration.ORGANIZATION, "aaacbb21-19b4-44f4-8483-d03746156f6b")
+	config.Set(constants.ContainerTargetArgName, target)
+	// tests of the depgraph cache enable it with a temporary directory.
+	config.Set(flags.FlagNoCache.Name, true)
+
+	mockEngine = mocks.NewMockEngine(mockCtrl)
+
+	mockInvocationContext = mocks.NewMockInvocationContext(mockCtrl)
+	mockInvocationContext.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	mockInvocationContext.EXPECT().GetConfiguration().Return(config)
+	mockInvocationContext.EXPECT().GetEngine().Return(mockEngine).MaxTimes(1)
+
+	mockSbomClient = NewMockSbomClient(mockCtrl)
+	mockRegistryClient = newResolvingRegistryClient(mockCtrl)
+
+	sbomWorkflow = NewWorkflow(mockSbomClient, nil, nil, mockRegistryClient, errFactory)
+	return config
+}
+
+// newResolvingRegistryClient returns a registry client which resolves every image to testImageDigest.
+func newResolvingRegistryClient(ctrl *gomock.Controller) *MockRegistryClient {
+	client := NewMockRegistryClient(ctrl)
+	client.EXPECT().Resolve(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _, platform string, _ registry.Credentials) (*registry.ResolvedImage, error) {
+			if platform == "" {
+				platform = "linux/amd64"
+			}
+			desc := image.Descriptor{MediaType: image.MediaTypeOCIManifest, Digest: testImageDigest}
+			return &registry.ResolvedImage{Descriptor: desc, Image: desc, Platform: platform}, nil
+		}).AnyTimes()
+	return client
+}
+
+// alpineSubject returns the subject of alpine:3.17.0 as resolved by newResolvingRegistryClient.
+func alpineSubject(platform string) Subject {
+	if platform == "" {
+		platform = "linux/amd64"
+	}
+	return Subject{
+		Name:       "alpine",
+		Version:    "3.17.0",
+		Registry:   "docker.io",
+		Repository: "library/alpine",
+		Tag:        "3.17.0",
+		Digest:     testImageDigest.String(),
+		Platform:   platform,
+	}
+}
+
+// beforeEachMultiTarget sets up the mocks for a run with a targets file listing the given images.
+func beforeEachMultiTarget(t *testing.T, target, targets string) configuration.Configuration {
+	t.Helper()
+
+	config := beforeEachInMemory(t, target)
+
+	targetsFile := filepath.Join(t.TempDir(), "targets.txt")
+	require.NoError(t, os.WriteFile(targetsFile, []byte(targets), 0o600))
+	config.Set(flags.FlagTargetsFile.Name, targetsFile)
+
+	return config
+}
+
+func getContentLocation(t *testing.T, data workflow.Data) string {
+	t.Helper()
+
+	location, err := data.GetMetaData(constants.HeaderContentLocation)
+	require.NoError(t, err)
+	return location
+}
+
 func getInvalidDepGraph() workflow.Data {
 	// a boolean payload (e.g. true) is not valid
 	invalidDepGraph := workflow.NewData(workflow.NewTypeIdentifier(workflow.NewWorkflowIdentifier("container depgraph"),
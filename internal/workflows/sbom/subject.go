@@ -0,0 +1,105 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+
+	"github.com/docker/distribution/reference"
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/redact"
+)
+
+// resolveSubject pins the subject to the digest of the image manifest, which is resolved from the registry
+// for registry images not referenced by digest. Local images are inspected to obtain their name and tag from
+// the archive as well. Registries are not contacted when running offline, nor for images of the docker daemon,
+// which the analysis reads instead of pulling them. Failing to resolve the digest is not fatal, as the registry
+// or archive may only be accessible to the analysis.
+func (w *Workflow) resolveSubject(
+	ctx context.Context,
+	logger *zerolog.Logger,
+	target string,
+	subject Subject,
+	opts generateOptions,
+) Subject {
+	subject.Platform = opts.platform
+
+	if ref, ok := image.ParseReference(target); ok {
+		// only the metadata is read, the layers are left to the analysis.
+		img, err := image.Inspect(ref, opts.platform)
+		if err != nil {
+			logger.Warn().Err(redact.Error(err)).Msgf("could not read the digest of %s", target)
+			return subject
+		}
+		return localSubject(subject, img)
+	}
+
+	if subject.Digest != "" || opts.offline {
+		return subject
+	}
+
+	daemonRef := image.Reference{Transport: image.TransportDockerDaemon, Path: target}
+	if img, err := image.Inspect(daemonRef, opts.platform); err == nil {
+		logger.Debug().Msgf("%s is read from the docker daemon, not resolving its digest", target)
+		subject.ImageID = img.ConfigDigest.String()
+		if img.Platform != "" {
+			subject.Platform = img.Platform
+		}
+		return subject
+	}
+
+	resolved, err := w.registryClient.Resolve(ctx, target, opts.platform, opts.credentials)
+	if err != nil {
+		logger.Warn().Err(redact.Error(err)).Msgf("could not resolve the digest of %s", target)
+		return subject
+	}
+	subject.Digest = resolved.Image.Digest.String()
+	if resolved.Platform != "" {
+		subject.Platform = resolved.Platform
+	}
+	logger.Debug().Msgf("resolved %s to %s", target, subject.Digest)
+	return subject
+}
+
+// localSubject fills the subject from the metadata recorded in the archive. The name and tag come from the
+// RepoTags of docker archives and the ref name annotations of OCI archives; archives without either
+// keep their basename.
+func localSubject(subject Subject, img *image.Image) Subject {
+	if img.Name != "" {
+		subject.Name = img.Name
+		if named, err := reference.ParseNormalizedNamed(img.Name); err == nil {
+			subject.Registry = reference.Domain(named)
+			subject.Repository = reference.Path(named)
+		}
+	}
+	if img.Tag != "" {
+		subject.Tag = img.Tag
+	}
+	// docker archives don't store the manifest, in which case the digest stays empty.
+	subject.Digest = img.ManifestDigest.String()
+	subject.ImageID = img.ConfigDigest.String()
+	subject.Platform = img.Platform
+
+	switch {
+	case subject.Tag != "":
+		subject.Version = subject.Tag
+	case subject.Digest != "":
+		subject.Version = subject.Digest
+	default:
+		subject.Version = subject.ImageID
+	}
+	return subject
+}
@@ -0,0 +1,257 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opencontainers/go-digest"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/image/imagetest"
+	"github.com/snyk/container-cli/internal/common/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubject(t *testing.T) {
+	imageDigest := testImageDigest.String()
+
+	tests := map[string]struct {
+		input    string
+		expected Subject
+	}{
+		"docker hub image with tag": {
+			"alpine:3.17.0",
+			Subject{Name: "alpine", Version: "3.17.0", Registry: "docker.io", Repository: "library/alpine", Tag: "3.17.0"},
+		},
+		"docker hub image without tag": {
+			"alpine",
+			Subject{Name: "alpine", Registry: "docker.io", Repository: "library/alpine"},
+		},
+		"registry image with digest": {
+			"registry.example.com:5000/team/app@" + imageDigest,
+			Subject{
+				Name:       "registry.example.com:5000/team/app",
+				Version:    imageDigest,
+				Registry:   "registry.example.com:5000",
+				Repository: "team/app",
+				Digest:     imageDigest,
+			},
+		},
+		"registry image with tag and digest": {
+			"ghcr.io/org/app:1.2@" + imageDigest,
+			Subject{
+				Name:       "ghcr.io/org/app",
+				Version:    imageDigest,
+				Registry:   "ghcr.io",
+				Repository: "org/app",
+				Tag:        "1.2",
+				Digest:     imageDigest,
+			},
+		},
+		"archive":             {"docker-archive:/tmp/nginx.tar", Subject{Name: "nginx.tar"}},
+		"oci layout with tag": {"oci:/tmp/layout:1.25", Subject{Name: "layout", Version: "1.25", Tag: "1.25"}},
+		"docker daemon image": {
+			"docker-daemon:nginx:1.25",
+			Subject{Name: "nginx", Version: "1.25", Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			subject, err := parseSubject(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, subject)
+		})
+	}
+
+	_, err := parseSubject("Invalid:Image")
+	require.ErrorContains(t, err, "could not parse container name")
+}
+
+func Test_ResolveSubject_GivenDigestedImage_ShouldNotResolve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	w := NewWorkflow(nil, nil, nil, NewMockRegistryClient(ctrl), errFactory)
+	subject := Subject{Name: "alpine", Digest: testImageDigest.String()}
+
+	resolved := w.resolveSubject(context.Background(), &zlog.Logger, "alpine@"+testImageDigest.String(), subject,
+		generateOptions{platform: "linux/arm64"})
+
+	require.Equal(t, Subject{Name: "alpine", Digest: testImageDigest.String(), Platform: "linux/arm64"}, resolved)
+}
+
+func Test_ResolveSubject_GivenTaggedImage_ShouldPinToResolvedDigest(t *testing.T) {
+	imagetest.NoDockerDaemon(t)
+	ctrl := gomock.NewController(t)
+	registryClient := NewMockRegistryClient(ctrl)
+	creds := registry.Credentials{Username: "user", Password: "secret"}
+	registryClient.EXPECT().Resolve(gomock.Any(), "alpine:3.17.0", "", creds).
+		Return(&registry.ResolvedImage{
+			Descriptor: image.Descriptor{MediaType: image.MediaTypeOCIIndex, Digest: digest.FromString("index")},
+			Image:      image.Descriptor{MediaType: image.MediaTypeOCIManifest, Digest: testImageDigest},
+			Platform:   "linux/amd64",
+		}, nil)
+	w := NewWorkflow(nil, nil, nil, registryClient, errFactory)
+
+	resolved := w.resolveSubject(
+		context.Background(), &zlog.Logger, "alpine:3.17.0", Subject{Name: "alpine", Tag: "3.17.0"},
+		generateOptions{credentials: creds},
+	)
+
+	require.Equal(t, Subject{
+		Name:     "alpine",
+		Tag:      "3.17.0",
+		Digest:   testImageDigest.String(),
+		Platform: "linux/amd64",
+	}, resolved)
+}
+
+func Test_ResolveSubject_GivenResolveError_ShouldKeepSubjectUnpinned(t *testing.T) {
+	imagetest.NoDockerDaemon(t)
+	ctrl := gomock.NewController(t)
+	registryClient := NewMockRegistryClient(ctrl)
+	registryClient.EXPECT().Resolve(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("registry unreachable"))
+	w := NewWorkflow(nil, nil, nil, registryClient, errFactory)
+
+	resolved := w.resolveSubject(
+		context.Background(), &zlog.Logger, "alpine:3.17.0", Subject{Name: "alpine", Tag: "3.17.0"},
+		generateOptions{platform: "linux/arm64"},
+	)
+
+	require.Equal(t, Subject{Name: "alpine", Tag: "3.17.0", Platform: "linux/arm64"}, resolved)
+}
+
+func Test_ResolveSubject_GivenOffline_ShouldNotResolve(t *testing.T) {
+	imagetest.NoDockerDaemon(t)
+	ctrl := gomock.NewController(t)
+	w := NewWorkflow(nil, nil, nil, NewMockRegistryClient(ctrl), errFactory)
+
+	resolved := w.resolveSubject(
+		context.Background(), &zlog.Logger, "alpine:3.17.0", Subject{Name: "alpine", Tag: "3.17.0"},
+		generateOptions{offline: true},
+	)
+
+	require.Equal(t, Subject{Name: "alpine", Tag: "3.17.0"}, resolved)
+}
+
+func Test_ResolveSubject_GivenImageOfDockerDaemon_ShouldNotResolve(t *testing.T) {
+	imageID := digest.FromBytes(imagetest.Config).String()
+	imagetest.DockerDaemon(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/app:1.2/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Id":"` + imageID + `","RepoTags":["app:1.2"],"Os":"linux","Architecture":"arm64"}`))
+	})
+	ctrl := gomock.NewController(t)
+	w := NewWorkflow(nil, nil, nil, NewMockRegistryClient(ctrl), errFactory)
+
+	resolved := w.resolveSubject(
+		context.Background(), &zlog.Logger, "app:1.2", Subject{Name: "app", Tag: "1.2"}, generateOptions{},
+	)
+
+	require.Equal(t, Subject{Name: "app", Tag: "1.2", ImageID: imageID, Platform: "linux/arm64"}, resolved)
+}
+
+func Test_ResolveSubject_GivenArchive_ShouldReadSubjectFromArchive(t *testing.T) {
+	dir := t.TempDir()
+	layer := imagetest.Layer{"etc/os-release": "alpine"}
+	imageID := digest.FromBytes(imagetest.Config).String()
+
+	ociArchive := filepath.Join(dir, "oci.tar")
+	manifestDigest := imagetest.WriteOCIArchive(t, ociArchive, "ghcr.io/org/app:1.2", layer)
+	bareTagArchive := filepath.Join(dir, "bare.tar")
+	bareTagDigest := imagetest.WriteOCIArchive(t, bareTagArchive, "3.17", layer)
+	dockerArchive := filepath.Join(dir, "docker.tar")
+	imagetest.WriteDockerArchive(t, dockerArchive, []string{"nginx:1.25"}, layer)
+	kanikoArchive := filepath.Join(dir, "kaniko.tar")
+	imagetest.WriteDockerArchive(t, kanikoArchive, nil, layer)
+
+	tests := map[string]struct {
+		target   string
+		subject  Subject
+		expected Subject
+	}{
+		"oci archive with ref name": {
+			"oci-archive:" + ociArchive,
+			Subject{Name: "oci.tar"},
+			Subject{
+				Name:       "ghcr.io/org/app",
+				Version:    "1.2",
+				Registry:   "ghcr.io",
+				Repository: "org/app",
+				Tag:        "1.2",
+				Digest:     manifestDigest.String(),
+				ImageID:    imageID,
+				Platform:   "linux/amd64",
+			},
+		},
+		"oci archive with bare tag": {
+			"oci-archive:" + bareTagArchive,
+			Subject{Name: "bare.tar"},
+			Subject{
+				Name:     "bare.tar",
+				Version:  "3.17",
+				Tag:      "3.17",
+				Digest:   bareTagDigest.String(),
+				ImageID:  imageID,
+				Platform: "linux/amd64",
+			},
+		},
+		"docker archive with repo tags": {
+			"docker-archive:" + dockerArchive,
+			Subject{Name: "docker.tar"},
+			Subject{
+				Name:       "nginx",
+				Version:    "1.25",
+				Registry:   "docker.io",
+				Repository: "library/nginx",
+				Tag:        "1.25",
+				ImageID:    imageID,
+				Platform:   "linux/amd64",
+			},
+		},
+		"kaniko archive without repo tags": {
+			"kaniko-archive:" + kanikoArchive,
+			Subject{Name: "kaniko.tar"},
+			Subject{Name: "kaniko.tar", Version: imageID, ImageID: imageID, Platform: "linux/amd64"},
+		},
+	}
+
+	w := NewWorkflow(nil, nil, nil, nil, errFactory)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			resolved := w.resolveSubject(context.Background(), &zlog.Logger, tc.target, tc.subject, generateOptions{})
+			require.Equal(t, tc.expected, resolved)
+		})
+	}
+}
+
+func Test_ResolveSubject_GivenMissingArchive_ShouldKeepBasename(t *testing.T) {
+	w := NewWorkflow(nil, nil, nil, nil, errFactory)
+
+	resolved := w.resolveSubject(
+		context.Background(), &zlog.Logger, "docker-archive:/does/not/exist.tar", Subject{Name: "exist.tar"},
+		generateOptions{},
+	)
+
+	require.Equal(t, Subject{Name: "exist.tar"}, resolved)
+}
@@ -0,0 +1,90 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readTargets returns the images to generate SBOMs for: the images passed on the command line followed by
+// the images listed in the targets file, if any, without duplicates.
+func readTargets(args []string, targetsFile string) ([]string, error) {
+	var targets []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+
+	for _, arg := range args {
+		add(arg)
+	}
+	if targetsFile == "" {
+		return targets, nil
+	}
+
+	content, err := os.ReadFile(targetsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s does not list any images", targetsFile)
+	}
+	return targets, nil
+}
+
+// targetResult is the outcome of generating the SBOM for a single target.
+type targetResult struct {
+	target string
+	output *sbomOutput
+	err    error
+}
+
+// summarize renders a human readable per-image summary of a multi-image run.
+func summarize(results []targetResult) (summary string, failed int) {
+	var sb strings.Builder
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			fmt.Fprintf(&sb, "  FAILED  %s: %s\n", r.target, r.err)
+		} else {
+			fmt.Fprintf(&sb, "  OK      %s\n", r.target)
+			if len(r.output.staleVex) > 0 {
+				sb.WriteString(staleVexReport(r.target, r.output.staleVex))
+			}
+		}
+	}
+	header := fmt.Sprintf("Generated %d of %d SBOMs:\n", len(results)-failed, len(results))
+	return header + sb.String(), failed
+}
@@ -0,0 +1,85 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadTargets_GivenTargetsFile_ShouldReturnTargetFollowedByListedImages(t *testing.T) {
+	type test struct {
+		args          []string
+		content       string
+		expected      []string
+		expectedError string
+	}
+
+	tests := map[string]test{
+		"comments, blank lines and duplicates": {
+			args:     []string{"alpine:3.17.0"},
+			content:  "# images\n  nginx:1.25  \n\nalpine:3.17.0\r\nnginx:1.25\n",
+			expected: []string{"alpine:3.17.0", "nginx:1.25"},
+		},
+		"several positional targets": {
+			args:     []string{"alpine:3.17.0", "redis:7"},
+			content:  "nginx:1.25\nredis:7\n",
+			expected: []string{"alpine:3.17.0", "redis:7", "nginx:1.25"},
+		},
+		"no positional target": {
+			content:  "nginx:1.25\n",
+			expected: []string{"nginx:1.25"},
+		},
+		"no images": {
+			content:       "# nothing here\n",
+			expectedError: "does not list any images",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "targets.txt")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+
+			targets, err := readTargets(tc.args, path)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, targets)
+		})
+	}
+}
+
+func Test_ReadTargets_GivenNoTargetsFile_ShouldReturnPositionalTargets(t *testing.T) {
+	targets, err := readTargets([]string{"alpine:3.17.0", "nginx:1.25", "alpine:3.17.0"}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alpine:3.17.0", "nginx:1.25"}, targets)
+}
+
+func Test_Summarize_GivenMixedResults_ShouldListEveryTarget(t *testing.T) {
+	summary, failed := summarize([]targetResult{
+		{target: "alpine:3.17.0", output: &sbomOutput{result: &GetSbomForDepGraphResult{}}},
+		{target: "nginx:1.25", err: errors.New("boom")},
+	})
+
+	require.Equal(t, 1, failed)
+	require.Equal(t, "Generated 1 of 2 SBOMs:\n  OK      alpine:3.17.0\n  FAILED  nginx:1.25: boom\n", summary)
+}
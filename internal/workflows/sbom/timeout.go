@@ -0,0 +1,139 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+// The phases of the generation of an SBOM, as reported when the generation times out or is canceled.
+const (
+	phaseResolve         = "resolving the digest of the image"
+	phaseDepGraph        = "analysing the image"
+	phaseSbom            = "generating the SBOM from the depgraphs"
+	phaseVulnerabilities = "testing the image for vulnerabilities"
+	phaseAttach          = "attaching the SBOM to the image"
+)
+
+// interruptSignals cancel the generation of the SBOMs.
+var interruptSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// workflowContext returns the context bounding the generation of the SBOMs. It is canceled on an interrupt, unless
+// the returned trap is paused, and, unless the timeout is 0, once the timeout has passed.
+func workflowContext(timeout time.Duration) (context.Context, *interruptTrap, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	trap := &interruptTrap{signals: make(chan os.Signal, 1)}
+	signal.Notify(trap.signals, interruptSignals...)
+	go func() {
+		select {
+		case <-trap.signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	stop := func() {
+		trap.stop()
+		cancel()
+	}
+	if timeout == 0 {
+		return ctx, trap, stop
+	}
+
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return ctx, trap, func() {
+		cancelTimeout()
+		stop()
+	}
+}
+
+// interruptTrap turns interrupts into the cancellation of the workflow context. While it is paused, interrupts
+// get their default handling and terminate the process, so they aren't swallowed by phases which can't be
+// canceled, like the analysis by the legacy CLI.
+type interruptTrap struct {
+	mu      sync.Mutex
+	signals chan os.Signal
+	paused  int
+	stopped bool
+}
+
+// pause restores the default handling of interrupts until the returned function is called. Images analysed in
+// parallel pause the trap independently, it is resumed once none of them needs it paused anymore. Pausing a nil
+// trap does nothing.
+func (t *interruptTrap) pause() (resume func()) {
+	if t == nil {
+		return func() {}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused++
+	if t.paused == 1 && !t.stopped {
+		signal.Stop(t.signals)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.paused--
+			if t.paused == 0 && !t.stopped {
+				signal.Notify(t.signals, interruptSignals...)
+			}
+		})
+	}
+}
+
+func (t *interruptTrap) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	signal.Stop(t.signals)
+}
+
+// interrupted returns the error telling the user in which phase the generation of the SBOM of the target
+// timed out or was canceled, or nil if the context is not done.
+func (w *Workflow) interrupted(ctx context.Context, target, phase string, opts generateOptions) error {
+	switch err := ctx.Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		return w.errFactory.NewTimeoutError(target, phase, opts.timeout)
+	case errors.Is(err, context.Canceled):
+		return w.errFactory.NewCanceledError(target, phase)
+	}
+	return nil
+}
+
+// invokeWithContext runs invoke and returns the error of the context instead of its result if the context is
+// done meanwhile. The engine can't cancel workflows, so invoke is waited for rather than abandoned, which would
+// leave the legacy CLI running with the registry credentials in its environment.
+func invokeWithContext(ctx context.Context, invoke func() ([]workflow.Data, error)) ([]workflow.Data, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := invoke()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return data, err
+}
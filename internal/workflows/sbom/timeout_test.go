@@ -0,0 +1,167 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/constants"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/mocks"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 20 * time.Millisecond
+
+// newTimeoutTest returns the workflow, the engine and the SBOM client used to generate the SBOM of alpine:3.17.0
+// with the returned configuration.
+func newTimeoutTest(t *testing.T) (*Workflow, *mocks.MockEngine, *MockSbomClient, configuration.Configuration) {
+	ctrl := gomock.NewController(t)
+	sbomClient := NewMockSbomClient(ctrl)
+	w := NewWorkflow(sbomClient, nil, nil, newResolvingRegistryClient(ctrl), errFactory)
+
+	config := configuration.NewInMemory()
+	config.Set(constants.ContainerTargetArgName, "alpine:3.17.0")
+	return w, mocks.NewMockEngine(ctrl), sbomClient, config
+}
+
+func Test_GenerateSbom_GivenTimeoutWhileAnalysingTheImage_ShouldReturnTimeoutErrorNamingThePhase(t *testing.T) {
+	w, engine, sbomClient, config := newTimeoutTest(t)
+
+	// the depgraph workflow returns after the timeout has passed.
+	engine.EXPECT().InvokeWithConfig(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ workflow.Identifier, config configuration.Configuration) ([]workflow.Data, error) {
+			deadline, ok := config.Get(constants.DepGraphDeadlineArgName).(time.Time)
+			require.True(t, ok, "the deadline must be passed to the depgraph workflow")
+			require.WithinDuration(t, time.Now().Add(testTimeout), deadline, testTimeout)
+			time.Sleep(2 * testTimeout)
+			return []workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	opts := generateOptions{client: sbomClient, timeout: testTimeout}
+	_, err := w.generateSbom(ctx, engine, &zlog.Logger, config, opts)
+
+	require.EqualError(t, err, errFactory.NewTimeoutError("alpine:3.17.0", phaseDepGraph, testTimeout).Error())
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	requireErrorCode(t, containererrors.CodeTimeout, err)
+}
+
+func Test_GenerateSbom_GivenTimeoutWhileGeneratingTheSbom_ShouldCancelTheSbomClient(t *testing.T) {
+	w, engine, sbomClient, config := newTimeoutTest(t)
+
+	engine.EXPECT().InvokeWithConfig(gomock.Any(), gomock.Any()).
+		Return([]workflow.Data{getValidDepGraph(t, "testdata/sbom_request_depgraph.json")}, nil)
+	sbomClient.EXPECT().GetSbomForDepGraph(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			ctx context.Context, _, _, _ string, _ *GetSbomForDepGraphRequest,
+		) (*GetSbomForDepGraphResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	opts := generateOptions{client: sbomClient, timeout: testTimeout}
+	_, err := w.generateSbom(ctx, engine, &zlog.Logger, config, opts)
+
+	require.EqualError(t, err, errFactory.NewTimeoutError("alpine:3.17.0", phaseSbom, testTimeout).Error())
+	requireErrorCode(t, containererrors.CodeTimeout, err)
+}
+
+func Test_GenerateSbom_GivenCanceledContext_ShouldReturnCanceledError(t *testing.T) {
+	w, engine, sbomClient, config := newTimeoutTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := generateOptions{client: sbomClient}
+	_, err := w.generateSbom(ctx, engine, &zlog.Logger, config, opts)
+
+	require.EqualError(t, err, errFactory.NewCanceledError("alpine:3.17.0", phaseResolve).Error())
+	require.True(t, errors.Is(err, context.Canceled))
+	requireErrorCode(t, containererrors.CodeCanceled, err)
+}
+
+func Test_InvokeWithContext_GivenContextDoneWhileInvoking_ShouldWaitForInvokeToReturn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := false
+
+	data, err := invokeWithContext(ctx, func() ([]workflow.Data, error) {
+		cancel()
+		time.Sleep(testTimeout)
+		returned = true
+		return []workflow.Data{nil}, nil
+	})
+
+	require.True(t, returned)
+	require.Nil(t, data)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_WorkflowContext_GivenTimeout_ShouldSetDeadline(t *testing.T) {
+	ctx, _, cancel := workflowContext(time.Minute)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	ctx, _, cancel = workflowContext(0)
+	defer cancel()
+
+	_, ok = ctx.Deadline()
+	require.False(t, ok)
+}
+
+func Test_InterruptTrap_GivenPausedTwice_ShouldResumeOnceBothResumed(t *testing.T) {
+	_, trap, cancel := workflowContext(0)
+	defer cancel()
+
+	resumeFirst := trap.pause()
+	resumeSecond := trap.pause()
+	require.Equal(t, 2, trap.paused)
+
+	resumeFirst()
+	resumeFirst()
+	require.Equal(t, 1, trap.paused, "resuming twice must count once")
+
+	resumeSecond()
+	require.Equal(t, 0, trap.paused)
+}
+
+func Test_InterruptTrap_GivenNilTrap_ShouldIgnorePause(t *testing.T) {
+	var trap *interruptTrap
+
+	require.NotPanics(t, func() { trap.pause()() })
+}
+
+func requireErrorCode(t *testing.T, expected containererrors.Code, err error) {
+	t.Helper()
+
+	var xerr *containererrors.ContainerExtensionError
+	require.True(t, errors.As(err, &xerr), "expected a container extension error, got %T", err)
+	require.Equal(t, expected, xerr.Code())
+}
@@ -14,19 +14,84 @@
 
 package sbom
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"io"
+)
 
 type GetSbomForDepGraphRequest struct {
 	DepGraphs []json.RawMessage `json:"depGraphs"`
 	Subject   Subject           `json:"subject"`
+
+	// API selects the version and endpoint of the SBOM API the request is sent to.
+	API SbomAPI `json:"-"`
+	// Output receives the SBOM document as it is downloaded if set, in which case the result holds no
+	// document. Clients which don't download the document may return it in the result anyway.
+	Output DocumentSink `json:"-"`
+}
+
+// DocumentSink receives an SBOM document while it is downloaded.
+type DocumentSink interface {
+	// Writer returns the writer of the document with the given media type.
+	Writer(mimeType string) (io.Writer, error)
 }
 
 type Subject struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+
+	// Registry and Repository are the normalized location of registry images, e.g. "docker.io" and
+	// "library/alpine".
+	Registry   string `json:"registry,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	// Digest is the digest of the image manifest, resolved from the registry or read from the archive
+	// if the image is not referenced by digest.
+	Digest string `json:"digest,omitempty"`
+	// ImageID is the digest of the image configuration. It is only known for local images.
+	ImageID  string `json:"imageId,omitempty"`
+	Platform string `json:"platform,omitempty"`
 }
 
 type GetSbomForDepGraphResult struct {
 	Doc      []byte
 	MIMEType string
 }
+
+// TestDepGraphResult lists the issues found by testing a depgraph.
+type TestDepGraphResult struct {
+	Issues []Issue `json:"issues"`
+	// IssuesData describes the issues by their id.
+	IssuesData map[string]IssueData `json:"issuesData"`
+}
+
+// Issue is an issue affecting a package of the depgraph.
+type Issue struct {
+	PkgName    string  `json:"pkgName"`
+	PkgVersion string  `json:"pkgVersion"`
+	IssueID    string  `json:"issueId"`
+	FixInfo    FixInfo `json:"fixInfo"`
+}
+
+// FixInfo tells which version of the package fixes an issue.
+type FixInfo struct {
+	NearestFixedInVersion string `json:"nearestFixedInVersion"`
+}
+
+// IssueData describes an issue.
+type IssueData struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Severity    string      `json:"severity"`
+	CVSSScore   float64     `json:"cvssScore"`
+	CVSSv3      string      `json:"CVSSv3"`
+	Identifiers Identifiers `json:"identifiers"`
+}
+
+// Identifiers are the ids of an issue in other databases.
+type Identifiers struct {
+	CVE []string `json:"CVE"`
+	CWE []string `json:"CWE"`
+}
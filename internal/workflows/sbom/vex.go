@@ -0,0 +1,189 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/snyk/container-cli/internal/workflows/sbom/vex"
+)
+
+// applyVex applies the statements about the image to the vulnerabilities found in it: statements saying
+// the image is not affected by, or fixed for, a vulnerability suppress it for the packages they cover.
+// Statements about other images are ignored, the statements about the image which match none of its
+// vulnerabilities are returned, as they are likely outdated.
+func applyVex(vulns []*vulnerability, statements []vex.Statement, subject Subject) []vex.Statement {
+	var stale []vex.Statement
+	for i := range statements {
+		s := &statements[i]
+		if !slices.ContainsFunc(s.Products, func(p string) bool { return matchesSubject(p, subject) }) {
+			continue
+		}
+
+		matched := false
+		for _, v := range vulns {
+			if !slices.ContainsFunc(s.Names(), v.isNamed) {
+				continue
+			}
+			for j := range v.affects {
+				pkg := &v.affects[j]
+				if len(s.Subcomponents) > 0 && !slices.ContainsFunc(s.Subcomponents, pkg.isIdentifiedBy) {
+					continue
+				}
+				matched = true
+				// the first statement suppressing the vulnerability for a package wins.
+				if s.Suppresses() && pkg.statement == nil {
+					pkg.statement = s
+				}
+			}
+		}
+		if !matched {
+			stale = append(stale, *s)
+		}
+	}
+	return stale
+}
+
+// staleVexReport lists the statements about the image which match none of its vulnerabilities.
+func staleVexReport(target string, stale []vex.Statement) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "    %d VEX statements no longer match any vulnerable package of %s:\n", len(stale), target)
+	for _, s := range stale {
+		fmt.Fprintf(&sb, "      %s  %s (%s)\n", s.Source, s.Vulnerability, s.Status)
+	}
+	return sb.String()
+}
+
+// isNamed returns true if the vulnerability is known by the given id.
+func (v *vulnerability) isNamed(id string) bool {
+	return strings.EqualFold(v.ID, id) || slices.ContainsFunc(v.Identifiers.CVE, func(cve string) bool {
+		return strings.EqualFold(cve, id)
+	})
+}
+
+// isIdentifiedBy returns true if the package URL, or name and version, identify the package. Package URLs
+// without a version identify all versions of the package, qualifiers are ignored.
+func (pkg *affectedPackage) isIdentifiedBy(id string) bool {
+	if !strings.HasPrefix(id, "pkg:") {
+		return id == pkg.name || id == pkg.name+"@"+pkg.version
+	}
+	if pkg.purl == "" {
+		return false
+	}
+
+	want, have := parsePURL(id), parsePURL(pkg.purl)
+	return want.typ == have.typ && want.name == have.name && (want.version == "" || want.version == have.version)
+}
+
+// matchesSubject returns true if the VEX product identifies the image. Products are package URLs of
+// type oci or docker, image references or image digests. Products which don't pin a tag or digest
+// match all versions of the image.
+func matchesSubject(product string, subject Subject) bool {
+	if product == "" {
+		return false
+	}
+	if product == subject.Digest || product == subject.ImageID {
+		return true
+	}
+
+	repository := subjectRepository(subject)
+	if strings.HasPrefix(product, "pkg:") {
+		p := parsePURL(product)
+		switch p.typ {
+		case "oci":
+			// oci purls carry the repository in a qualifier and the name only as its last element.
+			if repoURL := p.qualifiers.Get("repository_url"); repoURL != "" {
+				if named, err := reference.ParseNormalizedNamed(repoURL); err != nil || named.Name() != repository {
+					return false
+				}
+			} else if repository != p.name && !strings.HasSuffix(repository, "/"+p.name) {
+				return false
+			}
+			return p.version == "" || p.version == subject.Digest
+		case "docker":
+			name := p.name
+			if repoURL := p.qualifiers.Get("repository_url"); repoURL != "" {
+				name = strings.TrimSuffix(repoURL, "/") + "/" + name
+			}
+			named, err := reference.ParseNormalizedNamed(name)
+			if err != nil || named.Name() != repository {
+				return false
+			}
+			return p.version == "" || slices.Contains([]string{subject.Tag, subject.Digest, subject.Version}, p.version)
+		default:
+			return false
+		}
+	}
+
+	named, err := reference.ParseNormalizedNamed(product)
+	if err != nil || named.Name() != repository {
+		return false
+	}
+	if tagged, ok := named.(reference.Tagged); ok && tagged.Tag() != subject.Tag && tagged.Tag() != subject.Version {
+		return false
+	}
+	if digested, ok := named.(reference.Digested); ok && digested.Digest().String() != subject.Digest {
+		return false
+	}
+	return true
+}
+
+// subjectRepository returns the normalized name of the image repository, e.g. docker.io/library/alpine.
+func subjectRepository(subject Subject) string {
+	if subject.Registry != "" && subject.Repository != "" {
+		return subject.Registry + "/" + subject.Repository
+	}
+	if named, err := reference.ParseNormalizedNamed(subject.Name); err == nil {
+		return named.Name()
+	}
+	return subject.Name
+}
+
+type purl struct {
+	typ, name, version string
+	qualifiers         url.Values
+}
+
+// parsePURL splits a package URL into its components. The namespace is kept as part of the name, name and version
+// are percent-decoded.
+func parsePURL(s string) purl {
+	s, _, _ = strings.Cut(strings.TrimPrefix(s, "pkg:"), "#")
+	s, rawQualifiers, _ := strings.Cut(s, "?")
+	typ, s, _ := strings.Cut(s, "/")
+
+	var p purl
+	p.typ = strings.ToLower(typ)
+	p.qualifiers, _ = url.ParseQuery(rawQualifiers)
+	// the version follows the last "@", because namespaces such as npm scopes may contain "@" too.
+	if i := strings.LastIndex(s, "@"); i > strings.LastIndex(s, "/") {
+		p.version = unescapePURL(s[i+1:])
+		s = s[:i]
+	}
+	p.name = unescapePURL(s)
+	return p
+}
+
+// unescapePURL percent-decodes a part of a package URL, e.g. "1%3A5.2" to "1:5.2". Parts which are not validly
+// encoded are kept as they are.
+func unescapePURL(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// cdxStates maps the analysis states of CycloneDX to the OpenVEX statuses.
+var cdxStates = map[string]Status{
+	"not_affected":           StatusNotAffected,
+	"false_positive":         StatusNotAffected,
+	"resolved":               StatusFixed,
+	"resolved_with_pedigree": StatusFixed,
+	"exploitable":            StatusAffected,
+	"in_triage":              StatusUnderInvestigation,
+}
+
+// cdxJustifications maps the justifications of CycloneDX to the ones of OpenVEX.
+var cdxJustifications = map[string]string{
+	"code_not_present":                "vulnerable_code_not_present",
+	"code_not_reachable":              "vulnerable_code_not_in_execute_path",
+	"requires_environment":            "vulnerable_code_cannot_be_controlled_by_adversary",
+	"requires_dependency":             "component_not_present",
+	"protected_by_mitigating_control": "inline_mitigations_already_exist",
+	"protected_at_runtime":            "inline_mitigations_already_exist",
+	"protected_at_perimeter":          "inline_mitigations_already_exist",
+	"requires_configuration":          "vulnerable_code_cannot_be_controlled_by_adversary",
+}
+
+type cdxDocument struct {
+	Metadata struct {
+		Component *cdxComponent `json:"component"`
+	} `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+}
+
+type cdxComponent struct {
+	BOMRef     string         `json:"bom-ref"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version"`
+	PURL       string         `json:"purl"`
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxVulnerability struct {
+	ID         string `json:"id"`
+	References []struct {
+		ID string `json:"id"`
+	} `json:"references"`
+	Analysis *struct {
+		State         string `json:"state"`
+		Justification string `json:"justification"`
+		Detail        string `json:"detail"`
+	} `json:"analysis"`
+	Affects []struct {
+		Ref string `json:"ref"`
+	} `json:"affects"`
+}
+
+// parseCycloneDX reads the analysed vulnerabilities of a CycloneDX document. If the document describes
+// a component in its metadata, it is the product and the affected components are its subcomponents,
+// otherwise the affected components are the products.
+func parseCycloneDX(data []byte) ([]Statement, error) {
+	var doc cdxDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+	}
+
+	// affects reference components by their bom-ref, which are resolved to package URLs where possible.
+	ids := make(map[string]string)
+	var index func(components []cdxComponent)
+	index = func(components []cdxComponent) {
+		for i := range components {
+			ids[components[i].BOMRef] = components[i].id()
+			index(components[i].Components)
+		}
+	}
+	index(doc.Components)
+
+	var statements []Statement
+	for _, v := range doc.Vulnerabilities {
+		if v.Analysis == nil {
+			continue
+		}
+
+		status, ok := cdxStates[v.Analysis.State]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown analysis state %q", v.ID, v.Analysis.State)
+		}
+		statement := Statement{
+			Vulnerability:   v.ID,
+			Status:          status,
+			Justification:   cdxJustifications[v.Analysis.Justification],
+			ImpactStatement: v.Analysis.Detail,
+		}
+		if status == StatusNotAffected && statement.ImpactStatement == "" && statement.Justification == "" {
+			// false positives need no further justification in CycloneDX.
+			statement.ImpactStatement = "false positive"
+		}
+		for _, ref := range v.References {
+			statement.Aliases = append(statement.Aliases, ref.ID)
+		}
+
+		var affected []string
+		for _, a := range v.Affects {
+			if id, ok := ids[a.Ref]; ok {
+				affected = append(affected, id)
+			} else {
+				affected = append(affected, a.Ref)
+			}
+		}
+		if product := doc.Metadata.Component; product != nil {
+			statement.Products = []string{product.id()}
+			statement.Subcomponents = affected
+		} else {
+			statement.Products = affected
+		}
+
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func (c *cdxComponent) id() string {
+	switch {
+	case c.PURL != "":
+		return c.PURL
+	case c.Version != "":
+		return c.Name + ":" + c.Version
+	case c.Name != "":
+		return c.Name
+	default:
+		return c.BOMRef
+	}
+}
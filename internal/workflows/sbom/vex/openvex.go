@@ -0,0 +1,108 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type openVEXDocument struct {
+	Statements []openVEXStatement `json:"statements"`
+}
+
+// openVEXStatement covers both OpenVEX v0.2.0, which describes vulnerabilities and products with
+// objects, and earlier versions which identify them by strings.
+type openVEXStatement struct {
+	Vulnerability   json.RawMessage   `json:"vulnerability"`
+	Products        []json.RawMessage `json:"products"`
+	Subcomponents   []json.RawMessage `json:"subcomponents"`
+	Status          Status            `json:"status"`
+	Justification   string            `json:"justification"`
+	ImpactStatement string            `json:"impact_statement"`
+}
+
+type openVEXVulnerability struct {
+	ID      string   `json:"@id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+type openVEXComponent struct {
+	ID          string `json:"@id"`
+	Identifiers struct {
+		PURL string `json:"purl"`
+	} `json:"identifiers"`
+	Subcomponents []openVEXComponent `json:"subcomponents"`
+}
+
+func parseOpenVEX(data []byte) ([]Statement, error) {
+	var doc openVEXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenVEX document: %w", err)
+	}
+
+	statements := make([]Statement, 0, len(doc.Statements))
+	for i, s := range doc.Statements {
+		statement := Statement{Status: s.Status, Justification: s.Justification, ImpactStatement: s.ImpactStatement}
+
+		var vuln openVEXVulnerability
+		if err := unmarshalStringOr(s.Vulnerability, &vuln.Name, &vuln); err != nil {
+			return nil, fmt.Errorf("statement %d: invalid vulnerability: %w", i+1, err)
+		}
+		statement.Vulnerability = vuln.Name
+		if statement.Vulnerability == "" {
+			statement.Vulnerability = vuln.ID
+		}
+		statement.Aliases = vuln.Aliases
+
+		for _, raw := range s.Products {
+			var product openVEXComponent
+			if err := unmarshalStringOr(raw, &product.ID, &product); err != nil {
+				return nil, fmt.Errorf("statement %d: invalid product: %w", i+1, err)
+			}
+			statement.Products = append(statement.Products, product.id())
+			for _, sub := range product.Subcomponents {
+				statement.Subcomponents = append(statement.Subcomponents, sub.id())
+			}
+		}
+		for _, raw := range s.Subcomponents {
+			var sub openVEXComponent
+			if err := unmarshalStringOr(raw, &sub.ID, &sub); err != nil {
+				return nil, fmt.Errorf("statement %d: invalid subcomponent: %w", i+1, err)
+			}
+			statement.Subcomponents = append(statement.Subcomponents, sub.id())
+		}
+
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// id prefers the package URL, which is what products are matched by.
+func (c *openVEXComponent) id() string {
+	if c.Identifiers.PURL != "" {
+		return c.Identifiers.PURL
+	}
+	return c.ID
+}
+
+// unmarshalStringOr unmarshals a JSON string into s, and anything else into v.
+func unmarshalStringOr(raw json.RawMessage, s *string, v any) error {
+	if len(raw) > 0 && raw[0] == '"' {
+		return json.Unmarshal(raw, s)
+	}
+	return json.Unmarshal(raw, v)
+}
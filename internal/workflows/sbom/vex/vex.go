@@ -0,0 +1,144 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vex reads the statements of OpenVEX and CycloneDX VEX documents, which tell whether products
+// are affected by vulnerabilities.
+package vex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Status is the impact of a vulnerability on a product, as defined by OpenVEX.
+type Status string
+
+const (
+	StatusNotAffected        Status = "not_affected"
+	StatusAffected           Status = "affected"
+	StatusFixed              Status = "fixed"
+	StatusUnderInvestigation Status = "under_investigation"
+)
+
+var validStatuses = []Status{StatusNotAffected, StatusAffected, StatusFixed, StatusUnderInvestigation}
+
+// validJustifications are the OpenVEX justifications of not_affected statements.
+var validJustifications = []string{
+	"component_not_present",
+	"vulnerable_code_not_present",
+	"vulnerable_code_not_in_execute_path",
+	"vulnerable_code_cannot_be_controlled_by_adversary",
+	"inline_mitigations_already_exist",
+}
+
+// ErrUnsupportedFormat is returned for documents which are neither OpenVEX nor CycloneDX.
+var ErrUnsupportedFormat = errors.New("unsupported VEX format, expected an OpenVEX or CycloneDX document")
+
+// Statement tells the impact of a vulnerability on products.
+type Statement struct {
+	// Vulnerability is the id of the vulnerability, Aliases are its ids in other databases.
+	Vulnerability string
+	Aliases       []string
+	// Products are package URLs or image references of the products the statement applies to.
+	Products []string
+	// Subcomponents are package URLs of the packages of the products the statement is limited to. The
+	// statement applies to all packages of the products if empty.
+	Subcomponents []string
+	Status        Status
+	Justification string
+	// ImpactStatement explains why a product is not affected.
+	ImpactStatement string
+	// Source locates the statement, e.g. "vex.json#2", for reporting.
+	Source string
+}
+
+// Suppresses returns true if the statement states the vulnerability does not (or no longer) affect the products.
+func (s *Statement) Suppresses() bool {
+	return s.Status == StatusNotAffected || s.Status == StatusFixed
+}
+
+// Names returns the id and the aliases of the vulnerability.
+func (s *Statement) Names() []string {
+	return append([]string{s.Vulnerability}, s.Aliases...)
+}
+
+// Load reads the statements of the VEX document at the given path.
+func Load(path string) ([]Statement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, path)
+}
+
+// Parse reads the statements of an OpenVEX or CycloneDX VEX document. The source names the document
+// in the Source of the statements.
+func Parse(data []byte, source string) ([]Statement, error) {
+	var probe struct {
+		Context   json.RawMessage `json:"@context"`
+		BOMFormat string          `json:"bomFormat"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid VEX document: %w", err)
+	}
+
+	var statements []Statement
+	var err error
+	switch {
+	case bytes.Contains(probe.Context, []byte("openvex.dev/ns")):
+		statements, err = parseOpenVEX(data)
+	case probe.BOMFormat == "CycloneDX":
+		statements, err = parseCycloneDX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range statements {
+		statements[i].Source = fmt.Sprintf("%s#%d", source, i+1)
+		if err := statements[i].validate(); err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return statements, nil
+}
+
+func (s *Statement) validate() error {
+	if s.Vulnerability == "" {
+		return errors.New("the vulnerability is missing")
+	}
+	if len(s.Products) == 0 {
+		return fmt.Errorf("%s: the products are missing", s.Vulnerability)
+	}
+	if !slices.Contains(validStatuses, s.Status) {
+		return fmt.Errorf("%s: unknown status %q", s.Vulnerability, s.Status)
+	}
+	if s.Status == StatusNotAffected {
+		if s.Justification == "" && s.ImpactStatement == "" {
+			return fmt.Errorf("%s: not_affected requires a justification or an impact statement", s.Vulnerability)
+		}
+		if s.Justification != "" && !slices.Contains(validJustifications, s.Justification) {
+			return fmt.Errorf("%s: unknown justification %q, expected one of %s",
+				s.Vulnerability, s.Justification, strings.Join(validJustifications, ", "))
+		}
+	}
+	return nil
+}
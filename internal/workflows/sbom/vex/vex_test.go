@@ -0,0 +1,156 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse_GivenOpenVEX_ShouldReturnStatements(t *testing.T) {
+	doc := `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "https://example.com/vex-1",
+  "statements": [
+    {
+      "vulnerability": {"name": "CVE-2023-1234", "aliases": ["GHSA-xxxx"]},
+      "products": [{
+        "@id": "pkg:oci/alpine",
+        "subcomponents": [{"@id": "pkg:apk/alpine/testpkg", "identifiers": {"purl": "pkg:apk/alpine/testpkg@10.10"}}]
+      }],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": "CVE-2023-5678",
+      "products": ["alpine:3.17.0"],
+      "subcomponents": ["pkg:apk/alpine/other"],
+      "status": "fixed"
+    }
+  ]
+}`
+
+	statements, err := Parse([]byte(doc), "vex.json")
+	require.NoError(t, err)
+	require.Equal(t, []Statement{
+		{
+			Vulnerability: "CVE-2023-1234",
+			Aliases:       []string{"GHSA-xxxx"},
+			Products:      []string{"pkg:oci/alpine"},
+			Subcomponents: []string{"pkg:apk/alpine/testpkg@10.10"},
+			Status:        StatusNotAffected,
+			Justification: "vulnerable_code_not_in_execute_path",
+			Source:        "vex.json#1",
+		},
+		{
+			Vulnerability: "CVE-2023-5678",
+			Products:      []string{"alpine:3.17.0"},
+			Subcomponents: []string{"pkg:apk/alpine/other"},
+			Status:        StatusFixed,
+			Source:        "vex.json#2",
+		},
+	}, statements)
+}
+
+func Test_Parse_GivenCycloneDX_ShouldReturnAnalysedVulnerabilities(t *testing.T) {
+	doc := `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "metadata": {"component": {"type": "container", "name": "alpine", "purl": "pkg:oci/alpine"}},
+  "components": [{"bom-ref": "pkg-1", "name": "testpkg", "version": "10.10", "purl": "pkg:apk/alpine/testpkg@10.10"}],
+  "vulnerabilities": [
+    {
+      "id": "SNYK-ALPINE317-TESTPKG-1",
+      "references": [{"id": "CVE-2023-1234"}],
+      "analysis": {"state": "not_affected", "justification": "code_not_reachable", "detail": "not loaded"},
+      "affects": [{"ref": "pkg-1"}]
+    },
+    {"id": "CVE-2023-5678", "analysis": {"state": "false_positive"}, "affects": [{"ref": "pkg:apk/alpine/x"}]},
+    {"id": "CVE-2023-9999", "affects": [{"ref": "pkg-1"}]}
+  ]
+}`
+
+	statements, err := Parse([]byte(doc), "vex.cdx.json")
+	require.NoError(t, err)
+	require.Equal(t, []Statement{
+		{
+			Vulnerability:   "SNYK-ALPINE317-TESTPKG-1",
+			Aliases:         []string{"CVE-2023-1234"},
+			Products:        []string{"pkg:oci/alpine"},
+			Subcomponents:   []string{"pkg:apk/alpine/testpkg@10.10"},
+			Status:          StatusNotAffected,
+			Justification:   "vulnerable_code_not_in_execute_path",
+			ImpactStatement: "not loaded",
+			Source:          "vex.cdx.json#1",
+		},
+		{
+			Vulnerability:   "CVE-2023-5678",
+			Products:        []string{"pkg:oci/alpine"},
+			Subcomponents:   []string{"pkg:apk/alpine/x"},
+			Status:          StatusNotAffected,
+			ImpactStatement: "false positive",
+			Source:          "vex.cdx.json#2",
+		},
+	}, statements)
+}
+
+func Test_Parse_GivenInvalidDocument_ShouldReturnError(t *testing.T) {
+	tests := map[string]struct {
+		doc           string
+		expectedError string
+	}{
+		"not json": {
+			doc:           "vulnerabilities: []",
+			expectedError: "invalid VEX document",
+		},
+		"unsupported format": {
+			doc:           `{"spdxVersion":"SPDX-2.3"}`,
+			expectedError: ErrUnsupportedFormat.Error(),
+		},
+		"missing products": {
+			doc: `{"@context":"https://openvex.dev/ns/v0.2.0","statements":[` +
+				`{"vulnerability":{"name":"CVE-1"},"status":"fixed"}]}`,
+			expectedError: "statement 1: CVE-1: the products are missing",
+		},
+		"unknown status": {
+			doc: `{"@context":"https://openvex.dev/ns/v0.2.0","statements":[` +
+				`{"vulnerability":{"name":"CVE-1"},"products":["alpine"],"status":"ignored"}]}`,
+			expectedError: `statement 1: CVE-1: unknown status "ignored"`,
+		},
+		"not affected without justification": {
+			doc: `{"@context":"https://openvex.dev/ns/v0.2.0","statements":[` +
+				`{"vulnerability":{"name":"CVE-1"},"products":["alpine"],"status":"not_affected"}]}`,
+			expectedError: "statement 1: CVE-1: not_affected requires a justification or an impact statement",
+		},
+		"unknown justification": {
+			doc: `{"@context":"https://openvex.dev/ns/v0.2.0","statements":[{"vulnerability":{"name":"CVE-1"},` +
+				`"products":["alpine"],"status":"not_affected","justification":"trust me"}]}`,
+			expectedError: `statement 1: CVE-1: unknown justification "trust me"`,
+		},
+		"unknown analysis state": {
+			doc: `{"bomFormat":"CycloneDX","vulnerabilities":[` +
+				`{"id":"CVE-1","analysis":{"state":"maybe"},"affects":[{"ref":"pkg:oci/alpine"}]}]}`,
+			expectedError: `CVE-1: unknown analysis state "maybe"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.doc), "vex.json")
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
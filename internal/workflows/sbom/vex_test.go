@@ -0,0 +1,193 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/stretchr/testify/require"
+)
+
+const testVex = `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "statements": [
+    {
+      "vulnerability": {"name": "CVE-2023-1234"},
+      "products": [{"@id": "pkg:oci/alpine", "subcomponents": [{"@id": "testpkg@10.10"}]}],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": {"name": "CVE-2020-0001"},
+      "products": [{"@id": "docker.io/library/alpine"}],
+      "status": "fixed"
+    },
+    {
+      "vulnerability": {"name": "CVE-2020-0002"},
+      "products": [{"@id": "nginx:1.25"}],
+      "status": "fixed"
+    }
+  ]
+}`
+
+// writeVex writes the VEX document into a temporary file and returns its path.
+func writeVex(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vex.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_Entrypoint_GivenVexAndCycloneDXVulnerabilities_ShouldSuppressNotAffected(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	dir := t.TempDir()
+	vexFile := writeVex(t, testVex)
+	config.Set(flags.FlagVulnerabilities.Name, "cyclonedx")
+	config.Set(flags.FlagVex.Name, vexFile)
+	config.Set(flags.FlagOutputFile.Name, filepath.Join(dir, "sbom.json"))
+	expectSbomResultDoc(t)
+	expectTestDepGraph(t)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	summary, ok := result[0].GetPayload().([]byte)
+	require.True(t, ok)
+	require.Contains(t, string(summary), "    1 VEX statements no longer match any vulnerable package of alpine:3.17.0:\n"+
+		"      "+vexFile+"#2  CVE-2020-0001 (fixed)\n")
+
+	doc, err := os.ReadFile(filepath.Join(dir, "sbom.json"))
+	require.NoError(t, err)
+	var bom struct {
+		Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+	}
+	require.NoError(t, json.Unmarshal(doc, &bom))
+	require.Empty(t, bom.Vulnerabilities)
+}
+
+func Test_Entrypoint_GivenVexAndOpenVEXVulnerabilities_ShouldMergeStatements(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagVulnerabilities.Name, "openvex")
+	vexFile := writeVex(t, testVex)
+	config.Set(flags.FlagVex.Name, vexFile)
+	expectSbomResultDoc(t)
+	expectTestDepGraph(t)
+	var stderr bytes.Buffer
+	sbomWorkflow.stderr = &stderr
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+
+	// the SBOM and VEX documents are printed to stdout, the report goes to stderr instead.
+	require.Equal(t, "    1 VEX statements no longer match any vulnerable package of alpine:3.17.0:\n"+
+		"      "+vexFile+"#2  CVE-2020-0001 (fixed)\n", stderr.String())
+
+	payload, ok := result[1].GetPayload().([]byte)
+	require.True(t, ok)
+
+	var doc openVEXDocument
+	require.NoError(t, json.Unmarshal(payload, &doc))
+	require.Len(t, doc.Statements, 1)
+	require.Equal(t, "CVE-2023-1234", doc.Statements[0].Vulnerability.Name)
+	require.Equal(t, "not_affected", string(doc.Statements[0].Status))
+	require.Equal(t, "vulnerable_code_not_in_execute_path", doc.Statements[0].Justification)
+	require.Empty(t, doc.Statements[0].ActionStatement)
+}
+
+func Test_Entrypoint_GivenVexWithoutVulnerabilities_ShouldReturnError(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagVex.Name, writeVex(t, testVex))
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.EqualError(t, err, errFactory.NewVexWithoutVulnerabilitiesError().Error())
+}
+
+func Test_Entrypoint_GivenInvalidVex_ShouldReturnVexFileError(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagVulnerabilities.Name, "openvex")
+	config.Set(flags.FlagVex.Name, writeVex(t, `{"bomFormat":"SPDX"}`))
+
+	_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.ErrorContains(t, err, "The VEX file")
+	require.ErrorContains(t, err, "unsupported VEX format")
+}
+
+func Test_IsIdentifiedBy_GivenPercentEncodedPURL_ShouldCompareDecodedVersions(t *testing.T) {
+	pkg := &affectedPackage{name: "bash", version: "1:5.2.15-2", purl: "pkg:deb/debian/bash@1:5.2.15-2"}
+
+	tests := map[string]bool{
+		"pkg:deb/debian/bash@1%3A5.2.15-2":           true,
+		"pkg:deb/debian/bash@1%3A5.2.15-2?arch=i386": true,
+		"pkg:deb/debian/bash@1%3A5.2.15-3":           false,
+		"pkg:deb/debian/bash":                        true,
+		"pkg:deb/debian/%62ash@1:5.2.15-2":           true,
+		"pkg:deb/debian/bash@1%ZZ":                   false,
+		"bash@1:5.2.15-2":                            true,
+	}
+
+	for id, expected := range tests {
+		t.Run(id, func(t *testing.T) {
+			require.Equal(t, expected, pkg.isIdentifiedBy(id))
+		})
+	}
+}
+
+func Test_ParsePURL_GivenScopeWithoutVersion_ShouldKeepScopeInName(t *testing.T) {
+	p := parsePURL("pkg:npm/@acme/lib")
+
+	require.Equal(t, "@acme/lib", p.name)
+	require.Empty(t, p.version)
+}
+
+func Test_MatchesSubject_GivenProduct_ShouldMatchImage(t *testing.T) {
+	subject := alpineSubject("")
+	digest := subject.Digest
+	encoded := strings.ReplaceAll(digest, ":", "%3A")
+
+	tests := map[string]bool{
+		"alpine":                     true,
+		"alpine:3.17.0":              true,
+		"alpine:3.18.0":              false,
+		"docker.io/library/alpine":   true,
+		"alpine@" + digest:           true,
+		digest:                       true,
+		"nginx":                      false,
+		"pkg:oci/alpine":             true,
+		"pkg:oci/alpine@" + encoded:  true,
+		"pkg:oci/alpine@sha256%3A00": false,
+		"pkg:oci/alpine?repository_url=docker.io/library/alpine": true,
+		"pkg:oci/alpine?repository_url=ghcr.io/acme/alpine":      false,
+		"pkg:docker/alpine@3.17.0":                               true,
+		"pkg:docker/library/alpine@3.17.0":                       true,
+		"pkg:docker/alpine@3.18.0":                               false,
+		"pkg:npm/alpine":                                         false,
+		"":                                                       false,
+	}
+
+	for product, expected := range tests {
+		t.Run(product, func(t *testing.T) {
+			require.Equal(t, expected, matchesSubject(product, subject))
+		})
+	}
+}
@@ -0,0 +1,547 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	"github.com/snyk/container-cli/internal/workflows/sbom/vex"
+)
+
+const (
+	vulnerabilitiesCycloneDX = "cyclonedx"
+	vulnerabilitiesOpenVEX   = "openvex"
+
+	// MIMETypeOpenVEX is the media type of OpenVEX documents.
+	MIMETypeOpenVEX = "application/openvex+json"
+
+	openVEXContext = "https://openvex.dev/ns/v0.2.0"
+	snykVulnURL    = "https://security.snyk.io/vuln/"
+)
+
+// validateVulnerabilities checks the vulnerabilities mode and that it can be applied to the SBOM format.
+func (w *Workflow) validateVulnerabilities(mode, format string) error {
+	if mode == "" {
+		return nil
+	}
+	if !flags.FlagVulnerabilities.IsAllowed(mode) {
+		return w.errFactory.NewInvalidVulnerabilitiesModeError(mode, flags.FlagVulnerabilities.AllowedValues())
+	}
+	if mode == vulnerabilitiesCycloneDX && !isCycloneDXJSON(format) {
+		return w.errFactory.NewVulnerabilitiesFormatError(format)
+	}
+	return nil
+}
+
+func isCycloneDXJSON(format string) bool {
+	return strings.HasPrefix(format, "cyclonedx") && strings.HasSuffix(format, "+json")
+}
+
+// addVulnerabilities tests the depgraphs of the image and either embeds the vulnerabilities found into the
+// SBOM of the output, or adds them as an OpenVEX document accompanying the SBOM. The VEX statements of the
+// options are applied to the vulnerabilities first.
+func (w *Workflow) addVulnerabilities(
+	ctx context.Context,
+	out *sbomOutput,
+	depGraphs []json.RawMessage,
+	opts generateOptions,
+) error {
+	vulns, err := w.fetchVulnerabilities(ctx, opts.orgID, depGraphs)
+	if err != nil {
+		return err
+	}
+
+	// packages are identified by their purl in VEX documents, which only the SBOM knows.
+	if doc, err := document.Parse(out.result.Doc); err == nil {
+		purls := make(map[string]string)
+		for _, c := range doc.Components {
+			if c.PURL != "" {
+				purls[c.Name+"@"+c.Version] = c.PURL
+			}
+		}
+		for _, v := range vulns {
+			for i := range v.affects {
+				v.affects[i].purl = purls[v.affects[i].name+"@"+v.affects[i].version]
+			}
+		}
+	}
+
+	if len(opts.vex) > 0 {
+		out.staleVex = applyVex(vulns, opts.vex, out.subject)
+	}
+
+	if opts.vulnerabilities == vulnerabilitiesCycloneDX {
+		doc, err := embedVulnerabilities(out.result.Doc, vulns)
+		if err != nil {
+			return w.errFactory.NewInternalError(fmt.Errorf("could not embed the vulnerabilities: %w", err))
+		}
+		out.result = &GetSbomForDepGraphResult{Doc: doc, MIMEType: out.result.MIMEType}
+		return nil
+	}
+
+	doc, err := newOpenVEX(out.subject, vulns, w.now())
+	if err != nil {
+		return w.errFactory.NewInternalError(fmt.Errorf("could not create the OpenVEX document: %w", err))
+	}
+	out.vex = &GetSbomForDepGraphResult{Doc: doc, MIMEType: MIMETypeOpenVEX}
+	return nil
+}
+
+// vulnerability is an issue found by testing the depgraphs of an image, along with the packages it affects.
+type vulnerability struct {
+	IssueData
+	affects []affectedPackage
+}
+
+type affectedPackage struct {
+	name, version, fixedIn string
+	// purl is the package URL of the package in the SBOM, if known.
+	purl string
+	// statement is the imported VEX statement suppressing the vulnerability for this package, if any.
+	statement *vex.Statement
+}
+
+// fetchVulnerabilities tests the depgraphs of an image and returns the vulnerabilities found, ordered by id.
+// License issues are not vulnerabilities and are left out.
+func (w *Workflow) fetchVulnerabilities(
+	ctx context.Context,
+	orgID string,
+	depGraphs []json.RawMessage,
+) ([]*vulnerability, error) {
+	byID := make(map[string]*vulnerability)
+	for _, depGraph := range depGraphs {
+		result, err := w.vulnClient.TestDepGraph(ctx, orgID, depGraph)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range result.Issues {
+			data, ok := result.IssuesData[issue.IssueID]
+			if !ok || data.Type == "license" {
+				continue
+			}
+			v, ok := byID[issue.IssueID]
+			if !ok {
+				v = &vulnerability{IssueData: data}
+				v.ID = issue.IssueID
+				byID[issue.IssueID] = v
+			}
+			pkg := affectedPackage{
+				name:    issue.PkgName,
+				version: issue.PkgVersion,
+				fixedIn: issue.FixInfo.NearestFixedInVersion,
+			}
+			if !slices.Contains(v.affects, pkg) {
+				v.affects = append(v.affects, pkg)
+			}
+		}
+	}
+
+	vulns := make([]*vulnerability, 0, len(byID))
+	for _, v := range byID {
+		vulns = append(vulns, v)
+	}
+	slices.SortFunc(vulns, func(a, b *vulnerability) int { return strings.Compare(a.ID, b.ID) })
+	return vulns, nil
+}
+
+// unsuppressed returns the affected packages no VEX statement suppresses the vulnerability for.
+func (v *vulnerability) unsuppressed() []affectedPackage {
+	return slices.DeleteFunc(slices.Clone(v.affects), func(pkg affectedPackage) bool { return pkg.statement != nil })
+}
+
+// suppressed returns true if VEX statements suppress the vulnerability for all affected packages.
+func (v *vulnerability) suppressed() bool {
+	return len(v.affects) > 0 && len(v.unsuppressed()) == 0
+}
+
+// recommendation tells how to remediate the vulnerability in the packages it is not suppressed for.
+func (v *vulnerability) recommendation() string {
+	var upgrades []string
+	for _, pkg := range v.unsuppressed() {
+		if pkg.fixedIn != "" {
+			upgrades = append(upgrades, fmt.Sprintf("%s to %s", pkg.name, pkg.fixedIn))
+		}
+	}
+	if len(upgrades) == 0 {
+		return "No fixed version is available yet."
+	}
+	return "Upgrade " + strings.Join(upgrades, ", ") + "."
+}
+
+type cdxVulnerability struct {
+	BOMRef         string         `json:"bom-ref"`
+	ID             string         `json:"id"`
+	Source         cdxVulnSource  `json:"source"`
+	References     []cdxReference `json:"references,omitempty"`
+	Ratings        []cdxRating    `json:"ratings,omitempty"`
+	CWEs           []int          `json:"cwes,omitempty"`
+	Description    string         `json:"description,omitempty"`
+	Detail         string         `json:"detail,omitempty"`
+	Recommendation string         `json:"recommendation,omitempty"`
+	Affects        []cdxAffects   `json:"affects,omitempty"`
+}
+
+type cdxVulnSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cdxReference struct {
+	ID     string        `json:"id"`
+	Source cdxVulnSource `json:"source"`
+}
+
+type cdxRating struct {
+	Source   cdxVulnSource `json:"source"`
+	Score    float64       `json:"score,omitempty"`
+	Severity string        `json:"severity,omitempty"`
+	Method   string        `json:"method,omitempty"`
+	Vector   string        `json:"vector,omitempty"`
+}
+
+type cdxAffects struct {
+	Ref string `json:"ref"`
+}
+
+// cdxRefComponent is the part of a CycloneDX component needed to reference it from a vulnerability.
+type cdxRefComponent struct {
+	BOMRef     string            `json:"bom-ref"`
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	Components []cdxRefComponent `json:"components"`
+}
+
+// embedVulnerabilities adds the vulnerabilities to the CycloneDX JSON document. Vulnerabilities reference
+// the components they affect by their bom-ref, components missing from the document and components VEX
+// statements suppress the vulnerability for are left out. Vulnerabilities the document already lists are kept,
+// and the document is extended in place rather than re-encoded, which would reorder its fields.
+func embedVulnerabilities(doc []byte, vulns []*vulnerability) ([]byte, error) {
+	var bom map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &bom); err != nil {
+		return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+	}
+
+	var components []cdxRefComponent
+	if raw, ok := bom["components"]; ok {
+		if err := json.Unmarshal(raw, &components); err != nil {
+			return nil, fmt.Errorf("invalid CycloneDX components: %w", err)
+		}
+	}
+	refs := make(map[string]string)
+	var index func(components []cdxRefComponent)
+	index = func(components []cdxRefComponent) {
+		for _, c := range components {
+			if _, ok := refs[c.Name+"@"+c.Version]; !ok && c.BOMRef != "" {
+				refs[c.Name+"@"+c.Version] = c.BOMRef
+			}
+			index(c.Components)
+		}
+	}
+	index(components)
+
+	// bom-refs must be unique within the document, so vulnerabilities it already lists are not added again.
+	var existing []struct {
+		BOMRef string `json:"bom-ref"`
+		ID     string `json:"id"`
+	}
+	existingRaw, hasVulnerabilities := bom["vulnerabilities"]
+	if hasVulnerabilities {
+		if err := json.Unmarshal(existingRaw, &existing); err != nil {
+			return nil, fmt.Errorf("invalid CycloneDX vulnerabilities: %w", err)
+		}
+	}
+	listed := make(map[string]bool)
+	for _, v := range existing {
+		listed[v.BOMRef] = true
+		listed[v.ID] = true
+	}
+
+	var added [][]byte
+	for _, v := range vulns {
+		if v.suppressed() || listed[v.ID] {
+			continue
+		}
+		raw, err := json.Marshal(v.cycloneDX(refs))
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, raw)
+	}
+
+	if hasVulnerabilities {
+		start, end, err := topLevelValue(doc, "vulnerabilities")
+		if err != nil {
+			return nil, fmt.Errorf("invalid CycloneDX document: %w", err)
+		}
+		return splice(doc, start, end, appendToArray(doc[start:end], added)), nil
+	}
+
+	trimmed := bytes.TrimRight(doc, " \t\r\n")
+	closing := len(trimmed) - 1
+	var out bytes.Buffer
+	out.Write(bytes.TrimRight(trimmed[:closing], " \t\r\n"))
+	if len(bom) > 0 {
+		out.WriteByte(',')
+	}
+	out.WriteString(`"vulnerabilities":`)
+	out.Write(appendToArray([]byte("[]"), added))
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// topLevelValue returns the offsets of the value of a key of the JSON object in doc.
+func topLevelValue(doc []byte, key string) (start, end int, err error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	if _, err := dec.Token(); err != nil {
+		return 0, 0, err
+	}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return 0, 0, err
+		}
+		if name == key {
+			// the decoded value is the raw content of the document, which ends where the decoder stopped.
+			end = int(dec.InputOffset())
+			return end - len(value), end, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%q not found", key)
+}
+
+// appendToArray appends the encoded items to the JSON array, leaving its existing items untouched.
+func appendToArray(array []byte, items [][]byte) []byte {
+	if len(items) == 0 {
+		return array
+	}
+
+	body := bytes.TrimSpace(array)
+	body = bytes.TrimSpace(body[1 : len(body)-1])
+	var out bytes.Buffer
+	out.WriteByte('[')
+	out.Write(body)
+	for i, item := range items {
+		if i > 0 || len(body) > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(item)
+	}
+	out.WriteByte(']')
+	return out.Bytes()
+}
+
+func splice(doc []byte, start, end int, value []byte) []byte {
+	out := make([]byte, 0, len(doc)-(end-start)+len(value))
+	out = append(out, doc[:start]...)
+	out = append(out, value...)
+	return append(out, doc[end:]...)
+}
+
+func (v *vulnerability) cycloneDX(refs map[string]string) cdxVulnerability {
+	snyk := cdxVulnSource{Name: "Snyk", URL: snykVulnURL + v.ID}
+	cv := cdxVulnerability{
+		BOMRef:         v.ID,
+		ID:             v.ID,
+		Source:         snyk,
+		Description:    v.Title,
+		Detail:         v.Description,
+		Recommendation: v.recommendation(),
+	}
+
+	for _, cve := range v.Identifiers.CVE {
+		cv.References = append(cv.References, cdxReference{
+			ID:     cve,
+			Source: cdxVulnSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + cve},
+		})
+	}
+	for _, cwe := range v.Identifiers.CWE {
+		if n, err := strconv.Atoi(strings.TrimPrefix(cwe, "CWE-")); err == nil {
+			cv.CWEs = append(cv.CWEs, n)
+		}
+	}
+
+	rating := cdxRating{Source: cdxVulnSource{Name: "Snyk"}, Score: v.CVSSScore, Severity: v.Severity}
+	if v.CVSSv3 != "" {
+		rating.Vector = v.CVSSv3
+		rating.Method = "CVSSv3"
+		if strings.HasPrefix(v.CVSSv3, "CVSS:3.1/") {
+			rating.Method = "CVSSv31"
+		}
+	}
+	cv.Ratings = []cdxRating{rating}
+
+	for _, pkg := range v.unsuppressed() {
+		if ref, ok := refs[pkg.name+"@"+pkg.version]; ok {
+			cv.Affects = append(cv.Affects, cdxAffects{Ref: ref})
+		}
+	}
+	return cv
+}
+
+type openVEXDocument struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  string             `json:"timestamp"`
+	Version    int                `json:"version"`
+	Tooling    string             `json:"tooling"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability   openVEXVulnerability `json:"vulnerability"`
+	Products        []openVEXProduct     `json:"products"`
+	Status          vex.Status           `json:"status"`
+	Justification   string               `json:"justification,omitempty"`
+	ImpactStatement string               `json:"impact_statement,omitempty"`
+	ActionStatement string               `json:"action_statement,omitempty"`
+}
+
+type openVEXVulnerability struct {
+	ID          string   `json:"@id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+type openVEXProduct struct {
+	ID            string             `json:"@id"`
+	Subcomponents []openVEXComponent `json:"subcomponents,omitempty"`
+}
+
+type openVEXComponent struct {
+	ID string `json:"@id"`
+}
+
+// newOpenVEX returns an OpenVEX document stating that the image is affected by the vulnerabilities, unless
+// imported VEX statements say otherwise. The affected packages are identified by their purls.
+func newOpenVEX(subject Subject, vulns []*vulnerability, now time.Time) ([]byte, error) {
+	product := imagePURL(subject)
+
+	statements := make([]openVEXStatement, 0, len(vulns))
+	for _, v := range vulns {
+		// CVEs are what VEX consumers match against, the Snyk id is kept as an alias.
+		name, aliases := v.ID, v.Identifiers.CVE
+		if len(aliases) > 0 {
+			name, aliases = aliases[0], append(slices.Clone(aliases[1:]), v.ID)
+		}
+		vulnerability := openVEXVulnerability{ID: snykVulnURL + v.ID, Name: name, Description: v.Title, Aliases: aliases}
+
+		if unsuppressed := v.unsuppressed(); len(unsuppressed) > 0 || len(v.affects) == 0 {
+			statements = append(statements, openVEXStatement{
+				Vulnerability:   vulnerability,
+				Products:        []openVEXProduct{newOpenVEXProduct(product, unsuppressed)},
+				Status:          vex.StatusAffected,
+				ActionStatement: v.recommendation(),
+			})
+		}
+
+		// the packages a statement suppresses the vulnerability for, in the order of the statements.
+		var suppressing []*vex.Statement
+		packages := make(map[*vex.Statement][]affectedPackage)
+		for _, pkg := range v.affects {
+			if pkg.statement == nil {
+				continue
+			}
+			if _, ok := packages[pkg.statement]; !ok {
+				suppressing = append(suppressing, pkg.statement)
+			}
+			packages[pkg.statement] = append(packages[pkg.statement], pkg)
+		}
+		for _, s := range suppressing {
+			statements = append(statements, openVEXStatement{
+				Vulnerability:   vulnerability,
+				Products:        []openVEXProduct{newOpenVEXProduct(product, packages[s])},
+				Status:          s.Status,
+				Justification:   s.Justification,
+				ImpactStatement: s.ImpactStatement,
+			})
+		}
+	}
+
+	content, err := json.Marshal(statements)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+
+	return json.MarshalIndent(openVEXDocument{
+		Context:    openVEXContext,
+		ID:         "https://openvex.dev/docs/public/vex-" + hex.EncodeToString(sum[:]),
+		Author:     "Snyk",
+		Timestamp:  now.UTC().Format(time.RFC3339),
+		Version:    1,
+		Tooling:    "snyk container sbom",
+		Statements: statements,
+	}, "", "  ")
+}
+
+func newOpenVEXProduct(id string, packages []affectedPackage) openVEXProduct {
+	product := openVEXProduct{ID: id}
+	for _, pkg := range packages {
+		if pkg.purl != "" {
+			product.Subcomponents = append(product.Subcomponents, openVEXComponent{ID: pkg.purl})
+		}
+	}
+	return product
+}
+
+// imagePURL returns the package URL of the image, which pins the image by digest if it is known.
+func imagePURL(subject Subject) string {
+	name := cmp.Or(subject.Repository, subject.Name)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if subject.Digest == "" {
+		purl := "pkg:docker/" + url.PathEscape(cmp.Or(subject.Repository, subject.Name))
+		if subject.Version != "" {
+			purl += "@" + url.PathEscape(subject.Version)
+		}
+		return purl
+	}
+
+	// the purl spec requires the colon of the digest to be encoded, which url.PathEscape leaves as is.
+	purl := "pkg:oci/" + url.PathEscape(name) + "@" + strings.ReplaceAll(subject.Digest, ":", "%3A")
+	var qualifiers []string
+	if subject.Registry != "" {
+		qualifiers = append(qualifiers, "repository_url="+subject.Registry+"/"+subject.Repository)
+	}
+	if subject.Tag != "" {
+		qualifiers = append(qualifiers, "tag="+url.QueryEscape(subject.Tag))
+	}
+	if len(qualifiers) > 0 {
+		purl += "?" + strings.Join(qualifiers, "&")
+	}
+	return purl
+}
@@ -0,0 +1,258 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/snyk/container-cli/internal/common/flags"
+	sbomconstants "github.com/snyk/container-cli/internal/workflows/sbom/constants"
+	"github.com/stretchr/testify/require"
+)
+
+// expectTestDepGraph makes the vulnerability client of the workflow report a single vulnerability and
+// a license issue for testpkg@10.10.
+func expectTestDepGraph(t *testing.T) {
+	t.Helper()
+
+	client := NewMockVulnerabilityClient(mockCtrl)
+	client.EXPECT().TestDepGraph(gomock.Any(), "aaacbb21-19b4-44f4-8483-d03746156f6b", gomock.Any()).
+		Return(&TestDepGraphResult{
+			Issues: []Issue{
+				{PkgName: "testpkg", PkgVersion: "10.10", IssueID: "SNYK-ALPINE317-TESTPKG-1",
+					FixInfo: FixInfo{NearestFixedInVersion: "10.11"}},
+				{PkgName: "testpkg", PkgVersion: "10.10", IssueID: "snyk:lic:alpine:testpkg:GPL-3.0"},
+			},
+			IssuesData: map[string]IssueData{
+				"SNYK-ALPINE317-TESTPKG-1": {
+					ID:          "SNYK-ALPINE317-TESTPKG-1",
+					Type:        "vuln",
+					Title:       "Out-of-bounds Write",
+					Description: "testpkg writes out of bounds.",
+					Severity:    "high",
+					CVSSScore:   7.5,
+					CVSSv3:      "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+					Identifiers: Identifiers{CVE: []string{"CVE-2023-1234"}, CWE: []string{"CWE-787"}},
+				},
+				"snyk:lic:alpine:testpkg:GPL-3.0": {ID: "snyk:lic:alpine:testpkg:GPL-3.0", Type: "license"},
+			},
+		}, nil)
+
+	sbomWorkflow.vulnClient = client
+	sbomWorkflow.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+}
+
+func Test_Entrypoint_GivenCycloneDXVulnerabilities_ShouldEmbedThemIntoSbom(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagVulnerabilities.Name, "cyclonedx")
+	expectSbomResultDoc(t)
+	expectTestDepGraph(t)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	payload, ok := result[0].GetPayload().([]byte)
+	require.True(t, ok)
+
+	// the document is extended rather than re-encoded.
+	original := strings.TrimSpace(string(getSbom(t, "testdata/sbom_result_doc.json")))
+	require.True(t, strings.HasPrefix(string(payload), strings.TrimSpace(strings.TrimSuffix(original, "}"))))
+
+	var bom struct {
+		Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &bom))
+	require.Equal(t, []cdxVulnerability{{
+		BOMRef: "SNYK-ALPINE317-TESTPKG-1",
+		ID:     "SNYK-ALPINE317-TESTPKG-1",
+		Source: cdxVulnSource{Name: "Snyk", URL: "https://security.snyk.io/vuln/SNYK-ALPINE317-TESTPKG-1"},
+		References: []cdxReference{{
+			ID:     "CVE-2023-1234",
+			Source: cdxVulnSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/CVE-2023-1234"},
+		}},
+		Ratings: []cdxRating{{
+			Source:   cdxVulnSource{Name: "Snyk"},
+			Score:    7.5,
+			Severity: "high",
+			Method:   "CVSSv31",
+			Vector:   "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+		}},
+		CWEs:           []int{787},
+		Description:    "Out-of-bounds Write",
+		Detail:         "testpkg writes out of bounds.",
+		Recommendation: "Upgrade testpkg to 10.11.",
+		Affects:        []cdxAffects{{Ref: "2-testpkg@10.10"}},
+	}}, bom.Vulnerabilities)
+}
+
+func Test_Entrypoint_GivenOpenVEXVulnerabilities_ShouldReturnVexAlongSbom(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	config.Set(flags.FlagVulnerabilities.Name, "openvex")
+	expectSbomResultDoc(t)
+	expectTestDepGraph(t)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+
+	require.Equal(t, getSbom(t, "testdata/sbom_result_doc.json"), result[0].GetPayload())
+	require.Equal(t, MIMETypeOpenVEX, result[1].GetContentType())
+
+	payload, ok := result[1].GetPayload().([]byte)
+	require.True(t, ok)
+
+	var vex openVEXDocument
+	require.NoError(t, json.Unmarshal(payload, &vex))
+	require.Equal(t, "https://openvex.dev/ns/v0.2.0", vex.Context)
+	require.True(t, strings.HasPrefix(vex.ID, "https://openvex.dev/docs/public/vex-"))
+	require.Equal(t, "2026-01-02T03:04:05Z", vex.Timestamp)
+	require.Equal(t, []openVEXStatement{{
+		Vulnerability: openVEXVulnerability{
+			ID:          "https://security.snyk.io/vuln/SNYK-ALPINE317-TESTPKG-1",
+			Name:        "CVE-2023-1234",
+			Description: "Out-of-bounds Write",
+			Aliases:     []string{"SNYK-ALPINE317-TESTPKG-1"},
+		},
+		Products: []openVEXProduct{{
+			ID: "pkg:oci/alpine@" + strings.ReplaceAll(testImageDigest.String(), ":", "%3A") +
+				"?repository_url=docker.io/library/alpine&tag=3.17.0",
+		}},
+		Status:          "affected",
+		ActionStatement: "Upgrade testpkg to 10.11.",
+	}}, vex.Statements)
+}
+
+func Test_Entrypoint_GivenOpenVEXAndOutputFile_ShouldWriteVexNextToSbom(t *testing.T) {
+	config := beforeEachInMemory(t, "alpine:3.17.0")
+	dir := t.TempDir()
+	config.Set(flags.FlagVulnerabilities.Name, "openvex")
+	config.Set(flags.FlagOutputFile.Name, filepath.Join(dir, "{name}-{tag}{ext}"))
+	expectSbomResultDoc(t)
+	expectTestDepGraph(t)
+
+	result, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	vexPath := filepath.Join(dir, "alpine-3.17.0.openvex.json")
+	require.Contains(t, string(result[0].GetPayload().([]byte)), "VEX for alpine:3.17.0 written to "+vexPath)
+	require.FileExists(t, filepath.Join(dir, "alpine-3.17.0.cdx.json"))
+	require.FileExists(t, vexPath)
+}
+
+func Test_Entrypoint_GivenInvalidVulnerabilities_ShouldReturnError(t *testing.T) {
+	tests := map[string]struct {
+		mode          string
+		format        string
+		offline       bool
+		expectedError string
+	}{
+		"unknown mode": {
+			mode:   "csaf",
+			format: "cyclonedx1.4+json",
+			expectedError: errFactory.NewInvalidVulnerabilitiesModeError(
+				"csaf", sbomconstants.VulnerabilitiesModes).Error(),
+		},
+		"cyclonedx mode with spdx format": {
+			mode:          "cyclonedx",
+			format:        "spdx2.3+json",
+			expectedError: errFactory.NewVulnerabilitiesFormatError("spdx2.3+json").Error(),
+		},
+		"cyclonedx mode with xml format": {
+			mode:          "cyclonedx",
+			format:        "cyclonedx1.4+xml",
+			expectedError: errFactory.NewVulnerabilitiesFormatError("cyclonedx1.4+xml").Error(),
+		},
+		"offline": {
+			mode:          "openvex",
+			format:        "spdx2.3+json",
+			offline:       true,
+			expectedError: errFactory.NewVulnerabilitiesOfflineError().Error(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := beforeEachInMemory(t, "alpine:3.17.0")
+			config.Set(flags.FlagVulnerabilities.Name, tc.mode)
+			config.Set(flags.FlagSbomFormat.Name, tc.format)
+			config.Set(flags.FlagOffline.Name, tc.offline)
+
+			_, err := sbomWorkflow.entrypoint(mockInvocationContext, nil)
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_EmbedVulnerabilities_GivenDocumentWithVulnerabilities_ShouldMergeThemInPlace(t *testing.T) {
+	doc := []byte(`{"bomFormat":"CycloneDX","vulnerabilities": [ {"id":"SNYK-1","bom-ref":"SNYK-1"} ],` +
+		`"components":[{"bom-ref":"pkg-a","name":"a","version":"1"}]}`)
+	affects := []affectedPackage{{name: "a", version: "1"}}
+	existing := &vulnerability{IssueData: IssueData{ID: "SNYK-1"}, affects: affects}
+	added := &vulnerability{IssueData: IssueData{ID: "SNYK-2"}, affects: affects}
+
+	result, err := embedVulnerabilities(doc, []*vulnerability{existing, added})
+	require.NoError(t, err)
+
+	addedJSON, err := json.Marshal(added.cycloneDX(map[string]string{"a@1": "pkg-a"}))
+	require.NoError(t, err)
+	require.Equal(t, `{"bomFormat":"CycloneDX","vulnerabilities": [{"id":"SNYK-1","bom-ref":"SNYK-1"},`+
+		string(addedJSON)+`],"components":[{"bom-ref":"pkg-a","name":"a","version":"1"}]}`, string(result))
+}
+
+func Test_ImagePURL_GivenSubject_ShouldReturnPackageURL(t *testing.T) {
+	tests := map[string]struct {
+		subject  Subject
+		expected string
+	}{
+		"digest and registry": {
+			subject: Subject{Name: "ghcr.io/acme/app", Registry: "ghcr.io", Repository: "acme/app",
+				Digest: "sha256:abc"},
+			expected: "pkg:oci/app@sha256%3Aabc?repository_url=ghcr.io/acme/app",
+		},
+		"no digest": {
+			subject:  Subject{Name: "app.tar", Version: "1.0"},
+			expected: "pkg:docker/app.tar@1.0",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, imagePURL(tc.subject))
+		})
+	}
+}
+
+func Test_WriteOutputFiles_GivenVexWithoutExtensionPlaceholder_ShouldSwapExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sbom.json")
+	out := sbomOutput{
+		target: "alpine:3.17.0",
+		result: &GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: "application/vnd.cyclonedx+json"},
+		vex:    &GetSbomForDepGraphResult{Doc: []byte("{}"), MIMEType: MIMETypeOpenVEX},
+	}
+
+	_, err := writeOutputFiles(path, []sbomOutput{out})
+	require.NoError(t, err)
+
+	_, err = os.Stat(strings.TrimSuffix(path, ".json") + ".openvex.json")
+	require.NoError(t, err)
+}
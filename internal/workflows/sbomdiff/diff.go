@@ -0,0 +1,216 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbomdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+)
+
+// imageComponentPrefix marks the component representing the image itself in depgraph based SBOMs,
+// which is not a package of the image.
+const imageComponentPrefix = "docker-image|"
+
+// componentChange is a component present on both sides with different versions.
+type componentChange struct {
+	Name        string `json:"name"`
+	PURL        string `json:"purl,omitempty"`
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+}
+
+// sbomDiff lists how the components of the head differ from the components of the base.
+type sbomDiff struct {
+	Base      string               `json:"base"`
+	Head      string               `json:"head"`
+	Added     []document.Component `json:"added"`
+	Removed   []document.Component `json:"removed"`
+	Changed   []componentChange    `json:"changed"`
+	Unchanged int                  `json:"unchanged"`
+}
+
+// computeDiff compares the components of two documents, see componentKey. A component listed with several
+// versions counts as changed if the set of versions differs.
+func computeDiff(base, head string, baseComponents, headComponents []document.Component) *sbomDiff {
+	d := &sbomDiff{
+		Base:    base,
+		Head:    head,
+		Added:   []document.Component{},
+		Removed: []document.Component{},
+		Changed: []componentChange{},
+	}
+
+	keys := purlKeysByName(baseComponents, headComponents)
+	from, to := groupByKey(baseComponents, keys), groupByKey(headComponents, keys)
+	for _, key := range sortedKeys(from, to) {
+		b, inBase := from[key]
+		h, inHead := to[key]
+		switch {
+		case !inBase:
+			d.Added = append(d.Added, h...)
+		case !inHead:
+			d.Removed = append(d.Removed, b...)
+		case slices.Equal(versions(b), versions(h)):
+			d.Unchanged++
+		default:
+			d.Changed = append(d.Changed, componentChange{
+				Name:        h[0].Name,
+				PURL:        h[0].PURL,
+				FromVersion: strings.Join(versions(b), ", "),
+				ToVersion:   strings.Join(versions(h), ", "),
+			})
+		}
+	}
+	return d
+}
+
+// groupByKey groups the components by their key. Components without package URL take the key of the
+// components with the same name and a package URL, if it is unambiguous.
+func groupByKey(components []document.Component, purlKeys map[string]string) map[string][]document.Component {
+	grouped := make(map[string][]document.Component)
+	for _, c := range components {
+		if c.Name == "" || strings.HasPrefix(c.Name, imageComponentPrefix) {
+			continue
+		}
+		key := componentKey(c)
+		if purlKey := purlKeys[c.Name]; c.PURL == "" && purlKey != "" {
+			key = purlKey
+		}
+		if !slices.ContainsFunc(grouped[key], func(o document.Component) bool { return o.Version == c.Version }) {
+			grouped[key] = append(grouped[key], c)
+		}
+	}
+	for _, cs := range grouped {
+		slices.SortFunc(cs, func(a, b document.Component) int { return strings.Compare(a.Version, b.Version) })
+	}
+	return grouped
+}
+
+// purlKeysByName maps the names of components to the key of their package URL, unless components of the
+// same name have different package URLs, e.g. because they are from different ecosystems.
+func purlKeysByName(components ...[]document.Component) map[string]string {
+	keys := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	for _, cs := range components {
+		for _, c := range cs {
+			if c.PURL == "" {
+				continue
+			}
+			key := componentKey(c)
+			if other, ok := keys[c.Name]; ok && other != key {
+				ambiguous[c.Name] = true
+			}
+			keys[c.Name] = key
+		}
+	}
+	for name := range ambiguous {
+		delete(keys, name)
+	}
+	return keys
+}
+
+// componentKey identifies a component regardless of its version. Components with a package URL are identified
+// by it without version, qualifiers and subpath, which tells packages of the same name from different ecosystems
+// apart and matches packages whose names differ between depgraphs and SBOM documents. Other components are
+// identified by their name.
+func componentKey(c document.Component) string {
+	purl, ok := strings.CutPrefix(c.PURL, "pkg:")
+	if !ok {
+		return c.Name
+	}
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		purl = purl[:i]
+	}
+	// the version follows the last "@" after the last "/", an "@" before it starts an npm scope.
+	if i := strings.LastIndex(purl, "@"); i > strings.LastIndex(purl, "/") {
+		purl = purl[:i]
+	}
+	// the type is case-insensitive.
+	typ, rest, _ := strings.Cut(purl, "/")
+	return "pkg:" + strings.ToLower(typ) + "/" + rest
+}
+
+func versions(components []document.Component) []string {
+	v := make([]string, 0, len(components))
+	for _, c := range components {
+		v = append(v, c.Version)
+	}
+	return v
+}
+
+func sortedKeys(maps ...map[string][]document.Component) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (d *sbomDiff) hasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+func (d *sbomDiff) renderJSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+func (d *sbomDiff) renderText() []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Comparing %s with %s\n", d.Base, d.Head)
+
+	if !d.hasChanges() {
+		sb.WriteString("\nNo components were added, removed or changed.\n")
+	}
+	if len(d.Added) > 0 {
+		fmt.Fprintf(&sb, "\nAdded (%d):\n", len(d.Added))
+		for _, c := range d.Added {
+			fmt.Fprintf(&sb, "  + %s\n", formatComponent(c))
+		}
+	}
+	if len(d.Removed) > 0 {
+		fmt.Fprintf(&sb, "\nRemoved (%d):\n", len(d.Removed))
+		for _, c := range d.Removed {
+			fmt.Fprintf(&sb, "  - %s\n", formatComponent(c))
+		}
+	}
+	if len(d.Changed) > 0 {
+		fmt.Fprintf(&sb, "\nChanged (%d):\n", len(d.Changed))
+		for _, c := range d.Changed {
+			fmt.Fprintf(&sb, "  ~ %s %s -> %s\n", c.Name, c.FromVersion, c.ToVersion)
+		}
+	}
+
+	fmt.Fprintf(&sb, "\nSummary: %d added, %d removed, %d changed, %d unchanged\n",
+		len(d.Added), len(d.Removed), len(d.Changed), d.Unchanged)
+	return []byte(sb.String())
+}
+
+func formatComponent(c document.Component) string {
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + "@" + c.Version
+}
@@ -0,0 +1,146 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbomdiff
+
+import (
+	"testing"
+
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ComputeDiff_GivenComponents_ShouldReportAddedRemovedAndChanged(t *testing.T) {
+	base := []document.Component{
+		{Name: "docker-image|alpine", Version: "3.17.0"},
+		{Name: "musl", Version: "1.2.3-r4", PURL: "pkg:apk/alpine/musl@1.2.3-r4"},
+		{Name: "busybox", Version: "1.35.0-r29"},
+		{Name: "openssl", Version: "3.0.8-r0"},
+		{Name: "lodash", Version: "4.17.20"},
+		{Name: "lodash", Version: "4.17.21"},
+	}
+	head := []document.Component{
+		{Name: "docker-image|alpine", Version: "3.18.0"},
+		{Name: "musl", Version: "1.2.4-r0", PURL: "pkg:apk/alpine/musl@1.2.4-r0"},
+		{Name: "busybox", Version: "1.35.0-r29"},
+		{Name: "libssl3", Version: "3.1.0-r4"},
+		{Name: "lodash", Version: "4.17.21"},
+		{Name: "lodash", Version: "4.17.21"},
+	}
+
+	d := computeDiff("alpine:3.17.0", "alpine:3.18.0", base, head)
+
+	require.Equal(t, []document.Component{{Name: "libssl3", Version: "3.1.0-r4"}}, d.Added)
+	require.Equal(t, []document.Component{{Name: "openssl", Version: "3.0.8-r0"}}, d.Removed)
+	require.Equal(t, []componentChange{
+		{Name: "lodash", FromVersion: "4.17.20, 4.17.21", ToVersion: "4.17.21"},
+		{Name: "musl", PURL: "pkg:apk/alpine/musl@1.2.4-r0", FromVersion: "1.2.3-r4", ToVersion: "1.2.4-r0"},
+	}, d.Changed)
+	require.Equal(t, 1, d.Unchanged)
+
+	require.Equal(t, `Comparing alpine:3.17.0 with alpine:3.18.0
+
+Added (1):
+  + libssl3@3.1.0-r4
+
+Removed (1):
+  - openssl@3.0.8-r0
+
+Changed (2):
+  ~ lodash 4.17.20, 4.17.21 -> 4.17.21
+  ~ musl 1.2.3-r4 -> 1.2.4-r0
+
+Summary: 1 added, 1 removed, 2 changed, 1 unchanged
+`, string(d.renderText()))
+}
+
+func Test_ComputeDiff_GivenSameNameInDifferentEcosystems_ShouldNotMergeThem(t *testing.T) {
+	base := []document.Component{
+		{Name: "debug", Version: "4.3.4", PURL: "pkg:npm/debug@4.3.4"},
+		{Name: "debug", Version: "1.0-1", PURL: "pkg:deb/debian/debug@1.0-1?arch=amd64"},
+	}
+	head := []document.Component{
+		{Name: "debug", Version: "4.3.4", PURL: "pkg:npm/debug@4.3.4"},
+		{Name: "debug", Version: "1.0-2", PURL: "pkg:deb/debian/debug@1.0-2?arch=amd64"},
+	}
+
+	d := computeDiff("a", "b", base, head)
+
+	require.Empty(t, d.Added)
+	require.Empty(t, d.Removed)
+	require.Equal(t, []componentChange{
+		{Name: "debug", PURL: "pkg:deb/debian/debug@1.0-2?arch=amd64", FromVersion: "1.0-1", ToVersion: "1.0-2"},
+	}, d.Changed)
+	require.Equal(t, 1, d.Unchanged)
+}
+
+func Test_ComputeDiff_GivenDifferentNamesOfSamePackage_ShouldMatchThemByPURL(t *testing.T) {
+	// depgraphs qualify debian packages with their source package, SBOM documents don't.
+	base := []document.Component{
+		{Name: "openssl/libssl3", Version: "3.0.11-1", PURL: "pkg:deb/debian/libssl3@3.0.11-1?upstream=openssl"},
+		{Name: "@babel/core", Version: "7.23.0", PURL: "pkg:npm/%40babel/core@7.23.0"},
+	}
+	head := []document.Component{
+		{Name: "libssl3", Version: "3.0.11-1", PURL: "pkg:DEB/debian/libssl3@3.0.11-1?distro=debian-12"},
+		{Name: "core", Version: "7.23.0", PURL: "pkg:npm/%40babel/core@7.23.0"},
+	}
+
+	d := computeDiff("image", "sbom.cdx.json", base, head)
+
+	require.False(t, d.hasChanges())
+	require.Equal(t, 2, d.Unchanged)
+}
+
+func Test_ComputeDiff_GivenComponentWithoutPURL_ShouldMatchItByName(t *testing.T) {
+	base := []document.Component{{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"}}
+	head := []document.Component{{Name: "lodash", Version: "4.17.21"}}
+
+	d := computeDiff("sbom.spdx.json", "image", base, head)
+
+	require.False(t, d.hasChanges())
+	require.Equal(t, 1, d.Unchanged)
+}
+
+func Test_ComponentKey_GivenComponent_ShouldOmitVersion(t *testing.T) {
+	tests := map[string]struct {
+		component document.Component
+		expected  string
+	}{
+		"no purl": {document.Component{Name: "musl", Version: "1.2.4"}, "musl"},
+		"qualifiers": {
+			document.Component{PURL: "pkg:apk/alpine/musl@1.2.4-r0?arch=x86_64"}, "pkg:apk/alpine/musl",
+		},
+		"scope":             {document.Component{PURL: "pkg:npm/@angular/core@16.0.0"}, "pkg:npm/@angular/core"},
+		"scope, no version": {document.Component{PURL: "pkg:npm/@angular/core"}, "pkg:npm/@angular/core"},
+		"subpath": {
+			document.Component{PURL: "pkg:golang/golang.org/x/text@v0.13.0#unicode"}, "pkg:golang/golang.org/x/text",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, componentKey(tc.component))
+		})
+	}
+}
+
+func Test_RenderText_GivenNoChanges_ShouldSaySo(t *testing.T) {
+	components := []document.Component{{Name: "musl", Version: "1.2.3-r4"}}
+
+	d := computeDiff("a.cdx.json", "b.spdx.json", components, components)
+
+	require.Equal(t, "Comparing a.cdx.json with b.spdx.json\n\n"+
+		"No components were added, removed or changed.\n\n"+
+		"Summary: 0 added, 0 removed, 0 changed, 1 unchanged\n", string(d.renderText()))
+}
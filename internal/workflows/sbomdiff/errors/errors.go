@@ -0,0 +1,81 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+)
+
+type SbomDiffErrorFactory struct {
+	*containererrors.ErrorFactory
+}
+
+func NewSbomDiffErrorFactory(logger *zerolog.Logger) *SbomDiffErrorFactory {
+	return &SbomDiffErrorFactory{
+		ErrorFactory: containererrors.NewErrorFactory(logger),
+	}
+}
+
+func (ef *SbomDiffErrorFactory) NewInvalidInputCountError(count int) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInvalidInputCount,
+		fmt.Errorf("expected two inputs, got %d", count),
+		"Please provide the two container images or SBOM documents to compare, "+
+			"e.g. `snyk container sbom diff alpine:3.17 alpine:3.18` "+
+			"or `snyk container sbom diff alpine:3.17 --compare-to=alpine:3.18`.",
+	)
+}
+
+func (ef *SbomDiffErrorFactory) NewSbomDocumentError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeSbomDocument,
+		fmt.Errorf("could not read sbom document %s: %w", path, err),
+		fmt.Sprintf(
+			"The SBOM document (%s) could not be read: %s. "+
+				"Supported formats are CycloneDX (JSON and XML) and SPDX (JSON).",
+			path,
+			err,
+		),
+	)
+}
+
+// NewDepGraphWorkflowError reports a failed analysis of the image. Errors the depgraph workflow already
+// explains to the user are returned as they are.
+func (ef *SbomDiffErrorFactory) NewDepGraphWorkflowError(
+	target string, err error,
+) *containererrors.ContainerExtensionError {
+	var xerr *containererrors.ContainerExtensionError
+	if errors.As(err, &xerr) {
+		return xerr
+	}
+	return ef.NewError(
+		containererrors.CodeDepGraphWorkflow,
+		fmt.Errorf("error while invoking depgraph workflow for %s: %w", target, err),
+		fmt.Sprintf("An error occurred while analysing the image %s which is needed to compare the SBOMs.", target),
+	)
+}
+
+func (ef *SbomDiffErrorFactory) NewInternalError(err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInternal,
+		err,
+		"An error occurred while comparing the SBOMs. "+
+			"Should this issue persist, please reach out to customer support.",
+	)
+}
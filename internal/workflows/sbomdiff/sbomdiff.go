@@ -0,0 +1,205 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbomdiff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/constants"
+	containerdepgraph "github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/image"
+	"github.com/snyk/container-cli/internal/common/workflows"
+	"github.com/snyk/container-cli/internal/workflows/depgraph"
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	sbomdifferrors "github.com/snyk/container-cli/internal/workflows/sbomdiff/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+const dataTypeSbomDiff = "sbom-diff"
+
+// Workflow represents the SBOM diff workflow
+type Workflow struct {
+	workflows.BaseWorkflow
+	depGraph   *depgraph.DepGraphWorkflow
+	errFactory *sbomdifferrors.SbomDiffErrorFactory
+}
+
+// NewWorkflow creates a new SBOM diff workflow value
+func NewWorkflow(errFactory *sbomdifferrors.SbomDiffErrorFactory) *Workflow {
+	return &Workflow{
+		BaseWorkflow: workflows.BaseWorkflow{
+			Name: "container sbom diff",
+			Flags: append(
+				[]flags.Flag{flags.FlagCompareTo, flags.FlagJSON},
+				flags.CommonFlags...,
+			),
+		},
+		depGraph:   depgraph.Workflow,
+		errFactory: errFactory,
+	}
+}
+
+// Init registers the workflow for the provided engine
+func (w *Workflow) Init(e workflow.Engine) error {
+	_, err := e.Register(
+		w.Identifier(),
+		w.GetConfigurationOptionsFromFlagSet(),
+		workflows.JSONErrors(w.entrypoint),
+	)
+	return err
+}
+
+func (w *Workflow) entrypoint(ictx workflow.InvocationContext, _ []workflow.Data) ([]workflow.Data, error) {
+	logger := ictx.GetEnhancedLogger()
+	logger.Info().Msg("starting the sbom diff workflow")
+
+	config := ictx.GetConfiguration()
+	if _, err := flags.Resolve(config, w.Flags); err != nil {
+		return nil, w.errFactory.NewConfigFileError(err)
+	}
+
+	inputs := diffInputs(config)
+	if len(inputs) != 2 {
+		return nil, w.errFactory.NewInvalidInputCountError(len(inputs))
+	}
+	base, head := inputs[0], inputs[1]
+
+	engine := ictx.GetEngine()
+	baseComponents, err := w.components(engine, logger, config, base)
+	if err != nil {
+		return nil, err
+	}
+	headComponents, err := w.components(engine, logger, config, head)
+	if err != nil {
+		return nil, err
+	}
+
+	d := computeDiff(base, head, baseComponents, headComponents)
+	logger.Info().Msgf("compared %s with %s: %d added, %d removed, %d changed",
+		base, head, len(d.Added), len(d.Removed), len(d.Changed))
+
+	if flags.FlagJSON.GetFlagValue(config) {
+		out, err := d.renderJSON()
+		if err != nil {
+			return nil, w.errFactory.NewInternalError(fmt.Errorf("failed to marshal sbom diff: %w", err))
+		}
+		return []workflow.Data{workflow.NewData(w.typeIdentifier(), constants.ContentTypeJSON, out)}, nil
+	}
+	return []workflow.Data{workflow.NewData(w.typeIdentifier(), "text/plain", d.renderText())}, nil
+}
+
+// diffInputs returns the images or SBOM documents to compare: the positional arguments, followed by
+// the value of --compare-to.
+func diffInputs(config configuration.Configuration) []string {
+	inputs := workflows.TargetArgs(config)
+	if compareTo := flags.FlagCompareTo.GetFlagValue(config); compareTo != "" {
+		inputs = append(inputs, compareTo)
+	}
+	return inputs
+}
+
+// components returns the components of one side of the diff, which is either an SBOM document or
+// a container image that is analysed with the depgraph workflow.
+func (w *Workflow) components(
+	engine workflow.Engine,
+	logger *zerolog.Logger,
+	config configuration.Configuration,
+	input string,
+) ([]document.Component, error) {
+	if isDocument(input) {
+		logger.Debug().Msgf("reading sbom document %s", input)
+		data, err := os.ReadFile(input)
+		if err != nil {
+			return nil, w.errFactory.NewSbomDocumentError(input, err)
+		}
+		doc, err := document.Parse(data)
+		if err != nil {
+			return nil, w.errFactory.NewSbomDocumentError(input, err)
+		}
+		return doc.Components, nil
+	}
+
+	logger.Debug().Msgf("invoking depgraph workflow for %s", input)
+	targetConfig := config.Clone()
+	targetConfig.Set(constants.ContainerTargetArgName, input)
+	depGraphs, err := engine.InvokeWithConfig(w.depGraph.Identifier(), targetConfig)
+	if err != nil {
+		return nil, w.errFactory.NewDepGraphWorkflowError(input, err)
+	}
+
+	components, err := depGraphComponents(depGraphs)
+	if err != nil {
+		return nil, w.errFactory.NewDepGraphWorkflowError(input, err)
+	}
+	return components, nil
+}
+
+// isDocument returns true if the input is an existing file which is not an image archive.
+func isDocument(input string) bool {
+	if _, ok := image.ParseReference(input); ok {
+		return false
+	}
+	info, err := os.Stat(input)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// depGraphComponents lists the packages of the depgraphs, except for their root packages which
+// represent the image or the application itself.
+func depGraphComponents(depGraphs []workflow.Data) ([]document.Component, error) {
+	if len(depGraphs) == 0 {
+		return nil, errors.New("no depgraphs found")
+	}
+
+	var components []document.Component
+	for _, data := range depGraphs {
+		payload, ok := data.GetPayload().([]byte)
+		if !ok {
+			return nil, fmt.Errorf("invalid payload type, want []byte, got %T", data.GetPayload())
+		}
+
+		var g containerdepgraph.DepGraph
+		if err := json.Unmarshal(payload, &g); err != nil {
+			return nil, fmt.Errorf("invalid depgraph: %w", err)
+		}
+
+		rootPkgID := ""
+		for _, node := range g.Graph.Nodes {
+			if node.NodeID == g.Graph.RootNodeID {
+				rootPkgID = node.PkgID
+			}
+		}
+		for _, pkg := range g.Pkgs {
+			if pkg.ID == rootPkgID {
+				continue
+			}
+			components = append(components, document.Component{
+				Name:    pkg.Info.Name,
+				Version: pkg.Info.Version,
+				PURL:    pkg.Info.PURL,
+			})
+		}
+	}
+	return components, nil
+}
+
+func (w *Workflow) typeIdentifier() workflow.Identifier {
+	return workflow.NewTypeIdentifier(w.Identifier(), dataTypeSbomDiff)
+}
@@ -0,0 +1,181 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbomdiff
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/container-cli/internal/common/depgraph"
+	"github.com/snyk/container-cli/internal/common/flags"
+	containerdepgraph "github.com/snyk/container-cli/internal/workflows/depgraph"
+	"github.com/snyk/container-cli/internal/workflows/sbom/document"
+	sbomdifferrors "github.com/snyk/container-cli/internal/workflows/sbomdiff/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/mocks"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var errFactory = sbomdifferrors.NewSbomDiffErrorFactory(&zlog.Logger)
+
+func Test_Entrypoint_GivenTwoImages_ShouldCompareTheirDepGraphs(t *testing.T) {
+	config, ictx, engine := setup(t)
+	config.Set(constants.ContainerTargetArgName, []string{"alpine:3.17.0", "alpine:3.18.0"})
+
+	engine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		DoAndReturn(func(_ workflow.Identifier, c configuration.Configuration) ([]workflow.Data, error) {
+			if c.GetString(constants.ContainerTargetArgName) == "alpine:3.17.0" {
+				return []workflow.Data{newDepGraph(t, "3.17.0", "musl@1.2.3-r4", "openssl@3.0.8-r0")}, nil
+			}
+			return []workflow.Data{newDepGraph(t, "3.18.0", "musl@1.2.4-r0", "libssl3@3.1.0-r4")}, nil
+		}).Times(2)
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, "text/plain", result[0].GetContentType())
+	require.Equal(t, `Comparing alpine:3.17.0 with alpine:3.18.0
+
+Added (1):
+  + libssl3@3.1.0-r4
+
+Removed (1):
+  - openssl@3.0.8-r0
+
+Changed (1):
+  ~ musl 1.2.3-r4 -> 1.2.4-r0
+
+Summary: 1 added, 1 removed, 1 changed, 0 unchanged
+`, string(result[0].GetPayload().([]byte)))
+}
+
+func Test_Entrypoint_GivenSbomDocumentAndImageAsJSON_ShouldOnlyAnalyseTheImage(t *testing.T) {
+	config, ictx, engine := setup(t)
+	base := "../sbom/testdata/local_sbom_spdx2.3+json.golden"
+	config.Set(constants.ContainerTargetArgName, base)
+	config.Set(flags.FlagCompareTo.Name, "alpine:3.18.0")
+	config.Set(flags.FlagJSON.Name, true)
+
+	engine.EXPECT().InvokeWithConfig(containerdepgraph.Workflow.Identifier(), gomock.Any()).
+		Return([]workflow.Data{newDepGraph(t, "3.18.0", "testpkg@10.11", "lodash@4.17.21")}, nil)
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, constants.ContentTypeJSON, result[0].GetContentType())
+
+	var d sbomDiff
+	require.NoError(t, json.Unmarshal(result[0].GetPayload().([]byte), &d))
+	require.Equal(t, sbomDiff{
+		Base:      base,
+		Head:      "alpine:3.18.0",
+		Added:     []document.Component{},
+		Removed:   []document.Component{},
+		Changed:   []componentChange{{Name: "testpkg", FromVersion: "10.10", ToVersion: "10.11"}},
+		Unchanged: 1,
+	}, d)
+}
+
+func Test_Entrypoint_GivenInvalidInputs_ShouldReturnError(t *testing.T) {
+	type test struct {
+		targets       any
+		expectedError error
+	}
+
+	invalidDocument := filepath.Join(t.TempDir(), "sbom.json")
+	writeFile(t, invalidDocument, `{"name":"not an sbom"}`)
+
+	tests := map[string]test{
+		"single input": {
+			targets:       "alpine:3.17.0",
+			expectedError: errFactory.NewInvalidInputCountError(1),
+		},
+		"three inputs": {
+			targets:       []string{"alpine:3.16", "alpine:3.17", "alpine:3.18"},
+			expectedError: errFactory.NewInvalidInputCountError(3),
+		},
+		"unsupported document": {
+			targets:       []string{invalidDocument, invalidDocument},
+			expectedError: errFactory.NewSbomDocumentError(invalidDocument, document.ErrUnsupportedFormat),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config, ictx, _ := setup(t)
+			config.Set(constants.ContainerTargetArgName, tc.targets)
+
+			_, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+			require.EqualError(t, err, tc.expectedError.Error())
+		})
+	}
+}
+
+func Test_Init_GivenWorkflowFlags_ShouldRegisterFlags(t *testing.T) {
+	config := configuration.New()
+	engine := workflow.NewWorkFlowEngine(config)
+
+	err := NewWorkflow(nil).Init(engine)
+	require.NoError(t, err)
+
+	require.NotNil(t, config.Get(flags.FlagCompareTo.Name))
+	require.NotNil(t, config.Get(flags.FlagJSON.Name))
+	require.NotNil(t, config.Get(flags.FlagPlatform.Name))
+}
+
+func setup(t *testing.T) (configuration.Configuration, *mocks.MockInvocationContext, *mocks.MockEngine) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	config := configuration.NewInMemory()
+	engine := mocks.NewMockEngine(ctrl)
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetConfiguration().Return(config)
+	ictx.EXPECT().GetEngine().Return(engine).MaxTimes(1)
+
+	return config, ictx, engine
+}
+
+// newDepGraph returns an alpine depgraph with the given "name@version" packages.
+func newDepGraph(t *testing.T, version string, pkgs ...string) workflow.Data {
+	t.Helper()
+
+	b := depgraph.NewBuilder(depgraph.PkgManager{Name: "apk"},
+		depgraph.PkgInfo{Name: "docker-image|alpine", Version: version})
+	for _, pkg := range pkgs {
+		name, v, _ := strings.Cut(pkg, "@")
+		b.AddDependency(depgraph.PkgInfo{Name: name, Version: v})
+	}
+	payload, err := json.Marshal(b.Build())
+	require.NoError(t, err)
+
+	return workflow.NewData(containerdepgraph.Workflow.TypeIdentifier(), constants.ContentTypeJSON, payload)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
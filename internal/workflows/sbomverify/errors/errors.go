@@ -0,0 +1,97 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/snyk/container-cli/internal/common/attestation"
+	containererrors "github.com/snyk/container-cli/internal/common/errors"
+)
+
+type SbomVerifyErrorFactory struct {
+	*containererrors.ErrorFactory
+}
+
+func NewSbomVerifyErrorFactory(logger *zerolog.Logger) *SbomVerifyErrorFactory {
+	return &SbomVerifyErrorFactory{
+		ErrorFactory: containererrors.NewErrorFactory(logger),
+	}
+}
+
+func (ef *SbomVerifyErrorFactory) NewEmptyAttestationError() *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeEmptyAttestation,
+		errors.New("no attestation provided"),
+		"Please provide the attestation to verify, "+
+			"e.g. `snyk container sbom verify alpine.dsse.json --public-key=key.pub`.",
+	)
+}
+
+func (ef *SbomVerifyErrorFactory) NewEmptyPublicKeyError() *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeEmptyPublicKey,
+		errors.New("no public key provided"),
+		"Please provide the public key to verify the attestation with, using --public-key.",
+	)
+}
+
+func (ef *SbomVerifyErrorFactory) NewPublicKeyError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodePublicKey,
+		fmt.Errorf("could not load public key %s: %w", path, err),
+		fmt.Sprintf(
+			"The public key (%s) could not be loaded: %s. "+
+				"Please provide an ECDSA or Ed25519 public key in PEM format.",
+			path, err,
+		),
+	)
+}
+
+func (ef *SbomVerifyErrorFactory) NewAttestationError(path string, err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeAttestation,
+		fmt.Errorf("could not read attestation %s: %w", path, err),
+		fmt.Sprintf("The attestation (%s) could not be read: %s.", path, err),
+	)
+}
+
+func (ef *SbomVerifyErrorFactory) NewVerificationFailedError(
+	path string, err error,
+) *containererrors.ContainerExtensionError {
+	userMsg := fmt.Sprintf("The attestation (%s) could not be verified: %s.", path, err)
+	if errors.Is(err, attestation.ErrNoValidSignature) {
+		userMsg = fmt.Sprintf(
+			"The attestation (%s) was not signed with the given public key or has been tampered with.",
+			path,
+		)
+	}
+	return ef.NewError(
+		containererrors.CodeVerificationFailed,
+		fmt.Errorf("verification of %s failed: %w", path, err),
+		userMsg,
+	)
+}
+
+func (ef *SbomVerifyErrorFactory) NewInternalError(err error) *containererrors.ContainerExtensionError {
+	return ef.NewError(
+		containererrors.CodeInternal,
+		err,
+		"An error occurred while verifying the attestation. "+
+			"Should this issue persist, please reach out to customer support.",
+	)
+}
@@ -0,0 +1,148 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbomverify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/snyk/container-cli/internal/common/attestation"
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/container-cli/internal/common/flags"
+	"github.com/snyk/container-cli/internal/common/workflows"
+	sbomverifyerrors "github.com/snyk/container-cli/internal/workflows/sbomverify/errors"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+)
+
+const dataTypeSbomVerify = "sbom-verify"
+
+// Workflow represents the SBOM attestation verify workflow
+type Workflow struct {
+	workflows.BaseWorkflow
+	errFactory *sbomverifyerrors.SbomVerifyErrorFactory
+}
+
+// NewWorkflow creates a new SBOM attestation verify workflow value
+func NewWorkflow(errFactory *sbomverifyerrors.SbomVerifyErrorFactory) *Workflow {
+	return &Workflow{
+		BaseWorkflow: workflows.BaseWorkflow{
+			Name:  "container sbom verify",
+			Flags: []flags.Flag{flags.FlagPublicKey, flags.FlagJSON},
+		},
+		errFactory: errFactory,
+	}
+}
+
+// Init registers the workflow for the provided engine
+func (w *Workflow) Init(e workflow.Engine) error {
+	_, err := e.Register(
+		w.Identifier(),
+		w.GetConfigurationOptionsFromFlagSet(),
+		workflows.JSONErrors(w.entrypoint),
+	)
+	return err
+}
+
+func (w *Workflow) entrypoint(ictx workflow.InvocationContext, _ []workflow.Data) ([]workflow.Data, error) {
+	logger := ictx.GetEnhancedLogger()
+	logger.Info().Msg("starting the sbom verify workflow")
+
+	config := ictx.GetConfiguration()
+	if _, err := flags.Resolve(config, w.Flags); err != nil {
+		return nil, w.errFactory.NewConfigFileError(err)
+	}
+
+	path := config.GetString(constants.ContainerTargetArgName)
+	if path == "" {
+		return nil, w.errFactory.NewEmptyAttestationError()
+	}
+	publicKey := flags.FlagPublicKey.GetFlagValue(config)
+	if publicKey == "" {
+		return nil, w.errFactory.NewEmptyPublicKeyError()
+	}
+
+	logger.Debug().Msg("loading the public key")
+	verifier, err := attestation.LoadVerifier(publicKey)
+	if err != nil {
+		return nil, w.errFactory.NewPublicKeyError(publicKey, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, w.errFactory.NewAttestationError(path, err)
+	}
+
+	logger.Debug().Msgf("verifying attestation %s", path)
+	statement, err := verify(data, verifier)
+	if err != nil {
+		return nil, w.errFactory.NewVerificationFailedError(path, err)
+	}
+	logger.Info().Msgf("attestation %s verified with key %s", path, verifier.KeyID())
+
+	if flags.FlagJSON.GetFlagValue(config) {
+		out, err := json.Marshal(statement)
+		if err != nil {
+			return nil, w.errFactory.NewInternalError(fmt.Errorf("failed to marshal statement: %w", err))
+		}
+		return []workflow.Data{workflow.NewData(w.typeIdentifier(), constants.ContentTypeJSON, out)}, nil
+	}
+	return []workflow.Data{
+		workflow.NewData(w.typeIdentifier(), "text/plain", renderText(statement, verifier.KeyID())),
+	}, nil
+}
+
+// verify checks the signature of the DSSE envelope and returns the in-toto statement it carries.
+func verify(data []byte, verifier attestation.Verifier) (*attestation.Statement, error) {
+	var envelope attestation.Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid dsse envelope: %w", err)
+	}
+	if envelope.PayloadType != attestation.PayloadTypeInToto {
+		return nil, fmt.Errorf("unsupported payload type %q", envelope.PayloadType)
+	}
+
+	payload, err := envelope.Verify(verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var statement attestation.Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("invalid in-toto statement: %w", err)
+	}
+	if statement.Type != attestation.StatementType {
+		return nil, fmt.Errorf("unsupported statement type %q", statement.Type)
+	}
+	return &statement, nil
+}
+
+// renderText returns a human readable summary of a verified statement.
+func renderText(statement *attestation.Statement, keyID string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Verified attestation signed with key %s\n", keyID)
+	fmt.Fprintf(&sb, "Predicate type: %s\n", statement.PredicateType)
+	for _, s := range statement.Subject {
+		for algorithm, value := range s.Digest {
+			fmt.Fprintf(&sb, "Subject: %s@%s:%s\n", s.Name, algorithm, value)
+		}
+	}
+	return []byte(sb.String())
+}
+
+func (w *Workflow) typeIdentifier() workflow.Identifier {
+	return workflow.NewTypeIdentifier(w.Identifier(), dataTypeSbomVerify)
+}
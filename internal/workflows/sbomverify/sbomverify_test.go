@@ -0,0 +1,179 @@
+// © 2023-2026 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbomverify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/opencontainers/go-digest"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/snyk/container-cli/internal/common/attestation"
+	"github.com/snyk/container-cli/internal/common/attestation/attestationtest"
+	"github.com/snyk/container-cli/internal/common/constants"
+	"github.com/snyk/container-cli/internal/common/flags"
+	sbomverifyerrors "github.com/snyk/container-cli/internal/workflows/sbomverify/errors"
+	"github.com/snyk/go-application-framework/pkg/configuration"
+	"github.com/snyk/go-application-framework/pkg/mocks"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errFactory  = sbomverifyerrors.NewSbomVerifyErrorFactory(&zlog.Logger)
+	imageDigest = digest.FromString("alpine manifest")
+)
+
+func Test_Entrypoint_GivenValidAttestation_ShouldReturnSummary(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.Ed25519)
+	config, ictx := setup(t)
+	config.Set(constants.ContainerTargetArgName, writeAttestation(t, keys.PrivateKeyPath, attestation.PayloadTypeInToto))
+	config.Set(flags.FlagPublicKey.Name, keys.PublicKeyPath)
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	verifier, err := attestation.LoadVerifier(keys.PublicKeyPath)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, "text/plain", result[0].GetContentType())
+	require.Equal(t, "Verified attestation signed with key "+verifier.KeyID()+"\n"+
+		"Predicate type: https://cyclonedx.org/bom\n"+
+		"Subject: docker.io/library/alpine@"+imageDigest.String()+"\n",
+		string(result[0].GetPayload().([]byte)))
+}
+
+func Test_Entrypoint_GivenValidAttestationAsJSON_ShouldReturnStatement(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.ECDSAP384)
+	config, ictx := setup(t)
+	config.Set(constants.ContainerTargetArgName, writeAttestation(t, keys.PrivateKeyPath, attestation.PayloadTypeInToto))
+	config.Set(flags.FlagPublicKey.Name, keys.PublicKeyPath)
+	config.Set(flags.FlagJSON.Name, true)
+
+	result, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	require.Equal(t, constants.ContentTypeJSON, result[0].GetContentType())
+
+	var statement attestation.Statement
+	require.NoError(t, json.Unmarshal(result[0].GetPayload().([]byte), &statement))
+	require.Equal(t, attestation.PredicateTypeCycloneDX, statement.PredicateType)
+	require.JSONEq(t, `{"bomFormat":"CycloneDX"}`, string(statement.Predicate))
+}
+
+func Test_Entrypoint_GivenInvalidInput_ShouldReturnError(t *testing.T) {
+	keys := attestationtest.GenerateKeyPair(t, attestationtest.ECDSAP256)
+	otherKeys := attestationtest.GenerateKeyPair(t, attestationtest.ECDSAP256)
+	valid := writeAttestation(t, keys.PrivateKeyPath, attestation.PayloadTypeInToto)
+	wrongPayloadType := writeAttestation(t, keys.PrivateKeyPath, "application/json")
+	missing := filepath.Join(t.TempDir(), "missing.dsse.json")
+
+	tests := map[string]struct {
+		attestation   string
+		publicKey     string
+		expectedError string
+	}{
+		"no attestation": {
+			publicKey:     keys.PublicKeyPath,
+			expectedError: errFactory.NewEmptyAttestationError().Error(),
+		},
+		"no public key": {
+			attestation:   valid,
+			expectedError: errFactory.NewEmptyPublicKeyError().Error(),
+		},
+		"private key as public key": {
+			attestation:   valid,
+			publicKey:     keys.PrivateKeyPath,
+			expectedError: `unsupported PEM block "PRIVATE KEY"`,
+		},
+		"missing attestation": {
+			attestation:   missing,
+			publicKey:     keys.PublicKeyPath,
+			expectedError: "could not be read",
+		},
+		"other key": {
+			attestation:   valid,
+			publicKey:     otherKeys.PublicKeyPath,
+			expectedError: "was not signed with the given public key or has been tampered with",
+		},
+		"unsupported payload type": {
+			attestation:   wrongPayloadType,
+			publicKey:     keys.PublicKeyPath,
+			expectedError: `unsupported payload type "application/json"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config, ictx := setup(t)
+			config.Set(constants.ContainerTargetArgName, tc.attestation)
+			config.Set(flags.FlagPublicKey.Name, tc.publicKey)
+
+			_, err := NewWorkflow(errFactory).entrypoint(ictx, nil)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_Init_GivenWorkflowFlags_ShouldRegisterFlags(t *testing.T) {
+	config := configuration.New()
+	engine := workflow.NewWorkFlowEngine(config)
+
+	err := NewWorkflow(nil).Init(engine)
+	require.NoError(t, err)
+
+	require.NotNil(t, config.Get(flags.FlagPublicKey.Name))
+	require.NotNil(t, config.Get(flags.FlagJSON.Name))
+}
+
+func setup(t *testing.T) (configuration.Configuration, *mocks.MockInvocationContext) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	config := configuration.NewInMemory()
+
+	ictx := mocks.NewMockInvocationContext(ctrl)
+	ictx.EXPECT().GetEnhancedLogger().Return(&zlog.Logger)
+	ictx.EXPECT().GetConfiguration().Return(config)
+
+	return config, ictx
+}
+
+// writeAttestation signs an in-toto statement about alpine and returns the path of the envelope.
+func writeAttestation(t *testing.T, privateKeyPath, payloadType string) string {
+	t.Helper()
+
+	signer, err := attestation.LoadSigner(privateKeyPath)
+	require.NoError(t, err)
+
+	statement := attestation.NewStatement("docker.io/library/alpine", imageDigest,
+		attestation.PredicateTypeCycloneDX, json.RawMessage(`{"bomFormat":"CycloneDX"}`))
+	payload, err := json.Marshal(statement)
+	require.NoError(t, err)
+
+	envelope, err := attestation.Sign(payloadType, payload, signer)
+	require.NoError(t, err)
+	out, err := json.Marshal(envelope)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "alpine.dsse.json")
+	require.NoError(t, os.WriteFile(path, out, 0o600))
+	return path
+}
@@ -17,9 +17,18 @@ package container
 import (
 	"fmt"
 
+	"github.com/snyk/container-cli/internal/common/registry"
+	"github.com/snyk/container-cli/internal/workflows/cacheprune"
+	cachepruneerrors "github.com/snyk/container-cli/internal/workflows/cacheprune/errors"
+	"github.com/snyk/container-cli/internal/workflows/config"
+	configerrors "github.com/snyk/container-cli/internal/workflows/config/errors"
 	"github.com/snyk/container-cli/internal/workflows/depgraph"
 	"github.com/snyk/container-cli/internal/workflows/sbom"
 	sbomerrors "github.com/snyk/container-cli/internal/workflows/sbom/errors"
+	"github.com/snyk/container-cli/internal/workflows/sbomdiff"
+	sbomdifferrors "github.com/snyk/container-cli/internal/workflows/sbomdiff/errors"
+	"github.com/snyk/container-cli/internal/workflows/sbomverify"
+	sbomverifyerrors "github.com/snyk/container-cli/internal/workflows/sbomverify/errors"
 	"github.com/snyk/go-application-framework/pkg/configuration"
 	"github.com/snyk/go-application-framework/pkg/workflow"
 )
@@ -30,6 +39,22 @@ func Init(e workflow.Engine) error {
 		return fmt.Errorf("could not initialise container sbom workflow: %w", err)
 	}
 
+	if err := initSbomDiffWorkflow(e); err != nil {
+		return fmt.Errorf("could not initialise container sbom diff workflow: %w", err)
+	}
+
+	if err := initSbomVerifyWorkflow(e); err != nil {
+		return fmt.Errorf("could not initialise container sbom verify workflow: %w", err)
+	}
+
+	if err := initCachePruneWorkflow(e); err != nil {
+		return fmt.Errorf("could not initialise container cache prune workflow: %w", err)
+	}
+
+	if err := initConfigWorkflow(e); err != nil {
+		return fmt.Errorf("could not initialise container config workflow: %w", err)
+	}
+
 	if err := depgraph.Workflow.InitWorkflow(e); err != nil {
 		return fmt.Errorf("could not initialise container depgraph workflow: %w", err)
 	}
@@ -40,12 +65,38 @@ func Init(e workflow.Engine) error {
 func initSbomWorkflow(e workflow.Engine) error {
 	errFactory := sbomerrors.NewSbomErrorFactory(e.GetLogger())
 
-	sbomWorkflow := sbom.NewWorkflow(sbom.NewHTTPSbomClient(sbom.HTTPSbomClientConfig{
-		APIHost:    e.GetConfiguration().GetString(configuration.API_URL),
-		Client:     e.GetNetworkAccess().GetHttpClient(),
-		Logger:     e.GetLogger(),
+	// the SBOM API client also tests depgraphs for vulnerabilities. The workflow replaces the limits of the retry
+	// policy by the ones of --retry-max-attempts and --retry-max-backoff.
+	httpClient := sbom.NewHTTPSbomClient(sbom.HTTPSbomClientConfig{
+		APIHost:     e.GetConfiguration().GetString(configuration.API_URL),
+		Client:      e.GetNetworkAccess().GetHttpClient(),
+		Logger:      e.GetLogger(),
+		ErrFactory:  errFactory,
+		RetryPolicy: sbom.DefaultRetryPolicy,
+	})
+
+	sbomWorkflow := sbom.NewWorkflow(httpClient, sbom.NewLocalSbomClient(sbom.LocalSbomClientConfig{
 		ErrFactory: errFactory,
+	}), httpClient, registry.NewClient(registry.ClientConfig{
+		Client: e.GetNetworkAccess().GetUnauthorizedHttpClient(),
+		Logger: e.GetLogger(),
 	}), errFactory)
 
 	return sbomWorkflow.Init(e)
 }
+
+func initSbomDiffWorkflow(e workflow.Engine) error {
+	return sbomdiff.NewWorkflow(sbomdifferrors.NewSbomDiffErrorFactory(e.GetLogger())).Init(e)
+}
+
+func initSbomVerifyWorkflow(e workflow.Engine) error {
+	return sbomverify.NewWorkflow(sbomverifyerrors.NewSbomVerifyErrorFactory(e.GetLogger())).Init(e)
+}
+
+func initCachePruneWorkflow(e workflow.Engine) error {
+	return cacheprune.NewWorkflow(cachepruneerrors.NewCachePruneErrorFactory(e.GetLogger())).Init(e)
+}
+
+func initConfigWorkflow(e workflow.Engine) error {
+	return config.NewWorkflow(configerrors.NewConfigErrorFactory(e.GetLogger())).Init(e)
+}